@@ -97,6 +97,20 @@ func AnyArrayToFloat64Array(input any) ([]float64, bool) {
 	case []float64:
 		return val, true
 
+	case []any:
+		out := make([]float64, len(val))
+
+		for idx, elt := range val {
+			flt, ok := ToFloat64(elt)
+			if !ok {
+				return nil, false
+			}
+
+			out[idx] = flt
+		}
+
+		return out, true
+
 	default:
 		return nil, false
 	}
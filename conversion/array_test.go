@@ -0,0 +1,128 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// array_test.go --- Array conversion tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package conversion
+
+// * Imports:
+
+import (
+	"reflect"
+	"testing"
+)
+
+// * Code:
+
+// ** Types:
+
+type testStringer string
+
+// ** Methods:
+
+func (s testStringer) String() string {
+	return string(s)
+}
+
+// ** Tests:
+
+func TestAnyArrayToFloat64Array(t *testing.T) {
+	want := []struct {
+		name   string
+		val    any
+		want   []float64
+		result bool
+	}{
+		{"[]int", []int{1, 2}, []float64{1, 2}, true},
+		{"[]float64", []float64{1.5}, []float64{1.5}, true},
+		{"[]any", []any{1, int8(2), uint64(3), float32(4.5)}, []float64{1, 2, 3, 4.5}, true},
+		{"[]any empty", []any{}, []float64{}, true},
+		{"[]any non-numeric", []any{1, "two"}, nil, false},
+		{"[]any nil", []any{nil}, nil, false},
+		{"[]string", []string{"1"}, nil, false},
+		{"scalar", 42, nil, false},
+	}
+
+	for idx := range want {
+		t.Run(want[idx].name, func(t *testing.T) {
+			res, ok := AnyArrayToFloat64Array(want[idx].val)
+
+			if ok != want[idx].result {
+				t.Fatalf("ok != %#v", want[idx].result)
+			}
+
+			if !reflect.DeepEqual(res, want[idx].want) {
+				t.Errorf("%#v != %#v", want[idx].want, res)
+			}
+		})
+	}
+}
+
+func TestAnyArrayToStringArray(t *testing.T) {
+	want := []struct {
+		name   string
+		val    any
+		want   []string
+		result bool
+	}{
+		{"[]string", []string{"a", "b"}, []string{"a", "b"}, true},
+		{"[][]byte", [][]byte{[]byte("a")}, []string{"a"}, true},
+		{
+			"[]any",
+			[]any{"a", testStringer("b"), []byte("c"), []rune("d")},
+			[]string{"a", "b", "c", "d"},
+			true,
+		},
+		{"[]any empty", []any{}, []string{}, true},
+		{"[]any non-string", []any{"a", 1}, nil, false},
+		{"[]any nil", []any{nil}, nil, false},
+		{"[]int", []int{1}, nil, false},
+		{"scalar", "a", nil, false},
+	}
+
+	for idx := range want {
+		t.Run(want[idx].name, func(t *testing.T) {
+			res, ok := AnyArrayToStringArray(want[idx].val)
+
+			if ok != want[idx].result {
+				t.Fatalf("ok != %#v", want[idx].result)
+			}
+
+			if !reflect.DeepEqual(res, want[idx].want) {
+				t.Errorf("%#v != %#v", want[idx].want, res)
+			}
+		})
+	}
+}
+
+// * array_test.go ends here.
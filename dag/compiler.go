@@ -41,6 +41,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/Asmodai/gohacks/contextdi"
 	"github.com/Asmodai/gohacks/logger"
@@ -60,6 +61,7 @@ var (
 	ErrUnknownOperator      = errors.Base("unknown operator")
 	ErrRuleCompileFailed    = errors.Base("rule compilation failed")
 	ErrRuleActionAndActions = errors.Base("rule contains action and actions")
+	ErrInvalidCondition     = errors.Base("invalid condition")
 )
 
 // * Code:
@@ -71,7 +73,9 @@ type Compiler interface {
 	CompileFailure(FailureSpec) (ActionFn, error)
 	Compile([]RuleSpec) []error
 	Evaluate(Filterable)
+	EvaluateWithTrace(Filterable) *Trace
 	Export(io.Writer)
+	ExportTrace(io.Writer, *Trace)
 }
 
 // ** Types:
@@ -169,7 +173,7 @@ func (cmplr *compiler) compileRule(rule RuleSpec) error {
 	}
 
 	for _, cond := range rule.Conditions {
-		pred, key, err := cmplr.buildPredicate(cond)
+		next, _, err := cmplr.compileCondition(cond)
 		if err != nil {
 			return errors.WithMessagef(
 				err,
@@ -177,7 +181,6 @@ func (cmplr *compiler) compileRule(rule RuleSpec) error {
 				rule.Name)
 		}
 
-		next := cmplr.getOrCreateNode(pred, key)
 		cmplr.linkNodes(current, next)
 
 		current = next
@@ -197,6 +200,107 @@ func (cmplr *compiler) compileRule(rule RuleSpec) error {
 	return nil
 }
 
+// Compile a condition specification into a node.
+//
+// Returns the node along with its cache key.
+//
+//nolint:cyclop
+func (cmplr *compiler) compileCondition(cond ConditionSpec) (*node, string, error) {
+	groups := 0
+
+	for _, present := range []bool{cond.Not != nil, cond.All != nil, cond.Any != nil} {
+		if present {
+			groups++
+		}
+	}
+
+	switch {
+	case groups > 0 && cond.Operator != "":
+		return nil, "", errors.WithMessagef(
+			ErrInvalidCondition,
+			"Condition %q has both an operator and a group",
+			cond.Attribute)
+
+	case groups > 1:
+		return nil, "", errors.WithMessage(
+			ErrInvalidCondition,
+			"Condition has more than one group")
+
+	case cond.Not != nil:
+		return cmplr.compileGroup(notToken, []ConditionSpec{*cond.Not})
+
+	case cond.All != nil:
+		return cmplr.compileGroup(allToken, cond.All)
+
+	case cond.Any != nil:
+		return cmplr.compileGroup(anyToken, cond.Any)
+
+	case cond.Operator == "":
+		return nil, "", errors.WithMessagef(
+			ErrInvalidCondition,
+			"Condition %q has no operator",
+			cond.Attribute)
+	}
+
+	pred, key, err := cmplr.buildPredicate(cond)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cmplr.getOrCreateNode(pred, key), key, nil
+}
+
+// Compile a group of conditions into a node whose predicate combines
+// those of its operand nodes.
+//
+// Operands are compiled like any other condition, so they share nodes
+// with identical conditions elsewhere.
+func (cmplr *compiler) compileGroup(token string, specs []ConditionSpec) (*node, string, error) {
+	if len(specs) == 0 {
+		return nil, "", errors.WithMessagef(
+			ErrInvalidCondition,
+			"Empty %q group",
+			token)
+	}
+
+	operands := make([]*node, 0, len(specs))
+	preds := make([]Predicate, 0, len(specs))
+	keys := make([]string, 0, len(specs))
+
+	for _, spec := range specs {
+		operand, key, err := cmplr.compileCondition(spec)
+		if err != nil {
+			return nil, "", err
+		}
+
+		operands = append(operands, operand)
+		preds = append(preds, operand.Predicate)
+		keys = append(keys, key)
+	}
+
+	var pred Predicate
+
+	switch token {
+	case allToken:
+		pred = &ALLPredicate{operands: preds}
+
+	case anyToken:
+		pred = &ANYPredicate{operands: preds}
+
+	default:
+		pred = &NOTPredicate{operand: preds[0]}
+	}
+
+	key := token + "(" + strings.Join(keys, "; ") + ")"
+
+	group := cmplr.getOrCreateNode(pred, key)
+	if group.Operands == nil {
+		group.Operands = operands
+	}
+
+	return group, key, nil
+}
+
 // Build a predicate from a condition specification.
 func (cmplr *compiler) buildPredicate(cond ConditionSpec) (Predicate, string, error) {
 	builder, ok := cmplr.predicates[cond.Operator]
@@ -358,7 +462,24 @@ func (cmplr *compiler) Evaluate(input Filterable) {
 		cmplr.root,
 		input,
 		cmplr.debugMode,
-		cmplr.lgr)
+		cmplr.lgr,
+		nil,
+		0)
+}
+
+// Evaluate an input against the DAG, recording a trace of the evaluation.
+func (cmplr *compiler) EvaluateWithTrace(input Filterable) *Trace {
+	trace := newTrace(input)
+
+	traverse(cmplr.ctx,
+		cmplr.root,
+		input,
+		cmplr.debugMode,
+		cmplr.lgr,
+		trace,
+		0)
+
+	return trace
 }
 
 // Export the compiler's rulesets to GraphViz DOT format.
@@ -366,6 +487,12 @@ func (cmplr *compiler) Export(writer io.Writer) {
 	ExportToDOT(writer, cmplr.root)
 }
 
+// Export the compiler's rulesets to GraphViz DOT format, highlighting the
+// path taken in the given trace.
+func (cmplr *compiler) ExportTrace(writer io.Writer, trace *Trace) {
+	ExportTraceToDOT(writer, cmplr.root, trace)
+}
+
 // ** Functions:
 
 // Return a new DAG compiler.
@@ -406,6 +406,156 @@ func TestCompiler(t *testing.T) {
 	})
 }
 
+func TestCompilerGroups(t *testing.T) {
+	const groupRules = `
+- name: storm
+  conditions:
+    - attribute: type
+      operator: string-equal
+      value: weather
+    - any:
+        - attribute: temp
+          operator: '>='
+          value: 30
+        - all:
+            - attribute: temp
+              operator: '<='
+              value: 0
+            - not:
+                attribute: wind
+                operator: '<'
+                value: 10
+  action:
+    name: storm_action
+    perform: log
+    params:
+      message: Storm!
+- name: heat
+  conditions:
+    - attribute: temp
+      operator: '>='
+      value: 30
+  action:
+    name: heat_action
+    perform: log
+    params:
+      message: Hot!`
+
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("Could not set logger DI: %#v", err)
+	}
+
+	rules, err := ParseFromYAML(groupRules)
+	if err != nil {
+		t.Fatalf("YAML: %#v", err)
+	}
+
+	mact := &MockActions{}
+	cmplr := NewCompiler(ctx, mact)
+
+	if issues := cmplr.Compile(rules); len(issues) > 0 {
+		t.Fatalf("Compiler issues: %v", issues)
+	}
+
+	t.Run("Evaluate", func(t *testing.T) {
+		tests := []struct {
+			input map[string]any
+			want  bool
+		}{
+			{map[string]any{"type": "weather", "temp": 35}, true},
+			{map[string]any{"type": "weather", "temp": -5, "wind": 40}, true},
+			{map[string]any{"type": "weather", "temp": -5, "wind": 5}, false},
+			{map[string]any{"type": "weather", "temp": -5}, true},
+			{map[string]any{"type": "weather", "temp": 15, "wind": 40}, false},
+		}
+
+		for idx, test := range tests {
+			mact.hasRunLog = false
+			cmplr.Evaluate(NewDataInputFromMap(test.input))
+
+			if mact.hasRunLog != test.want {
+				t.Errorf("%d: %v != %v", idx, mact.hasRunLog, test.want)
+			}
+		}
+	})
+
+	t.Run("Shared operands", func(t *testing.T) {
+		impl, ok := cmplr.(*compiler)
+		if !ok {
+			t.Fatal("Not a compiler")
+		}
+
+		heat := impl.nodeCache["temp >= 30"]
+		if heat == nil {
+			t.Fatal("Leaf node not cached")
+		}
+
+		storm := impl.nodeCache["type string-equal weather"]
+		if storm == nil || len(storm.Children) != 1 {
+			t.Fatal("Rule chain not built")
+		}
+
+		group := storm.Children[0]
+		if group.Predicate.Instruction() != anyIsn || group.Operands[0] != heat {
+			t.Error("Group operand is not the shared leaf node")
+		}
+
+		// type, temp >= 30, temp <= 0, wind < 10, not, all, any.
+		if len(impl.nodeCache) != 7 {
+			t.Errorf("Node count mismatch: %d != 7", len(impl.nodeCache))
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		var sbld strings.Builder
+
+		cmplr.Export(&sbld)
+
+		for _, want := range []string{"<B>ANY</B>", "<B>NOT</B>", "arrowhead=odiamond"} {
+			if !strings.Contains(sbld.String(), want) {
+				t.Errorf("DOT output lacks %q", want)
+			}
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		dumped, err := DumpRulesToYAML(rules)
+		if err != nil {
+			t.Fatalf("Dump: %#v", err)
+		}
+
+		again, err := ParseFromYAML(dumped)
+		if err != nil {
+			t.Fatalf("YAML: %#v", err)
+		}
+
+		if again[0].Conditions[1].Any[1].All[1].Not.Attribute != "wind" {
+			t.Errorf("Groups lost in round trip:\n%s", dumped)
+		}
+	})
+
+	t.Run("Invalid groups", func(t *testing.T) {
+		bad := []ConditionSpec{
+			{Any: []ConditionSpec{}},
+			{Operator: ">=", Attribute: "temp", Value: 1, Not: &ConditionSpec{}},
+			{All: []ConditionSpec{{Attribute: "x", Operator: "==", Value: 1}}, Any: []ConditionSpec{}},
+			{Not: &ConditionSpec{Attribute: "x"}},
+		}
+
+		for idx, cond := range bad {
+			issues := NewCompiler(ctx, mact).Compile([]RuleSpec{{
+				Name:       "bad",
+				Conditions: []ConditionSpec{cond},
+			}})
+
+			if len(issues) != 1 || !errors.Is(issues[0], ErrInvalidCondition) {
+				t.Errorf("%d: unexpected issues: %v", idx, issues)
+			}
+		}
+	})
+}
+
 func TestUtilities(t *testing.T) {
 	t.Run("ParseFromYAML", func(t *testing.T) {
 		_, err := ParseFromYAML(rulesYAML)
@@ -54,6 +54,13 @@ const (
 
 	rankDirTB string = "TB"
 	rankDirLR string = "LR"
+
+	tracePassed   string = `, color="#2e7d32", penwidth=3`
+	traceFailed   string = `, color="#c62828", penwidth=3`
+	traceUntaken  string = `, color="#bdbdbd"`
+	traceFired    string = `, penwidth=3`
+	tableUntraced string = `<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0" CELLPADDING="4" BGCOLOR="#d8eaf8">`
+	tableTraced   string = `<TABLE BORDER="3" COLOR="%s" CELLBORDER="1" CELLSPACING="0" CELLPADDING="4" BGCOLOR="%s">`
 )
 
 // * Code:
@@ -84,6 +91,9 @@ type DOTOptions struct {
 
 	// Maximum number of items to show inline before truncating.
 	MaxInlineItems int
+
+	// Evaluation trace to overlay on the graph, if any.
+	Trace *Trace
 }
 
 // ** DOT Emitter:
@@ -114,28 +124,92 @@ func (d *dotEmitter) summariseNames(list []*nodeAction, limit int) string {
 	return strings.Join(names, ", ")
 }
 
+// Return extra attributes for an edge to the given node.
+//
+// With a trace, edges to visited nodes are drawn in the colour of the
+// node's result and the rest are greyed out.
+func (d *dotEmitter) traceEdge(target *node) string {
+	if d.options.Trace == nil {
+		return ""
+	}
+
+	result, visited := d.options.Trace.lookup(target)
+
+	switch {
+	case !visited:
+		return traceUntaken
+
+	case result:
+		return tracePassed
+
+	default:
+		return traceFailed
+	}
+}
+
+// Return extra attributes for an edge to an action or failure.
+//
+// With a trace, the edge is emphasised if the source node was visited and
+// its result was `fired`, which is true for actions and false for
+// failures.
+func (d *dotEmitter) traceOutcome(source *node, fired bool) string {
+	if d.options.Trace == nil {
+		return ""
+	}
+
+	if result, visited := d.options.Trace.lookup(source); visited && result == fired {
+		return traceFired
+	}
+
+	return traceUntaken
+}
+
+// Return the opening table tag for a predicate's label.
+func (d *dotEmitter) tableOpen(current *node) string {
+	if d.options.Trace == nil {
+		return tableUntraced
+	}
+
+	result, visited := d.options.Trace.lookup(current)
+
+	switch {
+	case !visited:
+		return fmt.Sprintf(tableTraced, "#bdbdbd", "#eeeeee")
+
+	case result:
+		return fmt.Sprintf(tableTraced, "#2e7d32", "#d8eaf8")
+
+	default:
+		return fmt.Sprintf(tableTraced, "#c62828", "#d8eaf8")
+	}
+}
+
 func (d *dotEmitter) emitFailures(current *node, label string) {
+	outcome := d.traceOutcome(current, false)
+
 	if d.options.EmitFailureNodes {
 		for _, elt := range current.Failures {
 			fid := d.emitFailureNode(elt)
 			fmt.Fprintf(
 				d.writer,
-				"  %s -> %s [color=%q, style=dashed, label=%q];\n",
+				"  %s -> %s [color=%q, style=dashed, label=%q%s];\n",
 				label,
 				fid,
 				"#c62828",
 				"",
+				outcome,
 			)
 		}
 	} else {
 		tid := d.emitTerminalNode("FAIL", "#C62828", "#FFEBEE")
 		fmt.Fprintf(
 			d.writer,
-			"  %s -> %s [color=%q, style=dashed, label=%q];\n",
+			"  %s -> %s [color=%q, style=dashed, label=%q%s];\n",
 			label,
 			tid,
 			"#c62828",
 			"",
+			outcome,
 		)
 	}
 }
@@ -176,15 +250,18 @@ func (d *dotEmitter) emitTerminalNode(label, border, fill string) string {
 }
 
 func (d *dotEmitter) emitActions(current *node, label string) {
+	outcome := d.traceOutcome(current, true)
+
 	for _, elt := range current.Actions {
 		aid := d.emitActionNode(elt)
 		fmt.Fprintf(
 			d.writer,
-			"  %s -> %s [color=%q, style=dashed, label=%q];\n",
+			"  %s -> %s [color=%q, style=dashed, label=%q%s];\n",
 			label,
 			aid,
 			"#2e7d32",
 			"",
+			outcome,
 		)
 	}
 }
@@ -213,11 +290,30 @@ func (d *dotEmitter) emitEdges(current *node, label string) {
 		cid := d.emitPredicate(elt)
 		fmt.Fprintf(
 			d.writer,
-			"  %s -> %s [color=%q, penwidth=2, label=%q];\n",
+			"  %s -> %s [color=%q, penwidth=2, label=%q%s];\n",
 			label,
 			cid,
 			"#2e7d32",
 			"",
+			d.traceEdge(elt),
+		)
+	}
+}
+
+// Emit dotted edges from a group predicate to its operands.
+func (d *dotEmitter) emitOperands(current *node, label string) {
+	token := current.Predicate.Token()
+
+	for _, elt := range current.Operands {
+		oid := d.emitPredicate(elt)
+		fmt.Fprintf(
+			d.writer,
+			"  %s -> %s [color=%q, style=dotted, arrowhead=odiamond, label=%q%s];\n",
+			label,
+			oid,
+			"#6a1b9a",
+			token,
+			d.traceEdge(elt),
 		)
 	}
 }
@@ -232,17 +328,24 @@ func (d *dotEmitter) predicateHTMLLabel(current *node) string {
 
 	var sbld strings.Builder
 
-	sbld.WriteString(`<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0" CELLPADDING="4" BGCOLOR="#d8eaf8">`)
+	sbld.WriteString(d.tableOpen(current))
+
+	// Header row: instruction, in purple for groups.
+	if len(current.Operands) > 0 {
+		sbld.WriteString(`<TR><TD BGCOLOR="#8e6bb8"><FONT COLOR="#ffffff"><B>`)
+	} else {
+		sbld.WriteString(`<TR><TD BGCOLOR="#6699cc"><FONT COLOR="#ffffff"><B>`)
+	}
 
-	// Header row: instruction
-	sbld.WriteString(`<TR><TD BGCOLOR="#6699cc"><FONT COLOR="#ffffff"><B>`)
 	sbld.WriteString(kind)
 	sbld.WriteString(`</B></FONT></TD></TR>`)
 
-	// Predicate row
-	sbld.WriteString(`<TR><TD ALIGN="LEFT"><FONT FACE="monospace">`)
-	sbld.WriteString(pred)
-	sbld.WriteString(`</FONT></TD></TR>`)
+	// Predicate row, unless the operands are drawn separately.
+	if len(current.Operands) == 0 {
+		sbld.WriteString(`<TR><TD ALIGN="LEFT"><FONT FACE="monospace">`)
+		sbld.WriteString(pred)
+		sbld.WriteString(`</FONT></TD></TR>`)
+	}
 
 	// Actions row (green)
 	if !d.options.EmitActionNodes && act != "" {
@@ -281,6 +384,10 @@ func (d *dotEmitter) emitPredicate(root *node) string {
 		d.emitFailures(root, pid)
 	}
 
+	if len(root.Operands) > 0 {
+		d.emitOperands(root, pid)
+	}
+
 	d.emitEdges(root, pid)
 
 	if len(root.Actions) > 0 && d.options.EmitActionNodes {
@@ -293,6 +400,15 @@ func (d *dotEmitter) emitPredicate(root *node) string {
 // ** Functions:
 
 func ExportToDOT(writer io.Writer, root *node) {
+	ExportTraceToDOT(writer, root, nil)
+}
+
+// Export the graph with the path taken in the given trace highlighted.
+//
+// Visited predicates are outlined in green if they held and red if they
+// did not, edges along the path are drawn in bold, and everything that was
+// not visited is greyed out.
+func ExportTraceToDOT(writer io.Writer, root *node, trace *Trace) {
 	ExportToDOTWithOptions(
 		writer,
 		root,
@@ -301,6 +417,7 @@ func ExportToDOT(writer io.Writer, root *node) {
 			EmitActionNodes:  true,
 			EmitFailureNodes: true,
 			MaxInlineItems:   DefaultMaxInlineItems,
+			Trace:            trace,
 		},
 	)
 }
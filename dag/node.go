@@ -61,6 +61,7 @@ type node struct {
 	Actions   []*nodeAction // Success actions.
 	Failures  []*nodeAction // Failure actions.
 	Children  []*node       // Child nodes.
+	Operands  []*node       // Operands of a group predicate.
 }
 
 // ** Functions:
@@ -69,8 +70,28 @@ type node struct {
 //
 // If the node has an associated predicate then that is evaluated against
 // the given input.
-func traverse(ctx context.Context, root *node, input Filterable, debug bool, logger logger.Logger) {
-	if !root.Predicate.Eval(ctx, input) {
+//
+// If `trace` is non-nil then each visit is recorded in it.
+//
+//nolint:cyclop
+func traverse(
+	ctx context.Context,
+	root *node,
+	input Filterable,
+	debug bool,
+	logger logger.Logger,
+	trace *Trace,
+	depth int,
+) {
+	var step *TraceStep
+
+	result := root.Predicate.Eval(ctx, input)
+
+	if trace != nil {
+		step = trace.record(ctx, root, input, result, depth)
+	}
+
+	if !result {
 		if debug {
 			logger.Debug(
 				"Eval failure",
@@ -81,6 +102,10 @@ func traverse(ctx context.Context, root *node, input Filterable, debug bool, log
 
 		if len(root.Failures) > 0 {
 			for _, elt := range root.Failures {
+				if step != nil {
+					trace.recordFailure(step, elt)
+				}
+
 				elt.Fn(ctx, input)
 			}
 		}
@@ -102,12 +127,17 @@ func traverse(ctx context.Context, root *node, input Filterable, debug bool, log
 				"Action triggered",
 				"reasons", elt.Reason,
 			)
+
+			if step != nil {
+				trace.recordAction(step, elt)
+			}
+
 			elt.Fn(ctx, input)
 		}
 	}
 
 	for _, child := range root.Children {
-		traverse(ctx, child, input, debug, logger)
+		traverse(ctx, child, input, debug, logger, trace, depth+1)
 	}
 }
 
@@ -42,10 +42,14 @@ package dag
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Asmodai/gohacks/conversion"
 	"github.com/Asmodai/gohacks/logger"
+	"github.com/Asmodai/gohacks/types"
 	"github.com/Asmodai/gohacks/utils"
 )
 
@@ -141,6 +145,21 @@ func (meta *MetaPredicate) String(token string) string {
 	return fmt.Sprintf("%q %s%s", meta.key, token, match)
 }
 
+// Return the attribute on which the predicate operates.
+func (meta *MetaPredicate) Attribute() string {
+	return meta.key
+}
+
+// Return the predicate's filter value.
+func (meta *MetaPredicate) Expected() any {
+	return meta.val
+}
+
+// Return the predicate's input value.
+func (meta *MetaPredicate) Actual(input Filterable) (any, bool) {
+	return input.Get(meta.key)
+}
+
 // Return the predicate's input value as a 64-bit float.
 //
 // This will return the value for the key on which the predicate operates.
@@ -173,6 +192,52 @@ func (meta *MetaPredicate) GetStringValues(input Filterable) (string, string, bo
 	return lhs, rhs, dataOk && lhsOk && rhsOk
 }
 
+// Return the predicate's input value as a time.
+//
+// See `toTime` for the types that are accepted.
+func (meta *MetaPredicate) GetTimeValueFromInput(input Filterable) (time.Time, bool) {
+	data, dataOk := input.Get(meta.key)
+	if !dataOk {
+		return time.Time{}, false
+	}
+
+	return toTime(data)
+}
+
+// Return the length of the predicate's input value.
+//
+// Strings are measured in runes.  Arrays, slices, maps and channels are
+// measured in elements.
+func (meta *MetaPredicate) GetLengthFromInput(input Filterable) (int64, bool) {
+	data, dataOk := input.Get(meta.key)
+	if !dataOk || data == nil {
+		return 0, false
+	}
+
+	if str, ok := data.(string); ok {
+		return int64(utf8.RuneCountInString(str)), true
+	}
+
+	rval := reflect.ValueOf(data)
+
+	switch rval.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Chan:
+		return int64(rval.Len()), true
+
+	default:
+		return 0, false
+	}
+}
+
+// Return both the length of the predicate's input value and the filter
+// value as 64-bit integers.
+func (meta *MetaPredicate) GetLengthValues(input Filterable) (int64, int64, bool) {
+	lhs, lhsOk := meta.GetLengthFromInput(input)
+	rhs, rhsOk := conversion.ToInt64(meta.val)
+
+	return lhs, rhs, lhsOk && rhsOk
+}
+
 // Return the predicate's filter value as an array of 64-bit floats.
 func (meta *MetaPredicate) GetPredicateFloatArray() ([]float64, bool) {
 	return conversion.AnyArrayToFloat64Array(meta.val)
@@ -258,25 +323,98 @@ func (meta *MetaPredicate) EvalStringMember(input Filterable, insens bool) bool
 	return false
 }
 
+// Is the predicate's input value a member of the array of numbers in the
+// predicate's filter value?
+func (meta *MetaPredicate) EvalNumericMember(input Filterable) bool {
+	val, valOk := meta.GetFloatValueFromInput(input)
+	if !valOk {
+		return false
+	}
+
+	array, arrayOk := meta.GetPredicateFloatArray()
+	if !arrayOk {
+		return false
+	}
+
+	for _, elt := range array {
+		if elt == val {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ** Functions:
 
+// Convert a value to a time.
+//
+// Accepts `time.Time`, `types.RFC3339` and strings that can be parsed by
+// `types.ParseRFC3339`.
+func toTime(value any) (time.Time, bool) {
+	switch val := value.(type) {
+	case time.Time:
+		return val, true
+
+	case types.RFC3339:
+		return val.Time(), true
+
+	case *types.RFC3339:
+		if val == nil {
+			return time.Time{}, false
+		}
+
+		return val.Time(), true
+
+	case string:
+		parsed, err := types.ParseRFC3339(val)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return parsed.Time(), true
+
+	default:
+		return time.Time{}, false
+	}
+}
+
 // Build the predicate dictionary for the directed acyclic graph filter.
 func BuildPredicateDict() PredicateDict {
 	result := make(PredicateDict)
 	preds := []PredicateBuilder{
+		//
+		// Existence predicates.
+		&EXISTSBuilder{}, &ABSENTBuilder{},
 		//
 		// Numeric predicates.
 		&EQBuilder{}, &NEQBuilder{},
 		&GTBuilder{}, &GTEBuilder{},
 		&LTBuilder{}, &LTEBuilder{},
+		&IIRBuilder{}, &EIRBuilder{},
+		&NMBuilder{},
 		//
 		// String predicates.
 		&SIEQBuilder{}, &SINEQBuilder{},
 		&SSEQBuilder{}, &SSNEQBuilder{},
+		&SIMBuilder{}, &SSMBuilder{},
+		&SPFXBuilder{}, &SSFXBuilder{},
+		&SCONBuilder{},
+		//
+		// Length predicates.
+		&LENEQBuilder{},
+		&LENLTBuilder{}, &LENGTBuilder{},
 		//
 		// Regex predicates.
 		&REIMBuilder{},
 		&RESMBuilder{},
+		//
+		// Time predicates.
+		&TBEFOREBuilder{}, &TAFTERBuilder{},
+		&TWITHINBuilder{},
+		//
+		// Network predicates.
+		&CIDRBuilder{},
 	}
 
 	for idx := range preds {
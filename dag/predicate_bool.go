@@ -0,0 +1,178 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_bool.go --- ALL, ANY, NOT - Boolean condition groups.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// These predicates are not in the predicate dictionary.  They are built by
+// the compiler from `all`, `any` and `not` groups in a condition
+// specification, and evaluate the predicates of their operand nodes.
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"strings"
+)
+
+// * Constants:
+
+const (
+	allIsn   = "ALL"
+	allToken = "all"
+	anyIsn   = "ANY"
+	anyToken = "any"
+	notIsn   = "NOT"
+	notToken = "not"
+)
+
+// * Code:
+
+// ** Predicates:
+
+// *** ALL:
+
+// ALL - Conjunction.
+//
+// Returns true if every operand is true.
+type ALLPredicate struct {
+	operands []Predicate
+}
+
+func (pred *ALLPredicate) Instruction() string {
+	return allIsn
+}
+
+func (pred *ALLPredicate) Token() string {
+	return allToken
+}
+
+func (pred *ALLPredicate) String() string {
+	return formatGroup(allToken, pred.operands)
+}
+
+func (pred *ALLPredicate) Debug() string {
+	return allIsn + ": " + pred.String()
+}
+
+func (pred *ALLPredicate) Eval(ctx context.Context, input Filterable) bool {
+	for _, operand := range pred.operands {
+		if !operand.Eval(ctx, input) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// *** ANY:
+
+// ANY - Disjunction.
+//
+// Returns true if at least one operand is true.
+type ANYPredicate struct {
+	operands []Predicate
+}
+
+func (pred *ANYPredicate) Instruction() string {
+	return anyIsn
+}
+
+func (pred *ANYPredicate) Token() string {
+	return anyToken
+}
+
+func (pred *ANYPredicate) String() string {
+	return formatGroup(anyToken, pred.operands)
+}
+
+func (pred *ANYPredicate) Debug() string {
+	return anyIsn + ": " + pred.String()
+}
+
+func (pred *ANYPredicate) Eval(ctx context.Context, input Filterable) bool {
+	for _, operand := range pred.operands {
+		if operand.Eval(ctx, input) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// *** NOT:
+
+// NOT - Negation.
+//
+// Returns true if the operand is false.  Note that a predicate on a missing
+// attribute is false, so its negation is true.
+type NOTPredicate struct {
+	operand Predicate
+}
+
+func (pred *NOTPredicate) Instruction() string {
+	return notIsn
+}
+
+func (pred *NOTPredicate) Token() string {
+	return notToken
+}
+
+func (pred *NOTPredicate) String() string {
+	return formatGroup(notToken, []Predicate{pred.operand})
+}
+
+func (pred *NOTPredicate) Debug() string {
+	return notIsn + ": " + pred.String()
+}
+
+func (pred *NOTPredicate) Eval(ctx context.Context, input Filterable) bool {
+	return !pred.operand.Eval(ctx, input)
+}
+
+// ** Functions:
+
+// Format a group predicate and its operands.
+func formatGroup(token string, operands []Predicate) string {
+	parts := make([]string, 0, len(operands))
+
+	for _, operand := range operands {
+		parts = append(parts, operand.String())
+	}
+
+	return token + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// * predicate_bool.go ends here.
@@ -0,0 +1,81 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_bool_test.go --- Boolean condition group tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"testing"
+)
+
+// * Code:
+
+func TestBooleanPredicates(t *testing.T) {
+	input := NewDataInputFromMap(map[string]any{"Numeric": float64(42)})
+	builder := &EQBuilder{}
+	yes, _ := builder.Build("Numeric", 42, nil, false)
+	no, _ := builder.Build("Numeric", 76, nil, false)
+
+	tests := []struct {
+		pred Predicate
+		want bool
+	}{
+		{&ALLPredicate{operands: []Predicate{yes, yes}}, true},
+		{&ALLPredicate{operands: []Predicate{yes, no}}, false},
+		{&ANYPredicate{operands: []Predicate{no, yes}}, true},
+		{&ANYPredicate{operands: []Predicate{no, no}}, false},
+		{&NOTPredicate{operand: no}, true},
+		{&NOTPredicate{operand: &ANYPredicate{operands: []Predicate{no, yes}}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pred.String(), func(t *testing.T) {
+			if got := test.pred.Eval(context.TODO(), input); got != test.want {
+				t.Errorf("%s - failed.  %v != %v",
+					test.pred.Debug(),
+					got,
+					test.want)
+			}
+		})
+	}
+
+	if got := (&NOTPredicate{operand: no}).String(); got != `not("Numeric" == 76)` {
+		t.Errorf("String mismatch: %s", got)
+	}
+}
+
+// * predicate_bool_test.go ends here.
@@ -0,0 +1,209 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_cidr.go --- CIDR - IP address In CIDR.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/Asmodai/gohacks/conversion"
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	cidrIsn   = "CIDR"
+	cidrToken = "ip-in-cidr"
+)
+
+// * Variables:
+
+var (
+	ErrInvalidCIDR = errors.Base("invalid CIDR")
+)
+
+// * Code:
+
+// ** Predicate:
+
+// CIDR - IP address In CIDR predicate.
+//
+// Returns true if the IP address in the input value is within any of the
+// networks in the filter value.
+//
+// The filter value may be a single CIDR string or an array of them, and is
+// parsed when the predicate is built.  The input value may be a string, a
+// `netip.Addr` or a `net.IP`.
+type CIDRPredicate struct {
+	prefixes []netip.Prefix
+
+	MetaPredicate
+}
+
+func (pred *CIDRPredicate) Instruction() string {
+	return cidrIsn
+}
+
+func (pred *CIDRPredicate) Token() string {
+	return cidrToken
+}
+
+func (pred *CIDRPredicate) String() string {
+	return pred.MetaPredicate.String(cidrToken)
+}
+
+func (pred *CIDRPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(cidrIsn, cidrToken)
+}
+
+func (pred *CIDRPredicate) Eval(_ context.Context, input Filterable) bool {
+	data, ok := pred.MetaPredicate.Actual(input)
+	if !ok {
+		return false
+	}
+
+	addr, ok := toAddr(data)
+	if !ok {
+		return false
+	}
+
+	for _, prefix := range pred.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ** Builder:
+
+type CIDRBuilder struct{}
+
+func (bld *CIDRBuilder) Token() string {
+	return cidrToken
+}
+
+func (bld *CIDRBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	prefixes, err := parsePrefixes(val)
+	if err != nil {
+		return nil, errors.WithMessagef(
+			errors.WrapWith(err, ErrInvalidCIDR),
+			"%s: value %v",
+			cidrToken,
+			val)
+	}
+
+	pred := &CIDRPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+		prefixes: prefixes,
+	}
+
+	return pred, nil
+}
+
+// ** Functions:
+
+// Parse a CIDR string, or an array of them, into network prefixes.
+func parsePrefixes(val any) ([]netip.Prefix, error) {
+	strs, ok := conversion.AnyArrayToStringArray(val)
+	if !ok {
+		str, isStr := val.(string)
+		if !isStr {
+			return nil, errors.WithStack(ErrValueNotString)
+		}
+
+		strs = []string{str}
+	}
+
+	if len(strs) == 0 {
+		return nil, errors.New("no networks given")
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(strs))
+
+	for _, str := range strs {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(str))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// Convert a value to an IP address.
+//
+// IPv4-mapped IPv6 addresses are converted to IPv4 so that they match IPv4
+// networks.
+func toAddr(val any) (netip.Addr, bool) {
+	var (
+		addr netip.Addr
+		ok   bool
+	)
+
+	switch data := val.(type) {
+	case netip.Addr:
+		addr, ok = data, data.IsValid()
+
+	case net.IP:
+		addr, ok = netip.AddrFromSlice(data)
+
+	case string:
+		parsed, err := netip.ParseAddr(strings.TrimSpace(data))
+		addr, ok = parsed, err == nil
+
+	default:
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), ok
+}
+
+// * predicate_cidr.go ends here.
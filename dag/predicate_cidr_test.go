@@ -0,0 +1,105 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_cidr_test.go --- CIDR tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+func TestCIDRPredicate(t *testing.T) {
+	input := NewDataInputFromMap(map[string]any{
+		"V4":     "192.168.1.20",
+		"V6":     "2001:db8::1",
+		"Mapped": "::ffff:10.1.2.3",
+		"NetIP":  netip.MustParseAddr("10.0.0.1"),
+		"IP":     net.ParseIP("172.16.5.4"),
+		"Broken": "not an address",
+	})
+	builder := &CIDRBuilder{}
+	tests := []struct {
+		key  string
+		val  any
+		want bool
+	}{
+		{"V4", "192.168.1.0/24", true},
+		{"V4", "192.168.2.0/24", false},
+		{"V4", []any{"10.0.0.0/8", "192.168.0.0/16"}, true},
+		{"V6", "2001:db8::/32", true},
+		{"V6", "192.168.0.0/16", false},
+		{"Mapped", "10.0.0.0/8", true},
+		{"NetIP", []string{"10.0.0.0/30"}, true},
+		{"IP", "172.16.0.0/12", true},
+		{"Broken", "0.0.0.0/0", false},
+		{"Missing", "0.0.0.0/0", false},
+	}
+
+	for _, test := range tests {
+		pred, err := builder.Build(test.key, test.val, nil, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		t.Run(pred.String(), func(t *testing.T) {
+			if got := pred.Eval(context.TODO(), input); got != test.want {
+				t.Errorf("%s - failed.  %v != %v",
+					pred.String(),
+					got,
+					test.want)
+			}
+		})
+	}
+}
+
+func TestCIDRBuilderErrors(t *testing.T) {
+	builder := &CIDRBuilder{}
+
+	for _, val := range []any{nil, 42, "10.0.0.0", "10.0.0.0/33", []any{}} {
+		if _, err := builder.Build("V4", val, nil, false); !errors.Is(err, ErrInvalidCIDR) {
+			t.Errorf("%v: unexpected error: %#v", val, err)
+		}
+	}
+}
+
+// * predicate_cidr_test.go ends here.
@@ -0,0 +1,109 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_exists.go --- EXISTS - Attribute exists.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package dag
+
+// * Imports:
+
+import (
+	"context"
+
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Constants:
+
+const (
+	existsIsn   = "EXISTS"
+	existsToken = "exists"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// EXISTS - Attribute exists predicate.
+//
+// Returns true if the input has a value for the attribute, even if that
+// value is nil.  The filter value is ignored.
+type EXISTSPredicate struct {
+	MetaPredicate
+}
+
+func (pred *EXISTSPredicate) Instruction() string {
+	return existsIsn
+}
+
+func (pred *EXISTSPredicate) Token() string {
+	return existsToken
+}
+
+func (pred *EXISTSPredicate) String() string {
+	return pred.MetaPredicate.String(existsToken)
+}
+
+func (pred *EXISTSPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(existsIsn, existsToken)
+}
+
+func (pred *EXISTSPredicate) Eval(_ context.Context, input Filterable) bool {
+	_, ok := pred.MetaPredicate.Actual(input)
+
+	return ok
+}
+
+// ** Builder:
+
+type EXISTSBuilder struct{}
+
+func (bld *EXISTSBuilder) Token() string {
+	return existsToken
+}
+
+func (bld *EXISTSBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	pred := &EXISTSPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+	}
+
+	return pred, nil
+}
+
+// * predicate_exists.go ends here.
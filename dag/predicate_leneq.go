@@ -0,0 +1,111 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_leneq.go --- LENEQ - Length Equal.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package dag
+
+// * Imports:
+
+import (
+	"context"
+
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Constants:
+
+const (
+	leneqIsn   = "LENEQ"
+	leneqToken = "length-equal"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// LENEQ - Length Equal predicate.
+//
+// Returns true if the length of the input value is equal to the filter
+// value.
+//
+// See `MetaPredicate.GetLengthFromInput` for how lengths are measured.
+type LENEQPredicate struct {
+	MetaPredicate
+}
+
+func (pred *LENEQPredicate) Instruction() string {
+	return leneqIsn
+}
+
+func (pred *LENEQPredicate) Token() string {
+	return leneqToken
+}
+
+func (pred *LENEQPredicate) String() string {
+	return pred.MetaPredicate.String(leneqToken)
+}
+
+func (pred *LENEQPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(leneqIsn, leneqToken)
+}
+
+func (pred *LENEQPredicate) Eval(_ context.Context, input Filterable) bool {
+	lhs, rhs, ok := pred.MetaPredicate.GetLengthValues(input)
+
+	return ok && lhs == rhs
+}
+
+// ** Builder:
+
+type LENEQBuilder struct{}
+
+func (bld *LENEQBuilder) Token() string {
+	return leneqToken
+}
+
+func (bld *LENEQBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	pred := &LENEQPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+	}
+
+	return pred, nil
+}
+
+// * predicate_leneq.go ends here.
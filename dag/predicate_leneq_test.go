@@ -0,0 +1,86 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_leneq_test.go --- LENEQ tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"testing"
+)
+
+// * Code:
+
+func TestLENEQPredicate(t *testing.T) {
+	input := NewDataInputFromMap(map[string]any{
+		"String": "日本語",
+		"Slice":  []int{1, 2, 3, 4},
+		"Map":    map[string]int{"a": 1},
+		"Int":    12345,
+	})
+	builder := &LENEQBuilder{}
+	tests := []struct {
+		key  string
+		val  any
+		want bool
+	}{
+		{"String", 3, true},
+		{"String", 9, false},
+		{"Slice", 4, true},
+		{"Map", 1, true},
+		{"Int", 5, false},
+		{"Slice", "four", false},
+		{"Missing", 0, false},
+	}
+
+	for _, test := range tests {
+		pred, err := builder.Build(test.key, test.val, nil, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		t.Run(pred.String(), func(t *testing.T) {
+			if got := pred.Eval(context.TODO(), input); got != test.want {
+				t.Errorf("%s - failed.  %v != %v",
+					pred.String(),
+					got,
+					test.want)
+			}
+		})
+	}
+}
+
+// * predicate_leneq_test.go ends here.
@@ -0,0 +1,111 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_lengt.go --- LENGT - Length Greater Than.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package dag
+
+// * Imports:
+
+import (
+	"context"
+
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Constants:
+
+const (
+	lengtIsn   = "LENGT"
+	lengtToken = "length-greater"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// LENGT - Length Greater Than predicate.
+//
+// Returns true if the length of the input value is greater than the filter
+// value.
+//
+// See `MetaPredicate.GetLengthFromInput` for how lengths are measured.
+type LENGTPredicate struct {
+	MetaPredicate
+}
+
+func (pred *LENGTPredicate) Instruction() string {
+	return lengtIsn
+}
+
+func (pred *LENGTPredicate) Token() string {
+	return lengtToken
+}
+
+func (pred *LENGTPredicate) String() string {
+	return pred.MetaPredicate.String(lengtToken)
+}
+
+func (pred *LENGTPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(lengtIsn, lengtToken)
+}
+
+func (pred *LENGTPredicate) Eval(_ context.Context, input Filterable) bool {
+	lhs, rhs, ok := pred.MetaPredicate.GetLengthValues(input)
+
+	return ok && lhs > rhs
+}
+
+// ** Builder:
+
+type LENGTBuilder struct{}
+
+func (bld *LENGTBuilder) Token() string {
+	return lengtToken
+}
+
+func (bld *LENGTBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	pred := &LENGTPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+	}
+
+	return pred, nil
+}
+
+// * predicate_lengt.go ends here.
@@ -0,0 +1,111 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_scon.go --- SCON - String Contains.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Constants:
+
+const (
+	sconIsn   = "SCON"
+	sconToken = "string-contains"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// SCON - String Contains predicate.
+//
+// Returns true if the input value contains the filter value.
+//
+// The comparison is case-sensitive.
+type SCONPredicate struct {
+	MetaPredicate
+}
+
+func (pred *SCONPredicate) Instruction() string {
+	return sconIsn
+}
+
+func (pred *SCONPredicate) Token() string {
+	return sconToken
+}
+
+func (pred *SCONPredicate) String() string {
+	return pred.MetaPredicate.String(sconToken)
+}
+
+func (pred *SCONPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(sconIsn, sconToken)
+}
+
+func (pred *SCONPredicate) Eval(_ context.Context, input Filterable) bool {
+	data, match, ok := pred.MetaPredicate.GetStringValues(input)
+
+	return ok && strings.Contains(data, match)
+}
+
+// ** Builder:
+
+type SCONBuilder struct{}
+
+func (bld *SCONBuilder) Token() string {
+	return sconToken
+}
+
+func (bld *SCONBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	pred := &SCONPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+	}
+
+	return pred, nil
+}
+
+// * predicate_scon.go ends here.
@@ -0,0 +1,111 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_spfx.go --- SPFX - String Prefix.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Constants:
+
+const (
+	spfxIsn   = "SPFX"
+	spfxToken = "string-prefix"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// SPFX - String Prefix predicate.
+//
+// Returns true if the input value starts with the filter value.
+//
+// The comparison is case-sensitive.
+type SPFXPredicate struct {
+	MetaPredicate
+}
+
+func (pred *SPFXPredicate) Instruction() string {
+	return spfxIsn
+}
+
+func (pred *SPFXPredicate) Token() string {
+	return spfxToken
+}
+
+func (pred *SPFXPredicate) String() string {
+	return pred.MetaPredicate.String(spfxToken)
+}
+
+func (pred *SPFXPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(spfxIsn, spfxToken)
+}
+
+func (pred *SPFXPredicate) Eval(_ context.Context, input Filterable) bool {
+	data, match, ok := pred.MetaPredicate.GetStringValues(input)
+
+	return ok && strings.HasPrefix(data, match)
+}
+
+// ** Builder:
+
+type SPFXBuilder struct{}
+
+func (bld *SPFXBuilder) Token() string {
+	return spfxToken
+}
+
+func (bld *SPFXBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	pred := &SPFXPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+	}
+
+	return pred, nil
+}
+
+// * predicate_spfx.go ends here.
@@ -0,0 +1,111 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_ssfx.go --- SSFX - String Suffix.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Constants:
+
+const (
+	ssfxIsn   = "SSFX"
+	ssfxToken = "string-suffix"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// SSFX - String Suffix predicate.
+//
+// Returns true if the input value ends with the filter value.
+//
+// The comparison is case-sensitive.
+type SSFXPredicate struct {
+	MetaPredicate
+}
+
+func (pred *SSFXPredicate) Instruction() string {
+	return ssfxIsn
+}
+
+func (pred *SSFXPredicate) Token() string {
+	return ssfxToken
+}
+
+func (pred *SSFXPredicate) String() string {
+	return pred.MetaPredicate.String(ssfxToken)
+}
+
+func (pred *SSFXPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(ssfxIsn, ssfxToken)
+}
+
+func (pred *SSFXPredicate) Eval(_ context.Context, input Filterable) bool {
+	data, match, ok := pred.MetaPredicate.GetStringValues(input)
+
+	return ok && strings.HasSuffix(data, match)
+}
+
+// ** Builder:
+
+type SSFXBuilder struct{}
+
+func (bld *SSFXBuilder) Token() string {
+	return ssfxToken
+}
+
+func (bld *SSFXBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	pred := &SSFXPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+	}
+
+	return pred, nil
+}
+
+// * predicate_ssfx.go ends here.
@@ -0,0 +1,125 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_tafter.go --- TAFTER - Time After.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"time"
+
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	tafterIsn   = "TAFTER"
+	tafterToken = "time-after"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// TAFTER - Time After predicate.
+//
+// Returns true if the time in the input value is after the timestamp in
+// the filter value.
+//
+// The filter value is parsed when the predicate is built.  See `toTime`
+// for the types accepted for both values.
+type TAFTERPredicate struct {
+	moment time.Time
+
+	MetaPredicate
+}
+
+func (pred *TAFTERPredicate) Instruction() string {
+	return tafterIsn
+}
+
+func (pred *TAFTERPredicate) Token() string {
+	return tafterToken
+}
+
+func (pred *TAFTERPredicate) String() string {
+	return pred.MetaPredicate.String(tafterToken)
+}
+
+func (pred *TAFTERPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(tafterIsn, tafterToken)
+}
+
+func (pred *TAFTERPredicate) Eval(_ context.Context, input Filterable) bool {
+	val, ok := pred.MetaPredicate.GetTimeValueFromInput(input)
+
+	return ok && val.After(pred.moment)
+}
+
+// ** Builder:
+
+type TAFTERBuilder struct{}
+
+func (bld *TAFTERBuilder) Token() string {
+	return tafterToken
+}
+
+func (bld *TAFTERBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	moment, ok := toTime(val)
+	if !ok {
+		return nil, errors.WithMessagef(
+			ErrInvalidTimestamp,
+			"%s: value %v",
+			tafterToken,
+			val)
+	}
+
+	pred := &TAFTERPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+		moment: moment,
+	}
+
+	return pred, nil
+}
+
+// * predicate_tafter.go ends here.
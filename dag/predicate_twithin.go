@@ -0,0 +1,161 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_twithin.go --- TWITHIN - Time Within.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	twithinIsn   = "TWITHIN"
+	twithinToken = "time-within"
+)
+
+// * Variables:
+
+var (
+	ErrInvalidWindow = errors.Base("invalid time window")
+)
+
+// * Code:
+
+// ** Predicate:
+
+// TWITHIN - Time Within predicate.
+//
+// Returns true if the time in the input value falls within the window
+// given in the filter value, inclusive.
+//
+// The filter value must be an array of two timestamps, the start and the
+// end of the window, and is parsed when the predicate is built.
+type TWITHINPredicate struct {
+	start time.Time
+	end   time.Time
+
+	MetaPredicate
+}
+
+func (pred *TWITHINPredicate) Instruction() string {
+	return twithinIsn
+}
+
+func (pred *TWITHINPredicate) Token() string {
+	return twithinToken
+}
+
+func (pred *TWITHINPredicate) String() string {
+	return pred.MetaPredicate.String(twithinToken)
+}
+
+func (pred *TWITHINPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(twithinIsn, twithinToken)
+}
+
+func (pred *TWITHINPredicate) Eval(_ context.Context, input Filterable) bool {
+	val, ok := pred.MetaPredicate.GetTimeValueFromInput(input)
+
+	return ok && !val.Before(pred.start) && !val.After(pred.end)
+}
+
+// ** Builder:
+
+type TWITHINBuilder struct{}
+
+func (bld *TWITHINBuilder) Token() string {
+	return twithinToken
+}
+
+func (bld *TWITHINBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (Predicate, error) {
+	start, end, ok := parseWindow(val)
+	if !ok {
+		return nil, errors.WithMessagef(
+			ErrInvalidWindow,
+			"%s: value %v",
+			twithinToken,
+			val)
+	}
+
+	pred := &TWITHINPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+		start: start,
+		end:   end,
+	}
+
+	return pred, nil
+}
+
+// ** Functions:
+
+// Parse a time window from an array of two timestamps.
+//
+// Fails if the end of the window is before its start.
+func parseWindow(val any) (time.Time, time.Time, bool) {
+	rval := reflect.ValueOf(val)
+
+	if rval.Kind() != reflect.Slice && rval.Kind() != reflect.Array {
+		return time.Time{}, time.Time{}, false
+	}
+
+	//nolint:mnd
+	if rval.Len() != 2 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start, startOk := toTime(rval.Index(0).Interface())
+	end, endOk := toTime(rval.Index(1).Interface())
+
+	if !startOk || !endOk || end.Before(start) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return start, end, true
+}
+
+// * predicate_twithin.go ends here.
@@ -0,0 +1,101 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_twithin_test.go --- TWITHIN tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Asmodai/gohacks/types"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+func TestTWITHINPredicate(t *testing.T) {
+	input := NewDataInputFromMap(map[string]any{
+		"Stamp":  "2025-06-01T12:00:00Z",
+		"Time":   time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		"RFC":    types.RFC3339(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)),
+		"Broken": "not a time",
+	})
+	builder := &TWITHINBuilder{}
+	tests := []struct {
+		key  string
+		val  any
+		want bool
+	}{
+		{"Stamp", []any{"2025-06-01T00:00:00Z", "2025-06-02T00:00:00Z"}, true},
+		{"Stamp", []string{"2025-06-01T12:00:00Z", "2025-06-01T12:00:00Z"}, true},
+		{"Time", []any{"2025-06-02T00:00:00Z", "2025-06-03T00:00:00Z"}, false},
+		{"RFC", []any{"2025-01-01T00:00:00Z", "2025-06-01T11:59:59Z"}, false},
+		{"Broken", []any{"2020-01-01T00:00:00Z", "2030-01-01T00:00:00Z"}, false},
+		{"Missing", []any{"2020-01-01T00:00:00Z", "2030-01-01T00:00:00Z"}, false},
+	}
+
+	for _, test := range tests {
+		pred, err := builder.Build(test.key, test.val, nil, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		t.Run(pred.String(), func(t *testing.T) {
+			if got := pred.Eval(context.TODO(), input); got != test.want {
+				t.Errorf("%s - failed.  %v != %v",
+					pred.String(),
+					got,
+					test.want)
+			}
+		})
+	}
+}
+
+func TestTWITHINBuilderErrors(t *testing.T) {
+	builder := &TWITHINBuilder{}
+
+	for _, val := range []any{nil, 42, "yesterday",
+		[]any{"2025-06-01T00:00:00Z"},
+		[]any{"2025-06-02T00:00:00Z", "2025-06-01T00:00:00Z"}} {
+		if _, err := builder.Build("Stamp", val, nil, false); !errors.Is(err, ErrInvalidWindow) {
+			t.Errorf("%v: unexpected error: %#v", val, err)
+		}
+	}
+}
+
+// * predicate_twithin_test.go ends here.
@@ -0,0 +1,445 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// ruleset.go --- Rule-set loading, hot reload and versioning.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A rule set compiles rules from one or more JSON or YAML files into a
+// fresh graph and swaps it in atomically.  Callers of `Evaluate` never
+// block on a load: each evaluation uses whichever graph was current when
+// it started, and graphs are never modified once they are built.
+//
+// A load that produces any errors is rejected as a whole, leaving the
+// current version in place.  The version that a successful load replaces
+// is kept so that it can be restored with `Rollback`.
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Variables:
+
+var (
+	ErrUnknownRuleFormat = errors.Base("unknown rule file format")
+	ErrNoRuleFiles       = errors.Base("no rule files given")
+	ErrNoPreviousVersion = errors.Base("no previous rule set version")
+)
+
+// * Code:
+
+// ** Interface:
+
+type RuleSet interface {
+	// Load and compile rules from the given files, and make them the
+	// current version.
+	//
+	// Files ending in `.json` are parsed as JSON, and files ending in
+	// `.yaml` or `.yml` as YAML.  If anything fails then the errors
+	// are returned, each wrapped in a `RuleError`, and the current
+	// version is left in place.
+	Load(...string) []error
+
+	// Load the files given to the last successful `Load` again.
+	Reload() []error
+
+	// Restore the version replaced by the last load.
+	//
+	// Only one previous version is kept, so rolling back twice in a
+	// row returns `ErrNoPreviousVersion`.
+	Rollback() error
+
+	// Evaluate an input against the current version.
+	//
+	// Does nothing if no rules have been loaded.
+	Evaluate(Filterable)
+
+	// Evaluate an input against the current version, recording a trace.
+	//
+	// Returns nil if no rules have been loaded.
+	EvaluateWithTrace(Filterable) *Trace
+
+	// Export the current version to GraphViz DOT format.
+	Export(io.Writer)
+
+	// Export the current version to GraphViz DOT format, highlighting
+	// the path taken in the given trace.
+	//
+	// The trace should come from the same version, otherwise nothing
+	// is highlighted.
+	ExportTrace(io.Writer, *Trace)
+
+	// Return the current version, or nil if no rules have been loaded.
+	Current() *RuleSetVersion
+
+	// Return the version that the current version replaced, if any.
+	Previous() *RuleSetVersion
+
+	// Reload the rules whenever their files change.
+	//
+	// The files are checked at the given interval until the context is
+	// cancelled.  Failed reloads are logged and the current version is
+	// kept.  This blocks, so it is usually run in its own goroutine.
+	Watch(context.Context, time.Duration)
+}
+
+// ** Types:
+
+// Error loading a rule file or compiling one of its rules.
+type RuleError struct {
+	Err  error  // Underlying error.
+	File string // Rule file.
+	Rule string // Rule name, empty if the file itself failed.
+}
+
+// A compiled version of a rule set.
+type RuleSetVersion struct {
+	LoadedAt time.Time // When the version was loaded.
+	compiler Compiler  // Compiled graph.
+	Files    []string  // Files the rules came from.
+	Digest   []byte    // SHA-256 digest of the files' contents.
+	Version  uint64    // Version number, starting at 1.
+	Rules    int       // Number of rules.
+}
+
+type ruleSet struct {
+	ctx        context.Context
+	lgr        logger.Logger
+	builder    Actions
+	predicates PredicateDict
+	current    atomic.Pointer[RuleSetVersion]
+	previous   *RuleSetVersion
+	paths      []string
+	serial     uint64
+	mu         sync.Mutex // Serialises loads and rollbacks.
+}
+
+// ** Methods:
+
+// *** Rule error:
+
+func (e *RuleError) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Err.Error())
+	}
+
+	return fmt.Sprintf("%s: rule %q: %s", e.File, e.Rule, e.Err.Error())
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// *** Rule set:
+
+func (rs *ruleSet) Load(paths ...string) []error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.loadLocked(paths)
+}
+
+func (rs *ruleSet) Reload() []error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.loadLocked(rs.paths)
+}
+
+func (rs *ruleSet) Rollback() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.previous == nil {
+		return errors.WithStack(ErrNoPreviousVersion)
+	}
+
+	rs.current.Store(rs.previous)
+	rs.paths = rs.previous.Files
+
+	rs.lgr.Info(
+		"Rule set rolled back",
+		"version", rs.previous.Version)
+
+	rs.previous = nil
+
+	return nil
+}
+
+func (rs *ruleSet) Evaluate(input Filterable) {
+	if ver := rs.current.Load(); ver != nil {
+		ver.compiler.Evaluate(input)
+	}
+}
+
+func (rs *ruleSet) EvaluateWithTrace(input Filterable) *Trace {
+	if ver := rs.current.Load(); ver != nil {
+		return ver.compiler.EvaluateWithTrace(input)
+	}
+
+	return nil
+}
+
+func (rs *ruleSet) Export(writer io.Writer) {
+	if ver := rs.current.Load(); ver != nil {
+		ver.compiler.Export(writer)
+	}
+}
+
+func (rs *ruleSet) ExportTrace(writer io.Writer, trace *Trace) {
+	if ver := rs.current.Load(); ver != nil {
+		ver.compiler.ExportTrace(writer, trace)
+	}
+}
+
+func (rs *ruleSet) Current() *RuleSetVersion {
+	return rs.current.Load()
+}
+
+func (rs *ruleSet) Previous() *RuleSetVersion {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.previous
+}
+
+func (rs *ruleSet) Watch(ctx context.Context, interval time.Duration) {
+	var failed []byte
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			failed = rs.reloadIfChanged(failed)
+		}
+	}
+}
+
+// Reload if the files no longer match the current version.
+//
+// `failed` is the digest of the last contents that failed to load, which
+// are not retried until they change again.  Returns the new value for it.
+func (rs *ruleSet) reloadIfChanged(failed []byte) []byte {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	cur := rs.current.Load()
+	if cur == nil || len(rs.paths) == 0 {
+		return failed
+	}
+
+	digest, err := digestFiles(rs.paths)
+	if err != nil {
+		if failed != nil {
+			return failed
+		}
+
+		rs.lgr.Warn(
+			"Rule set files could not be read",
+			"err", err.Error())
+
+		return []byte{}
+	}
+
+	if bytes.Equal(digest, cur.Digest) || bytes.Equal(digest, failed) {
+		return failed
+	}
+
+	if errs := rs.loadLocked(rs.paths); len(errs) > 0 {
+		return digest
+	}
+
+	return nil
+}
+
+// Build a new version from the given files and swap it in.
+func (rs *ruleSet) loadLocked(paths []string) []error {
+	if len(paths) == 0 {
+		return []error{errors.WithStack(ErrNoRuleFiles)}
+	}
+
+	ver, errs := rs.build(paths)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			rs.lgr.Warn(
+				"Rule set failed to load",
+				"err", err.Error())
+		}
+
+		return errs
+	}
+
+	rs.serial++
+	ver.Version = rs.serial
+
+	rs.previous = rs.current.Swap(ver)
+	rs.paths = ver.Files
+
+	rs.lgr.Info(
+		"Rule set loaded",
+		"version", ver.Version,
+		"rules", ver.Rules,
+		"files", strings.Join(ver.Files, ", "))
+
+	return nil
+}
+
+// Compile the rules in the given files into a new version.
+func (rs *ruleSet) build(paths []string) (*RuleSetVersion, []error) {
+	var issues []error
+
+	//nolint:forcetypeassert
+	cmplr := NewCompilerWithPredicates(
+		rs.ctx,
+		rs.builder,
+		rs.predicates).(*compiler)
+
+	cmplr.initPredicates()
+	cmplr.initRoot()
+
+	ver := &RuleSetVersion{
+		LoadedAt: time.Now(),
+		compiler: cmplr,
+		Files:    slices.Clone(paths)}
+	hash := sha256.New()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			issues = append(issues, &RuleError{File: path, Err: errors.WithStack(err)})
+
+			continue
+		}
+
+		_, _ = hash.Write(data)
+
+		rules, err := parseRuleFile(path, data)
+		if err != nil {
+			issues = append(issues, &RuleError{File: path, Err: err})
+
+			continue
+		}
+
+		for _, rule := range rules {
+			if err := cmplr.compileRule(rule); err != nil {
+				issues = append(issues, &RuleError{File: path, Rule: rule.Name, Err: err})
+
+				continue
+			}
+
+			ver.Rules++
+		}
+	}
+
+	ver.Digest = hash.Sum(nil)
+
+	return ver, issues
+}
+
+// ** Functions:
+
+// Parse the rules in a file according to its extension.
+func parseRuleFile(path string, data []byte) ([]RuleSpec, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseFromJSON(string(data))
+
+	case ".yaml", ".yml":
+		return ParseFromYAML(string(data))
+
+	default:
+		return nil, errors.WithMessagef(
+			ErrUnknownRuleFormat,
+			"%q",
+			filepath.Ext(path))
+	}
+}
+
+// Return the SHA-256 digest of the contents of the given files.
+func digestFiles(paths []string) ([]byte, error) {
+	hash := sha256.New()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		_, _ = hash.Write(data)
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// Return a new rule set.
+func NewRuleSet(ctx context.Context, build Actions) RuleSet {
+	return NewRuleSetWithPredicates(ctx, build, BuildPredicateDict())
+}
+
+// Return a new rule set with custom predicates.
+func NewRuleSetWithPredicates(
+	ctx context.Context,
+	builder Actions,
+	predicates PredicateDict,
+) RuleSet {
+	return &ruleSet{
+		ctx:        ctx,
+		lgr:        logger.MustGetLogger(ctx),
+		builder:    builder,
+		predicates: predicates,
+	}
+}
+
+// * ruleset.go ends here.
@@ -0,0 +1,286 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// ruleset_test.go --- Rule set tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Utilities:
+
+func writeRuleFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Could not write %s: %#v", path, err)
+	}
+}
+
+func newTestRuleSet(t *testing.T) (RuleSet, *MockActions) {
+	t.Helper()
+
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("Could not set logger DI: %#v", err)
+	}
+
+	mact := &MockActions{}
+
+	return NewRuleSet(ctx, mact), mact
+}
+
+// ** Tests:
+
+//nolint:funlen
+func TestRuleSet(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "rules.yaml")
+	jsonFile := filepath.Join(dir, "rules.json")
+
+	writeRuleFile(t, yamlFile, rulesYAML)
+	writeRuleFile(t, jsonFile, rulesJSON)
+
+	rset, mact := newTestRuleSet(t)
+
+	t.Run("Empty", func(t *testing.T) {
+		rset.Evaluate(NewDataInputFromMap(WarmWeather))
+
+		if mact.hasRunLog || rset.Current() != nil {
+			t.Error("Empty rule set did something")
+		}
+
+		if err := rset.Rollback(); !errors.Is(err, ErrNoPreviousVersion) {
+			t.Errorf("Unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("Load", func(t *testing.T) {
+		if errs := rset.Load(yamlFile); len(errs) > 0 {
+			t.Fatalf("Unexpected errors: %v", errs)
+		}
+
+		ver := rset.Current()
+		if ver.Version != 1 || ver.Rules != 2 {
+			t.Errorf("Version mismatch: %d/%d", ver.Version, ver.Rules)
+		}
+
+		rset.Evaluate(NewDataInputFromMap(WarmWeather))
+
+		if !mact.hasRunLog {
+			t.Error("Rule did not fire")
+		}
+	})
+
+	t.Run("Bad rules keep current version", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.yml")
+		writeRuleFile(t, bad, badRulesYAML)
+
+		errs := rset.Load(yamlFile, bad)
+		if len(errs) != 3 {
+			t.Fatalf("Error count mismatch: %d != 3", len(errs))
+		}
+
+		var rerr *RuleError
+
+		if !errors.As(errs[0], &rerr) {
+			t.Fatalf("Not a rule error: %#v", errs[0])
+		}
+
+		if rerr.File != bad || rerr.Rule != "derpy_weather" {
+			t.Errorf("Error location mismatch: %s", rerr.Error())
+		}
+
+		if rset.Current().Version != 1 {
+			t.Error("Failed load replaced the current version")
+		}
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		errs := rset.Load(filepath.Join(dir, "rules.toml"))
+		if len(errs) != 1 {
+			t.Fatalf("Error count mismatch: %d != 1", len(errs))
+		}
+
+		if errors.Is(errs[0], ErrUnknownRuleFormat) {
+			t.Error("Unreadable file reported as unknown format")
+		}
+
+		writeRuleFile(t, filepath.Join(dir, "rules.toml"), "")
+
+		errs = rset.Load(filepath.Join(dir, "rules.toml"))
+		if len(errs) != 1 || !errors.Is(errs[0], ErrUnknownRuleFormat) {
+			t.Errorf("Unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("Reload and rollback", func(t *testing.T) {
+		if errs := rset.Load(jsonFile); len(errs) > 0 {
+			t.Fatalf("Unexpected errors: %v", errs)
+		}
+
+		if rset.Current().Version != 2 || rset.Previous().Version != 1 {
+			t.Fatal("Versions not rotated")
+		}
+
+		if errs := rset.Reload(); len(errs) > 0 {
+			t.Fatalf("Unexpected errors: %v", errs)
+		}
+
+		if rset.Current().Version != 3 || rset.Current().Files[0] != jsonFile {
+			t.Fatal("Reload did not use the loaded files")
+		}
+
+		if err := rset.Rollback(); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if rset.Current().Version != 2 || rset.Previous() != nil {
+			t.Error("Rollback did not restore the previous version")
+		}
+
+		if err := rset.Rollback(); !errors.Is(err, ErrNoPreviousVersion) {
+			t.Errorf("Unexpected error: %#v", err)
+		}
+	})
+}
+
+func TestRuleSetConcurrentSwap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yaml")
+
+	writeRuleFile(t, path, rulesYAML)
+
+	rset, _ := newTestRuleSet(t)
+	if errs := rset.Load(path); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	var wg sync.WaitGroup
+
+	stop := make(chan struct{})
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+
+				default:
+					rset.Evaluate(NewDataInputFromMap(NormalWeather))
+				}
+			}
+		}()
+	}
+
+	for range 20 {
+		if errs := rset.Reload(); len(errs) > 0 {
+			t.Errorf("Unexpected errors: %v", errs)
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if rset.Current().Version != 21 {
+		t.Errorf("Version mismatch: %d != 21", rset.Current().Version)
+	}
+}
+
+func TestRuleSetWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yaml")
+
+	writeRuleFile(t, path, rulesYAML)
+
+	rset, _ := newTestRuleSet(t)
+	if errs := rset.Load(path); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		rset.Watch(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+
+	waitVersion := func(want uint64) {
+		t.Helper()
+
+		deadline := time.Now().Add(2 * time.Second)
+
+		for rset.Current().Version != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Version mismatch: %d != %d",
+					rset.Current().Version,
+					want)
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Broken rules are not swapped in.
+	writeRuleFile(t, path, badRulesYAML)
+	time.Sleep(50 * time.Millisecond)
+	waitVersion(1)
+
+	writeRuleFile(t, path, rulesYAML+"\n")
+	waitVersion(2)
+
+	cancel()
+	<-done
+}
+
+// * ruleset_test.go ends here.
@@ -0,0 +1,229 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// trace.go --- Evaluation tracing.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A trace records every node visited while evaluating an input: the
+// predicate, the attribute it looked at, the value it expected and the
+// value it found, whether it held, and the actions or failures that ran
+// as a result.
+//
+// Operands of `all`, `any` and `not` groups are evaluated inside the group
+// predicate, so the trace evaluates them again to record their results.
+// Predicates are expected to be free of side effects, so this only costs
+// time.
+//
+// A trace can be written as JSON, or overlaid on the graph by passing it
+// to `ExportToDOTWithOptions`.
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Interface:
+
+// Predicates that can describe their operands for tracing.
+//
+// Predicates built on `MetaPredicate` implement this.  Predicates that do
+// not are traced without attribute and values.
+type TraceablePredicate interface {
+	// Return the attribute on which the predicate operates.
+	Attribute() string
+
+	// Return the predicate's filter value.
+	Expected() any
+
+	// Return the predicate's input value, and whether it was present.
+	Actual(Filterable) (any, bool)
+}
+
+// ** Types:
+
+// Evaluation trace.
+type Trace struct {
+	results  map[*node]bool // Results for visited nodes.
+	Input    string         `json:"input"`
+	Steps    []*TraceStep   `json:"steps"`
+	Actions  []string       `json:"actions,omitempty"`
+	Failures []string       `json:"failures,omitempty"`
+}
+
+// A node visited during evaluation.
+type TraceStep struct {
+	Expected    any          `json:"expected,omitempty"`
+	Actual      any          `json:"actual,omitempty"`
+	Instruction string       `json:"instruction"`
+	Token       string       `json:"token"`
+	Predicate   string       `json:"predicate"`
+	Attribute   string       `json:"attribute,omitempty"`
+	Operands    []*TraceStep `json:"operands,omitempty"`
+	Actions     []string     `json:"actions,omitempty"`
+	Failures    []string     `json:"failures,omitempty"`
+	Depth       int          `json:"depth"`
+	Missing     bool         `json:"missing,omitempty"`
+	Result      bool         `json:"result"`
+}
+
+// ** Methods:
+
+// Was the given node visited, and if so what was its result?
+func (t *Trace) lookup(current *node) (bool, bool) {
+	if t == nil {
+		return false, false
+	}
+
+	result, visited := t.results[current]
+
+	return result, visited
+}
+
+// Record a visit to a node in the graph.
+func (t *Trace) record(
+	ctx context.Context,
+	current *node,
+	input Filterable,
+	result bool,
+	depth int,
+) *TraceStep {
+	step := t.describe(ctx, current, input, result, depth)
+	t.Steps = append(t.Steps, step)
+
+	return step
+}
+
+// Record that an action ran.
+func (t *Trace) recordAction(step *TraceStep, action *nodeAction) {
+	name := actionName(action)
+
+	step.Actions = append(step.Actions, name)
+	t.Actions = append(t.Actions, name)
+}
+
+// Record that a failure action ran.
+func (t *Trace) recordFailure(step *TraceStep, action *nodeAction) {
+	name := actionName(action)
+
+	step.Failures = append(step.Failures, name)
+	t.Failures = append(t.Failures, name)
+}
+
+// Describe a node and, recursively, its operands.
+func (t *Trace) describe(
+	ctx context.Context,
+	current *node,
+	input Filterable,
+	result bool,
+	depth int,
+) *TraceStep {
+	pred := current.Predicate
+	step := &TraceStep{
+		Instruction: pred.Instruction(),
+		Token:       pred.Token(),
+		Predicate:   pred.String(),
+		Depth:       depth,
+		Result:      result,
+	}
+
+	if traceable, ok := pred.(TraceablePredicate); ok {
+		actual, found := traceable.Actual(input)
+
+		step.Attribute = traceable.Attribute()
+		step.Expected = traceValue(traceable.Expected())
+		step.Actual = traceValue(actual)
+		step.Missing = !found
+	}
+
+	for _, operand := range current.Operands {
+		step.Operands = append(
+			step.Operands,
+			t.describe(
+				ctx,
+				operand,
+				input,
+				operand.Predicate.Eval(ctx, input),
+				depth+1))
+	}
+
+	t.results[current] = result
+
+	return step
+}
+
+// Write the trace as indented JSON.
+func (t *Trace) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return errors.WithStack(encoder.Encode(t))
+}
+
+// ** Functions:
+
+// Return a value in a form that can be written as JSON.
+//
+// Values that cannot be marshalled are replaced by their Go syntax
+// representation.
+func traceValue(val any) any {
+	if val == nil {
+		return nil
+	}
+
+	if _, err := json.Marshal(val); err != nil {
+		return fmt.Sprintf("%#v", val)
+	}
+
+	return val
+}
+
+// Create a new trace for the given input.
+func newTrace(input Filterable) *Trace {
+	return &Trace{
+		results: make(map[*node]bool),
+		Input:   input.String(),
+		Steps:   make([]*TraceStep, 0),
+	}
+}
+
+// * trace.go ends here.
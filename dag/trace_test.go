@@ -0,0 +1,183 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// trace_test.go --- Evaluation tracing tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package dag
+
+// * Imports:
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Code:
+
+// ** Tests:
+
+//nolint:funlen
+func TestTrace(t *testing.T) {
+	const traceRules = `
+- name: cold
+  conditions:
+    - attribute: type
+      operator: string-equal
+      value: weather
+    - attribute: temp
+      operator: '<='
+      value: 10
+  action:
+    name: cold_action
+    perform: log
+    params:
+      message: Cold!
+  failure:
+    name: not_cold
+    perform: log
+    params:
+      message: Not cold!
+- name: calm
+  conditions:
+    - not:
+        attribute: wind
+        operator: '>'
+        value: 20
+  action:
+    name: calm_action
+    perform: log
+    params:
+      message: Calm!`
+
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("Could not set logger DI: %#v", err)
+	}
+
+	rules, err := ParseFromYAML(traceRules)
+	if err != nil {
+		t.Fatalf("YAML: %#v", err)
+	}
+
+	cmplr := NewCompiler(ctx, &MockActions{})
+	if issues := cmplr.Compile(rules); len(issues) > 0 {
+		t.Fatalf("Compiler issues: %v", issues)
+	}
+
+	trace := cmplr.EvaluateWithTrace(
+		NewDataInputFromMap(map[string]any{"type": "weather", "temp": 19}))
+
+	t.Run("Steps", func(t *testing.T) {
+		// root, type, temp, not.
+		if len(trace.Steps) != 4 {
+			t.Fatalf("Step count mismatch: %d != 4", len(trace.Steps))
+		}
+
+		temp := trace.Steps[2]
+		if temp.Attribute != "temp" || temp.Expected != 10 || temp.Actual != 19 {
+			t.Errorf("Values not traced: %#v", temp)
+		}
+
+		if temp.Result || temp.Depth != 2 || temp.Failures[0] != "not_cold" {
+			t.Errorf("Result not traced: %#v", temp)
+		}
+
+		not := trace.Steps[3]
+		if !not.Result || len(not.Operands) != 1 {
+			t.Fatalf("Group not traced: %#v", not)
+		}
+
+		if wind := not.Operands[0]; !wind.Missing || wind.Result {
+			t.Errorf("Operand not traced: %#v", wind)
+		}
+
+		if len(trace.Actions) != 1 || trace.Actions[0] != "calm_action" {
+			t.Errorf("Actions mismatch: %v", trace.Actions)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var (
+			buf    bytes.Buffer
+			parsed map[string]any
+		)
+
+		if err := trace.WriteJSON(&buf); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if failures, ok := parsed["failures"].([]any); !ok || failures[0] != "not_cold" {
+			t.Errorf("Failures mismatch: %v", parsed["failures"])
+		}
+	})
+
+	t.Run("DOT", func(t *testing.T) {
+		var plain, traced strings.Builder
+
+		cmplr.Export(&plain)
+		cmplr.ExportTrace(&traced, trace)
+
+		if strings.Contains(plain.String(), "penwidth=3") {
+			t.Error("Untraced export is highlighted")
+		}
+
+		for _, want := range []string{
+			`BORDER="3" COLOR="#2e7d32"`,
+			`BORDER="3" COLOR="#c62828"`,
+			tracePassed,
+			traceFailed,
+			traceUntaken,
+		} {
+			if !strings.Contains(traced.String(), want) {
+				t.Errorf("DOT overlay lacks %q", want)
+			}
+		}
+	})
+
+	t.Run("Not traceable", func(t *testing.T) {
+		if traceValue(func() {}) == nil {
+			t.Error("Unmarshallable value dropped")
+		}
+	})
+}
+
+// * trace_test.go ends here.
@@ -111,15 +111,28 @@ type RuleSpec struct {
 }
 
 // Condition specification.
+//
+// A condition is either a predicate, given by `Attribute`, `Operator` and
+// `Value`, or exactly one of the `All`, `Any` and `Not` groups, which may
+// be nested.
 type ConditionSpec struct {
 	// Value to check.
-	Value any `json:"value" yaml:"value"`
+	Value any `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Negated condition.
+	Not *ConditionSpec `json:"not,omitempty" yaml:"not,omitempty"`
 
 	// Attribute to check.
-	Attribute string `json:"attribute" yaml:"attribute"`
+	Attribute string `json:"attribute,omitempty" yaml:"attribute,omitempty"`
 
 	// Predicate operator.
-	Operator string `json:"operator" yaml:"operator"`
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+
+	// Conditions that must all be true.
+	All []ConditionSpec `json:"all,omitempty" yaml:"all,omitempty"`
+
+	// Conditions of which at least one must be true.
+	Any []ConditionSpec `json:"any,omitempty" yaml:"any,omitempty"`
 }
 
 // Action specification.
@@ -0,0 +1,214 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// agenda.go --- Agenda and conflict resolution.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// Each rule whose conditions hold becomes an activation on the agenda.  One
+// activation fires per cycle, chosen by a conflict resolution strategy.
+//
+// Every fact an activation read carries a time tag: the working memory
+// version at which the fact last changed.  The built-in strategies are
+// modelled on those in OPS5 and CLIPS:
+//
+//   - Salience: higher salience first.
+//   - Recency: most recently changed fact first.
+//   - Specificity: more conditions first.
+//   - LEX: time tags compared newest first, then specificity.
+//   - MEA: time tag of the first condition, then as LEX.
+//
+// All of them compare salience first, and ties are always broken by the
+// order in which the rules were given to the engine.
+
+// * Package:
+
+package expertsys
+
+// * Imports:
+
+import (
+	"cmp"
+	"slices"
+)
+
+// * Variables:
+
+var (
+	// Fire the activation with the highest salience.
+	SalienceStrategy Strategy = StrategyFunc(bySalience) //nolint:gochecknoglobals
+
+	// Fire the activation that read the most recently changed fact.
+	RecencyStrategy Strategy = StrategyFunc(byRecency) //nolint:gochecknoglobals
+
+	// Fire the activation of the rule with the most conditions.
+	SpecificityStrategy Strategy = StrategyFunc(bySpecificity) //nolint:gochecknoglobals
+
+	// OPS5's LEX strategy.
+	LEXStrategy Strategy = StrategyFunc(byLEX) //nolint:gochecknoglobals
+
+	// OPS5's MEA strategy.
+	MEAStrategy Strategy = StrategyFunc(byMEA) //nolint:gochecknoglobals
+)
+
+// * Code:
+
+// ** Types:
+
+// Conflict resolution strategy.
+type Strategy interface {
+	// Compare two activations.
+	//
+	// Returns a negative number if `lhs` should fire before `rhs`, a
+	// positive number if it should fire after, or zero if there is no
+	// preference.
+	Compare(lhs, rhs *Activation) int
+}
+
+// Function that implements `Strategy`.
+type StrategyFunc func(lhs, rhs *Activation) int
+
+// Identity of an activation: a rule and the fact it is bound to, if any.
+type activationKey struct {
+	rule int
+	fact FactID
+}
+
+// A rule whose conditions hold, waiting to fire.
+type Activation struct {
+	rule        *engineRule    // Rule to fire.
+	Facts       map[string]any // Facts read by the rule when it matched.
+	Fact        *Fact          // Templated fact the rule is bound to.
+	key         string         // Refraction key.
+	akey        activationKey  // Identity.
+	Rule        string         // Rule name.
+	Tags        []uint64       // Time tags of the facts, in condition order.
+	Salience    int            // Rule salience.
+	Specificity int            // Number of conditions in the rule.
+	Order       int            // Position of the rule in the engine.
+	Cycle       int            // Cycle in which the rule matched.
+}
+
+// ** Methods:
+
+// Compare two activations.
+func (fn StrategyFunc) Compare(lhs, rhs *Activation) int {
+	return fn(lhs, rhs)
+}
+
+// Return the newest time tag.
+func (act *Activation) Recency() uint64 {
+	var newest uint64
+
+	for _, tag := range act.Tags {
+		newest = max(newest, tag)
+	}
+
+	return newest
+}
+
+// ** Functions:
+
+// Sort activations into the order in which they should fire.
+func sortAgenda(agenda []*Activation, strategy Strategy) {
+	slices.SortStableFunc(agenda, func(lhs, rhs *Activation) int {
+		if res := strategy.Compare(lhs, rhs); res != 0 {
+			return res
+		}
+
+		return cmp.Or(
+			cmp.Compare(lhs.Order, rhs.Order),
+			cmp.Compare(lhs.akey.fact, rhs.akey.fact))
+	})
+}
+
+func bySalience(lhs, rhs *Activation) int {
+	return cmp.Compare(rhs.Salience, lhs.Salience)
+}
+
+func byRecency(lhs, rhs *Activation) int {
+	return cmp.Or(
+		bySalience(lhs, rhs),
+		cmp.Compare(rhs.Recency(), lhs.Recency()))
+}
+
+func bySpecificity(lhs, rhs *Activation) int {
+	return cmp.Or(
+		bySalience(lhs, rhs),
+		cmp.Compare(rhs.Specificity, lhs.Specificity))
+}
+
+func byLEX(lhs, rhs *Activation) int {
+	return cmp.Or(
+		bySalience(lhs, rhs),
+		compareTags(lhs.Tags, rhs.Tags),
+		cmp.Compare(rhs.Specificity, lhs.Specificity))
+}
+
+func byMEA(lhs, rhs *Activation) int {
+	return cmp.Or(
+		bySalience(lhs, rhs),
+		cmp.Compare(firstTag(rhs), firstTag(lhs)),
+		byLEX(lhs, rhs))
+}
+
+// Compare time tags as LEX does.
+//
+// Each set of tags is sorted newest first, and the sets are compared
+// element by element.  The first newer tag wins; if one set runs out first
+// then the longer set wins.
+func compareTags(lhs, rhs []uint64) int {
+	left := slices.Clone(lhs)
+	right := slices.Clone(rhs)
+
+	slices.Sort(left)
+	slices.Reverse(left)
+	slices.Sort(right)
+	slices.Reverse(right)
+
+	for idx := range min(len(left), len(right)) {
+		if res := cmp.Compare(right[idx], left[idx]); res != 0 {
+			return res
+		}
+	}
+
+	return cmp.Compare(len(right), len(left))
+}
+
+// Return the time tag of the first condition, if any.
+func firstTag(act *Activation) uint64 {
+	if len(act.Tags) == 0 {
+		return 0
+	}
+
+	return act.Tags[0]
+}
+
+// * agenda.go ends here.
@@ -31,6 +31,31 @@
 
 // * Comments:
 
+//
+// The engine compiles each rule on its own, keeping track of the facts the
+// rule's conditions read.  Matching a rule evaluates its conditions without
+// running its actions; matched rules become activations on a session's
+// agenda, and a conflict resolution strategy picks one to fire each cycle.
+// See agenda.go for the strategies.
+//
+// Once every rule has been matched, a session only matches rules that read
+// a fact changed since the last cycle, which is what a Rete network's alpha
+// memories buy you without the join network.  This relies on the working
+// memory implementing `ChangeTracker`; working memory that does not is
+// matched in full every cycle.
+//
+// A rule whose conditions read `template.field` keys of a defined template
+// is matched once for each fact of that template, and each match is its
+// own activation.  Its actions see that fact's fields as `template.field`
+// keys, and setting one modifies the fact.  A rule binds at most one
+// template: the first one its conditions mention.
+//
+// Facts created by the actions of a rule marked `Logical` are retracted
+// once that activation's conditions stop holding.  Facts that already
+// existed when the action set them are left alone.
+//
+// Rules' failure actions are not used by the engine.
+
 // * Package:
 
 package expertsys
@@ -38,12 +63,20 @@ package expertsys
 // * Imports:
 
 import (
+	"context"
+	"slices"
+
 	"github.com/Asmodai/gohacks/dag"
 	"github.com/Asmodai/gohacks/errx"
 )
 
 // * Constants:
 
+const (
+	// Action that the engine uses to detect a match.
+	matchPerform = "match"
+)
+
 // * Variables:
 
 var (
@@ -52,29 +85,338 @@ var (
 
 // * Code:
 
-// ** Type:
+// ** Types:
+
+// A rule compiled on its own.
+type engineRule struct {
+	matcher     dag.Compiler   // Graph for the rule's conditions.
+	actions     []dag.ActionFn // The rule's actions.
+	name        string         // Rule name.
+	deps        []string       // Facts read, in condition order.
+	templates   []string       // Possible templates, in condition order.
+	salience    int            // Rule salience.
+	specificity int            // Number of conditions.
+	logical     bool           // Are created facts logically supported?
+}
+
+// Actions for the matcher graphs.
+//
+// The only action records a match on the `matchProbe` being evaluated.
+type matchActions struct{}
+
+// Working memory being matched.
+type matchProbe struct {
+	WorkingMemory
+
+	hit bool
+}
+
+// Working memory as seen by a rule bound to a templated fact.
+type factView struct {
+	WorkingMemory
+
+	fact *Fact
+}
+
+// Working memory that records the facts created through it.
+type supportRecorder struct {
+	WorkingMemory
+
+	keys  []string
+	facts []FactID
+}
 
 // Expert system engine.
 type Engine struct {
-	cmplr dag.Compiler
+	ctx      context.Context  // Context passed to actions.
+	strategy Strategy         // Conflict resolution strategy.
+	rules    []*engineRule    // Rules, in the order given.
+	index    map[string][]int // Rules that read each fact.
 }
 
+// ** Methods:
+
+// *** Match actions:
+
+func (ma *matchActions) Builder(_ string, _ dag.ActionParams) (dag.ActionFn, error) {
+	return func(_ context.Context, input dag.Filterable) {
+		if probe, ok := input.(*matchProbe); ok {
+			probe.hit = true
+		}
+	}, nil
+}
+
+// *** Fact view:
+
+// Return the value of the key, looking in the bound fact first.
+func (v *factView) Get(key string) (any, bool) {
+	if template, field, ok := splitFieldKey(key); ok && template == v.fact.Template {
+		val, found := v.fact.Fields[field]
+
+		return val, found
+	}
+
+	return v.WorkingMemory.Get(key)
+}
+
+// Set the value of the key, modifying the bound fact if it is one of its
+// fields.
+func (v *factView) Set(key string, val any) bool {
+	template, field, ok := splitFieldKey(key)
+	if !ok || template != v.fact.Template {
+		return v.WorkingMemory.Set(key, val)
+	}
+
+	changed, err := v.WorkingMemory.Modify(v.fact.ID, field, val)
+	if err != nil || !changed {
+		return false
+	}
+
+	v.fact.Fields[field] = val
+
+	return true
+}
+
+// Return a sorted list of keys, including the bound fact's fields.
+func (v *factView) Keys() []string {
+	keys := v.WorkingMemory.Keys()
+
+	for field := range v.fact.Fields {
+		keys = append(keys, fieldKey(v.fact.Template, field))
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// *** Support recorder:
+
+// Set the value of the key, recording it if the key is new.
+func (r *supportRecorder) Set(key string, val any) bool {
+	_, existed := r.WorkingMemory.Get(key)
+
+	changed := r.WorkingMemory.Set(key, val)
+	if changed && !existed {
+		r.keys = append(r.keys, key)
+	}
+
+	return changed
+}
+
+// Assert a new fact, recording its ID.
+func (r *supportRecorder) Assert(template string, fields map[string]any) (FactID, error) {
+	id, err := r.WorkingMemory.Assert(template, fields)
+	if err != nil {
+		return id, errx.WithStack(err)
+	}
+
+	r.facts = append(r.facts, id)
+
+	return id, nil
+}
+
+// *** Engine:
+
+// Fire rules until no activations are left.
+//
+// Returns the number of cycles taken, including the final one that found
+// nothing to fire, or `ErrNotStable` if there were still activations after
+// `maxIters` cycles.
+//
+// This is shorthand for running a new session.
 func (e *Engine) RunToFixpoint(wmem WorkingMemory, maxIters int) (int, error) {
-	for iter := range maxIters {
-		before := wmem.Version()
+	return e.NewSession(wmem).Run(maxIters)
+}
 
-		e.cmplr.Evaluate(wmem)
+// Create a new session for the given working memory.
+func (e *Engine) NewSession(wmem WorkingMemory) *Session {
+	tracker, _ := wmem.(ChangeTracker)
 
-		if wmem.Version() == before {
-			return iter + 1, nil
+	return &Session{
+		engine:  e,
+		wmem:    wmem,
+		tracker: tracker,
+		active:  make(map[activationKey]*Activation),
+		matched: make(map[activationKey]struct{}),
+		fired:   make(map[activationKey]string),
+		support: make(map[activationKey]*support),
+	}
+}
+
+// Return the names of the rules that read the given fact.
+func (e *Engine) Dependents(key string) []string {
+	names := make([]string, 0, len(e.index[key]))
+
+	for _, idx := range e.index[key] {
+		names = append(names, e.rules[idx].name)
+	}
+
+	return names
+}
+
+// Return the indices of all rules.
+func (e *Engine) allRules() []int {
+	rules := make([]int, len(e.rules))
+
+	for idx := range rules {
+		rules[idx] = idx
+	}
+
+	return rules
+}
+
+// Return the indices of rules that read any of the given facts, in rule
+// order.
+func (e *Engine) affectedRules(keys []string) []int {
+	seen := make(map[int]struct{})
+	rules := make([]int, 0)
+
+	for _, key := range keys {
+		for _, idx := range e.index[key] {
+			if _, found := seen[idx]; found {
+				continue
+			}
+
+			seen[idx] = struct{}{}
+			rules = append(rules, idx)
 		}
 	}
 
-	return 0, errx.WithMessagef(
-		ErrNotStable,
-		"version stuck at %d after %d iterations",
-		wmem.Version(),
-		maxIters)
+	slices.Sort(rules)
+
+	return rules
+}
+
+// ** Functions:
+
+// Collect the facts read by the given conditions, in order.
+//
+// Returns the number of predicates in the conditions.
+func collectDeps(conds []dag.ConditionSpec, deps *[]string) int {
+	count := 0
+
+	for _, cond := range conds {
+		if len(cond.Operator) > 0 {
+			count++
+
+			if !slices.Contains(*deps, cond.Attribute) {
+				*deps = append(*deps, cond.Attribute)
+			}
+		}
+
+		if cond.Not != nil {
+			count += collectDeps([]dag.ConditionSpec{*cond.Not}, deps)
+		}
+
+		count += collectDeps(cond.All, deps)
+		count += collectDeps(cond.Any, deps)
+	}
+
+	return count
+}
+
+// Compile a rule on its own.
+func compileEngineRule(
+	ctx context.Context,
+	acmplr dag.Compiler,
+	rule Rule,
+) (*engineRule, []error) {
+	if rule.Action != nil && len(rule.Actions) > 0 {
+		return nil, []error{errx.WithMessagef(
+			dag.ErrRuleActionAndActions,
+			"rule '%s' has both action and actions set",
+			rule.Name)}
+	}
+
+	matcher := dag.NewCompiler(ctx, &matchActions{})
+	errs := matcher.Compile([]dag.RuleSpec{{
+		Name:       rule.Name,
+		Conditions: rule.Conditions,
+		Action:     &dag.ActionSpec{Name: rule.Name, Perform: matchPerform},
+	}})
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	erule := &engineRule{
+		matcher:   matcher,
+		name:      rule.Name,
+		deps:      make([]string, 0),
+		templates: make([]string, 0),
+		salience:  rule.Salience,
+		logical:   rule.Logical,
+	}
+
+	erule.specificity = collectDeps(rule.Conditions, &erule.deps)
+
+	for _, key := range erule.deps {
+		if template, _, ok := splitFieldKey(key); ok && !slices.Contains(erule.templates, template) {
+			erule.templates = append(erule.templates, template)
+		}
+	}
+
+	for _, spec := range append([]*dag.ActionSpec{rule.Action}, rule.Actions...) {
+		if spec == nil || len(spec.Perform) == 0 {
+			continue
+		}
+
+		afn, err := acmplr.CompileAction(spec)
+		if err != nil {
+			return nil, []error{errx.WithMessagef(
+				err,
+				"Rule %q: Action %q",
+				rule.Name,
+				spec.Name)}
+		}
+
+		erule.actions = append(erule.actions, afn)
+	}
+
+	return erule, nil
+}
+
+// Create a new engine for the given rules.
+//
+// Activations are chosen by salience, then by rule order.  Any rules that
+// fail to compile are left out of the engine and the errors are returned.
+func NewEngine(ctx context.Context, actions dag.Actions, rules []Rule) (*Engine, []error) {
+	return NewEngineWithStrategy(ctx, actions, rules, SalienceStrategy)
+}
+
+// Create a new engine with the given conflict resolution strategy.
+func NewEngineWithStrategy(
+	ctx context.Context,
+	actions dag.Actions,
+	rules []Rule,
+	strategy Strategy,
+) (*Engine, []error) {
+	issues := make([]error, 0)
+	acmplr := dag.NewCompiler(ctx, actions)
+	inst := &Engine{
+		ctx:      ctx,
+		strategy: strategy,
+		rules:    make([]*engineRule, 0, len(rules)),
+		index:    make(map[string][]int),
+	}
+
+	for _, rule := range rules {
+		erule, errs := compileEngineRule(ctx, acmplr, rule)
+		if len(errs) > 0 {
+			issues = append(issues, errs...)
+
+			continue
+		}
+
+		for _, key := range erule.deps {
+			inst.index[key] = append(inst.index[key], len(inst.rules))
+		}
+
+		inst.rules = append(inst.rules, erule)
+	}
+
+	return inst, issues
 }
 
 // * engine.go ends here.
@@ -39,7 +39,8 @@ package expertsys
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/Asmodai/gohacks/contextdi"
@@ -54,6 +55,13 @@ import (
 
 // * Code:
 
+// ** Test working memory:
+
+// Working memory that hides its change tracking.
+type untrackedMemory struct {
+	WorkingMemory
+}
+
 // ** Mock actions:
 
 // *** Type:
@@ -89,6 +97,23 @@ func (ma *MockActions) Builder(fn string, params dag.ActionParams) (dag.ActionFn
 	}
 }
 
+// ** Utilities:
+
+// Return a rule that sets `to` when `from` is "yes".
+func chainRule(name, from, to string) Rule {
+	return Rule{RuleSpec: dag.RuleSpec{
+		Name: name,
+		Conditions: []dag.ConditionSpec{
+			{Attribute: from, Operator: "string-equal", Value: "yes"},
+		},
+		Action: &dag.ActionSpec{
+			Name:    "set_" + to,
+			Perform: "assert",
+			Params:  dag.ActionParams{"key": to, "value": "yes"},
+		},
+	}}
+}
+
 // ** Tests:
 
 func TestEngine(t *testing.T) {
@@ -102,8 +127,6 @@ func TestEngine(t *testing.T) {
 		t.Fatalf("Could not set debug flag to DI: %#v", err)
 	}
 
-	cmplr := dag.NewCompiler(ctx, &MockActions{})
-
 	rules := []dag.RuleSpec{
 		{
 			Name: "rule_b_depends_on_foo",
@@ -144,7 +167,7 @@ func TestEngine(t *testing.T) {
 		},
 	}
 
-	issues := cmplr.Compile(rules)
+	eng, issues := NewEngine(ctx, &MockActions{}, RulesFromSpecs(rules))
 	if len(issues) > 0 {
 		t.Log("Compiler issues:")
 		for idx := range issues {
@@ -153,8 +176,6 @@ func TestEngine(t *testing.T) {
 		t.Fatal("compiler issues present, see log output")
 	}
 
-	eng := &Engine{cmplr: cmplr}
-
 	t.Run("maxIters=1 should not stabilise", func(t *testing.T) {
 		wm := NewWorkingMemory()
 		wm.Set("type", "event")
@@ -187,8 +208,260 @@ func TestEngine(t *testing.T) {
 		t.Logf("Final state after %d iterations:", iter)
 		t.Logf("%#v", wm.(*workingMemory).facts)
 	})
+}
+
+func TestEngineIncremental(t *testing.T) {
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("set logger DI: %#v", err)
+	}
+
+	// A chain of rules, `step0` -> `step1` -> ... -> `step5`, plus rules
+	// that never match anything.
+	rules := make([]Rule, 0)
+
+	for idx := range 5 {
+		rules = append(rules, chainRule(
+			fmt.Sprintf("chain%d", idx),
+			fmt.Sprintf("step%d", idx),
+			fmt.Sprintf("step%d", idx+1)))
+	}
+
+	for idx := range 50 {
+		rules = append(rules, chainRule(
+			fmt.Sprintf("idle%d", idx),
+			fmt.Sprintf("idle%d", idx),
+			fmt.Sprintf("unused%d", idx)))
+	}
+
+	eng, issues := NewEngine(ctx, &MockActions{}, rules)
+	if len(issues) > 0 {
+		t.Fatalf("Compiler issues: %v", issues)
+	}
+
+	if deps := eng.Dependents("step2"); len(deps) != 1 || deps[0] != "chain2" {
+		t.Errorf("Dependents mismatch: %v", deps)
+	}
+
+	t.Run("Incremental", func(t *testing.T) {
+		wm := NewWorkingMemory()
+		wm.Set("step0", "yes")
+
+		sess := eng.NewSession(wm)
+
+		iters, err := sess.Run(16)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if val, _ := wm.Get("step5"); val != "yes" {
+			t.Fatal("Chain did not complete")
+		}
+
+		// The first cycle matches everything; after that, each cycle
+		// matches only the rule reading the fact the last one set.
+		if iters != 6 || sess.evals != len(rules)+4 {
+			t.Errorf("Work mismatch: %d iterations, %d evaluations",
+				iters,
+				sess.evals)
+		}
+	})
+
+	t.Run("Full", func(t *testing.T) {
+		wm := &untrackedMemory{NewWorkingMemory()}
+		wm.Set("step0", "yes")
+
+		sess := eng.NewSession(wm)
+
+		iters, err := sess.Run(16)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if iters != 6 || sess.evals != iters*len(rules) {
+			t.Errorf("Work mismatch: %d iterations, %d evaluations",
+				iters,
+				sess.evals)
+		}
+	})
+}
+
+//nolint:funlen
+func TestEngineAgenda(t *testing.T) {
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("set logger DI: %#v", err)
+	}
+
+	low := chainRule("low", "a", "x")
+	high := chainRule("high", "b", "y")
+	high.Salience = 10
+	specific := chainRule("specific", "a", "z")
+	specific.Conditions = append(specific.Conditions, dag.ConditionSpec{
+		Attribute: "b",
+		Operator:  "string-equal",
+		Value:     "yes",
+	})
+	rules := []Rule{low, specific, high}
+
+	newSession := func(t *testing.T, strategy Strategy) *Session {
+		t.Helper()
+
+		eng, issues := NewEngineWithStrategy(ctx, &MockActions{}, rules, strategy)
+		if len(issues) > 0 {
+			t.Fatalf("Compiler issues: %v", issues)
+		}
+
+		wm := NewWorkingMemory()
+		wm.Set("b", "yes")
+		wm.Set("a", "yes")
+
+		return eng.NewSession(wm)
+	}
+
+	names := func(acts []*Activation) []string {
+		out := make([]string, 0, len(acts))
+
+		for _, act := range acts {
+			out = append(out, act.Rule)
+		}
 
-	cmplr.Export(os.Stdout)
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []string
+	}{
+		{"Salience", SalienceStrategy, []string{"high", "low", "specific"}},
+		{"Recency", RecencyStrategy, []string{"high", "low", "specific"}},
+		{"Specificity", SpecificityStrategy, []string{"high", "specific", "low"}},
+		{"LEX", LEXStrategy, []string{"high", "specific", "low"}},
+		{"MEA", MEAStrategy, []string{"high", "specific", "low"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sess := newSession(t, test.strategy)
+
+			if got := names(sess.Agenda()); !slices.Equal(got, test.want) {
+				t.Errorf("Agenda mismatch: %v != %v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("Refraction", func(t *testing.T) {
+		sess := newSession(t, SalienceStrategy)
+
+		iters, err := sess.Run(8)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		want := []string{"high", "low", "specific"}
+		if got := names(sess.Fired()); iters != 4 || !slices.Equal(got, want) {
+			t.Fatalf("Fired mismatch after %d cycles: %v", iters, got)
+		}
+
+		// Setting a fact to the same value changes nothing.
+		sess.wmem.Set("a", "yes")
+
+		if act := sess.Step(); act != nil {
+			t.Errorf("%s fired twice", act.Rule)
+		}
+
+		// Changing it re-activates the rules that read it.
+		sess.wmem.Set("a", "no")
+		sess.wmem.Set("a", "yes")
+
+		if got := names(sess.Agenda()); !slices.Equal(got, []string{"low", "specific"}) {
+			t.Errorf("Agenda mismatch: %v", got)
+		}
+	})
+}
+
+func TestEngineFacts(t *testing.T) {
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("set logger DI: %#v", err)
+	}
+
+	ship := chainRule("ship", "order.status", "order.status")
+	ship.Conditions[0].Value = "paid"
+	ship.Action.Params["value"] = "shipped"
+
+	alarm := chainRule("alarm", "smoke", "alarm")
+	alarm.Logical = true
+
+	rules := []Rule{ship, alarm, chainRule("log", "smoke", "logged")}
+
+	eng, issues := NewEngine(ctx, &MockActions{}, rules)
+	if len(issues) > 0 {
+		t.Fatalf("Compiler issues: %v", issues)
+	}
+
+	t.Run("Templates", func(t *testing.T) {
+		wm := NewWorkingMemory()
+		_ = wm.DefineTemplate("order", "id", "status")
+
+		for idx, status := range []string{"paid", "pending", "paid"} {
+			_, _ = wm.Assert("order", map[string]any{"id": idx, "status": status})
+		}
+
+		sess := eng.NewSession(wm)
+
+		if _, err := sess.Run(8); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		bound := make([]FactID, 0)
+
+		for _, act := range sess.Fired() {
+			bound = append(bound, act.Fact.ID)
+		}
+
+		if !slices.Equal(bound, []FactID{1, 3}) {
+			t.Errorf("Fired mismatch: %v", bound)
+		}
+
+		want := []string{"shipped", "pending", "shipped"}
+
+		for idx, fact := range wm.Facts("order") {
+			if fact.Fields["status"] != want[idx] {
+				t.Errorf("Fact mismatch: %v", fact)
+			}
+		}
+	})
+
+	t.Run("Logical support", func(t *testing.T) {
+		wm := NewWorkingMemory()
+		wm.Set("smoke", "yes")
+
+		sess := eng.NewSession(wm)
+
+		if _, err := sess.Run(8); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if val, _ := wm.Get("alarm"); val != "yes" {
+			t.Fatal("alarm was not raised")
+		}
+
+		wm.Retract("smoke")
+
+		if act := sess.Step(); act != nil {
+			t.Errorf("%s fired unexpectedly", act.Rule)
+		}
+
+		if _, found := wm.Get("alarm"); found {
+			t.Error("alarm outlived its support")
+		}
+
+		if _, found := wm.Get("logged"); !found {
+			t.Error("unsupported fact was retracted")
+		}
+	})
 }
 
 // * engine_test.go ends here.
@@ -0,0 +1,338 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// facts.go --- Templated facts.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// Besides plain facts, one value per key, working memory holds templated
+// facts: any number of facts of a named template, each with its own set of
+// fields.
+//
+// Rule conditions refer to the fields of a template as `template.field`.
+// The engine matches such a rule once for each fact of the template; see
+// engine.go.  Changing a templated fact counts as a change to every
+// `template.field` key of its template for change tracking.
+
+// * Package:
+
+package expertsys
+
+// * Imports:
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Asmodai/gohacks/errx"
+)
+
+// * Constants:
+
+const (
+	// Separator between a template name and a field name.
+	fieldSeparator = "."
+)
+
+// * Variables:
+
+var (
+	ErrInvalidTemplate = errx.Base("invalid template")
+	ErrTemplateExists  = errx.Base("template already defined differently")
+	ErrUnknownTemplate = errx.Base("unknown template")
+	ErrUnknownField    = errx.Base("unknown template field")
+	ErrUnknownFact     = errx.Base("unknown fact")
+)
+
+// * Code:
+
+// ** Types:
+
+// Templated fact identifier.
+//
+// Identifiers start at 1 and are never reused.
+type FactID uint64
+
+// A templated fact.
+type Fact struct {
+	Fields   map[string]any // Field values.
+	Template string         // Template name.
+	ID       FactID         // Fact identifier.
+	Version  uint64         // Version at which the fact last changed.
+}
+
+// ** Methods:
+
+// *** Fact:
+
+// Return the string representation of the fact.
+func (f *Fact) String() string {
+	var sbld strings.Builder
+
+	fmt.Fprintf(&sbld, "%s#%d(", f.Template, f.ID)
+
+	for idx, field := range slices.Sorted(maps.Keys(f.Fields)) {
+		if idx > 0 {
+			sbld.WriteString(", ")
+		}
+
+		fmt.Fprintf(&sbld, "%s=%v", field, f.Fields[field])
+	}
+
+	sbld.WriteRune(')')
+
+	return sbld.String()
+}
+
+// Return a copy of the fact.
+func (f *Fact) clone() *Fact {
+	dup := *f
+	dup.Fields = maps.Clone(f.Fields)
+
+	return &dup
+}
+
+// *** Working memory:
+
+// Define a fact template with the given field names.
+//
+// Defining a template again with the same fields does nothing.
+func (wm *workingMemory) DefineTemplate(name string, fields ...string) error {
+	if !validName(name) {
+		return errx.WithMessagef(ErrInvalidTemplate, "template %q", name)
+	}
+
+	for _, field := range fields {
+		if !validName(field) {
+			return errx.WithMessagef(
+				ErrInvalidTemplate,
+				"template %q: field %q",
+				name,
+				field)
+		}
+	}
+
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	if old, found := wm.templates[name]; found {
+		if !slices.Equal(old, fields) {
+			return errx.WithMessagef(ErrTemplateExists, "template %q", name)
+		}
+
+		return nil
+	}
+
+	wm.templates[name] = slices.Clone(fields)
+
+	return nil
+}
+
+// Does a template with the given name exist?
+func (wm *workingMemory) HasTemplate(name string) bool {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+
+	_, found := wm.templates[name]
+
+	return found
+}
+
+// Assert a new fact of the given template.
+//
+// Fields that are not given are absent from the fact.
+func (wm *workingMemory) Assert(template string, fields map[string]any) (FactID, error) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	names, found := wm.templates[template]
+	if !found {
+		return 0, errx.WithMessagef(ErrUnknownTemplate, "template %q", template)
+	}
+
+	for field := range fields {
+		if !slices.Contains(names, field) {
+			return 0, errx.WithMessagef(
+				ErrUnknownField,
+				"template %q: field %q",
+				template,
+				field)
+		}
+	}
+
+	wm.lastID++
+
+	fact := &Fact{
+		Fields:   maps.Clone(fields),
+		Template: template,
+		ID:       wm.lastID,
+	}
+
+	if fact.Fields == nil {
+		fact.Fields = make(map[string]any)
+	}
+
+	wm.instances[fact.ID] = fact
+	wm.byTemplate[template] = append(wm.byTemplate[template], fact.ID)
+	wm.touchLocked(fact)
+
+	return fact.ID, nil
+}
+
+// Change a field of an existing fact.
+//
+// Returns `true` if the field was changed.  Setting a field to a value
+// approximately equal to its current one changes nothing.
+func (wm *workingMemory) Modify(id FactID, field string, val any) (bool, error) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	fact, found := wm.instances[id]
+	if !found {
+		return false, errx.WithMessagef(ErrUnknownFact, "fact %d", id)
+	}
+
+	if !slices.Contains(wm.templates[fact.Template], field) {
+		return false, errx.WithMessagef(
+			ErrUnknownField,
+			"template %q: field %q",
+			fact.Template,
+			field)
+	}
+
+	if old, found := fact.Fields[field]; found && equalish(old, val) {
+		return false, nil
+	}
+
+	fact.Fields[field] = val
+	wm.touchLocked(fact)
+
+	return true, nil
+}
+
+// Remove the fact with the given ID.
+//
+// Returns `true` if the fact existed.
+func (wm *workingMemory) RetractFact(id FactID) bool {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	fact, found := wm.instances[id]
+	if !found {
+		return false
+	}
+
+	delete(wm.instances, id)
+
+	wm.byTemplate[fact.Template] = slices.DeleteFunc(
+		wm.byTemplate[fact.Template],
+		func(elt FactID) bool { return elt == id })
+
+	wm.touchLocked(fact)
+
+	return true
+}
+
+// Return a copy of the fact with the given ID.
+func (wm *workingMemory) Fact(id FactID) (*Fact, bool) {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+
+	fact, found := wm.instances[id]
+	if !found {
+		return nil, false
+	}
+
+	return fact.clone(), true
+}
+
+// Return copies of every fact of the given template, oldest first.
+func (wm *workingMemory) Facts(template string) []*Fact {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+
+	ids := wm.byTemplate[template]
+	facts := make([]*Fact, 0, len(ids))
+
+	for _, id := range ids {
+		facts = append(facts, wm.instances[id].clone())
+	}
+
+	return facts
+}
+
+// Return copies of every templated fact, ordered by template and age.
+func (wm *workingMemory) allFacts() []*Fact {
+	wm.mu.RLock()
+	templates := slices.Sorted(maps.Keys(wm.byTemplate))
+	wm.mu.RUnlock()
+
+	facts := make([]*Fact, 0)
+
+	for _, template := range templates {
+		facts = append(facts, wm.Facts(template)...)
+	}
+
+	return facts
+}
+
+// Bump the version and record a change to every field of the fact's
+// template.
+func (wm *workingMemory) touchLocked(fact *Fact) {
+	version := wm.version.Add(1)
+	fact.Version = version
+
+	for _, field := range wm.templates[fact.Template] {
+		wm.changed[fieldKey(fact.Template, field)] = version
+	}
+}
+
+// ** Functions:
+
+// Return the key by which rules refer to a template field.
+func fieldKey(template, field string) string {
+	return template + fieldSeparator + field
+}
+
+// Split a key into template and field names.
+//
+// Returns `false` if the key does not name a template field.
+func splitFieldKey(key string) (string, string, bool) {
+	return strings.Cut(key, fieldSeparator)
+}
+
+// Is the name valid for a template or a field?
+func validName(name string) bool {
+	return len(name) > 0 && !strings.Contains(name, fieldSeparator)
+}
+
+// * facts.go ends here.
@@ -0,0 +1,117 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// rule.go --- Expert system rule specifications.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+//
+// Expert system rules are DAG rule specifications with extra settings
+// that only the engine uses.  They are read from JSON and YAML just like
+// DAG rules, with the extra settings alongside the usual keys:
+//
+//	- name: "ship"
+//	  salience: 10
+//	  logical: true
+//	  conditions:
+//	    - attribute: order.status
+//	      operator: string-equal
+//	      value: paid
+//	  action:
+//	    name: ship
+//	    perform: assert
+//	    params: {key: order.status, value: shipped}
+
+// * Package:
+
+package expertsys
+
+// * Imports:
+
+import (
+	"encoding/json"
+
+	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/errx"
+	"gopkg.in/yaml.v3"
+)
+
+// * Code:
+
+// ** Types:
+
+// Expert system rule specification.
+type Rule struct {
+	dag.RuleSpec `yaml:",inline"`
+
+	// Priority of the rule on the agenda.
+	//
+	// Higher values fire first.
+	Salience int `json:"salience,omitempty" yaml:"salience,omitempty"`
+
+	// Should facts created by the rule's actions be retracted once the
+	// rule's conditions no longer hold?
+	Logical bool `json:"logical,omitempty" yaml:"logical,omitempty"`
+}
+
+// ** Functions:
+
+// Create rules with default settings from DAG rule specifications.
+func RulesFromSpecs(specs []dag.RuleSpec) []Rule {
+	rules := make([]Rule, 0, len(specs))
+
+	for _, spec := range specs {
+		rules = append(rules, Rule{RuleSpec: spec})
+	}
+
+	return rules
+}
+
+// Parse rules from a string containing YAML.
+func ParseFromYAML(data string) ([]Rule, error) {
+	result := []Rule{}
+
+	if err := yaml.Unmarshal([]byte(data), &result); err != nil {
+		return []Rule{}, errx.WithStack(err)
+	}
+
+	return result, nil
+}
+
+// Parse rules from a string containing JSON.
+func ParseFromJSON(data string) ([]Rule, error) {
+	result := []Rule{}
+
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return []Rule{}, errx.WithStack(err)
+	}
+
+	return result, nil
+}
+
+// * rule.go ends here.
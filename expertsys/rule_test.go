@@ -0,0 +1,120 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// rule_test.go --- Rule specification tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package expertsys
+
+// * Imports:
+
+import (
+	"testing"
+)
+
+// * Constants:
+
+const (
+	testRulesYAML = `
+- name: ship
+  salience: 10
+  logical: true
+  conditions:
+    - attribute: order.status
+      operator: string-equal
+      value: paid
+  action:
+    name: ship
+    perform: assert
+    params: {key: order.status, value: shipped}
+- name: log
+  conditions:
+    - attribute: smoke
+      operator: string-equal
+      value: "yes"
+`
+
+	testRulesJSON = `[
+  {"name": "ship", "salience": 10, "logical": true,
+   "conditions": [{"attribute": "order.status", "operator": "string-equal", "value": "paid"}],
+   "action": {"name": "ship", "perform": "assert"}},
+  {"name": "log",
+   "conditions": [{"attribute": "smoke", "operator": "string-equal", "value": "yes"}]}
+]`
+)
+
+// * Code:
+
+// ** Tests:
+
+func TestParseRules(t *testing.T) {
+	parsers := map[string]func() ([]Rule, error){
+		"YAML": func() ([]Rule, error) { return ParseFromYAML(testRulesYAML) },
+		"JSON": func() ([]Rule, error) { return ParseFromJSON(testRulesJSON) },
+	}
+
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			rules, err := parse()
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			if len(rules) != 2 {
+				t.Fatalf("Unexpected rules: %#v", rules)
+			}
+
+			ship, log := rules[0], rules[1]
+
+			if ship.Name != "ship" || ship.Salience != 10 || !ship.Logical ||
+				len(ship.Conditions) != 1 || ship.Action == nil {
+				t.Errorf("Unexpected rule: %#v", ship)
+			}
+
+			if log.Name != "log" || log.Salience != 0 || log.Logical {
+				t.Errorf("Unexpected rule: %#v", log)
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		if _, err := ParseFromYAML("- name: [nope"); err == nil {
+			t.Error("Expected a YAML error")
+		}
+
+		if _, err := ParseFromJSON("[{"); err == nil {
+			t.Error("Expected a JSON error")
+		}
+	})
+}
+
+// * rule_test.go ends here.
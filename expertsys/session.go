@@ -0,0 +1,324 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// session.go --- Expert system sessions.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A session runs an engine's rules against one working memory, keeping the
+// agenda, the refraction state and the logical support for derived facts
+// between cycles.
+//
+// Refraction stops an activation from firing twice.  An activation is
+// identified by its rule and bound fact together with the time tags and
+// values of the facts it read, so a rule fires again only once one of
+// those facts has changed.
+
+// * Package:
+
+package expertsys
+
+// * Imports:
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Asmodai/gohacks/errx"
+)
+
+// * Code:
+
+// ** Types:
+
+// Facts created by an activation of a logical rule.
+type support struct {
+	keys  []string
+	facts []FactID
+}
+
+// Expert system session.
+type Session struct {
+	engine  *Engine                       // Engine whose rules are run.
+	wmem    WorkingMemory                 // Working memory.
+	tracker ChangeTracker                 // Change tracking, if supported.
+	active  map[activationKey]*Activation // Activations waiting to fire.
+	matched map[activationKey]struct{}    // Activations whose conditions hold.
+	fired   map[activationKey]string      // Refraction keys.
+	support map[activationKey]*support    // Logical support.
+	history []*Activation                 // Activations that have fired.
+	mark    uint64                        // Version at the last match.
+	evals   int                           // Number of rule matches performed.
+	cycle   int                           // Current cycle.
+	primed  bool                          // Have all rules been matched?
+}
+
+// ** Methods:
+
+// Return the agenda, in the order the activations would fire.
+func (s *Session) Agenda() []*Activation {
+	s.match()
+
+	agenda := slices.Collect(maps.Values(s.active))
+
+	sortAgenda(agenda, s.engine.strategy)
+
+	return agenda
+}
+
+// Return the activations that have fired, oldest first.
+func (s *Session) Fired() []*Activation {
+	return slices.Clone(s.history)
+}
+
+// Fire the first activation on the agenda.
+//
+// Returns the activation, or nil if the agenda is empty.
+func (s *Session) Step() *Activation {
+	agenda := s.Agenda()
+	if len(agenda) == 0 {
+		return nil
+	}
+
+	act := agenda[0]
+	target := s.wmem
+
+	var recorder *supportRecorder
+
+	if act.rule.logical {
+		recorder = &supportRecorder{WorkingMemory: target}
+		target = recorder
+	}
+
+	if act.Fact != nil {
+		target = &factView{WorkingMemory: target, fact: act.Fact.clone()}
+	}
+
+	for _, afn := range act.rule.actions {
+		afn(s.engine.ctx, target)
+	}
+
+	if recorder != nil && (len(recorder.keys) > 0 || len(recorder.facts) > 0) {
+		sup, found := s.support[act.akey]
+		if !found {
+			sup = &support{}
+			s.support[act.akey] = sup
+		}
+
+		sup.keys = append(sup.keys, recorder.keys...)
+		sup.facts = append(sup.facts, recorder.facts...)
+	}
+
+	s.fired[act.akey] = act.key
+	s.history = append(s.history, act)
+	delete(s.active, act.akey)
+
+	s.cycle++
+
+	return act
+}
+
+// Fire activations until the agenda is empty.
+//
+// Returns the number of cycles taken, including the final one that found
+// nothing to fire, or `ErrNotStable` if there were still activations after
+// `maxIters` cycles.
+func (s *Session) Run(maxIters int) (int, error) {
+	for iter := range maxIters {
+		if s.Step() == nil {
+			return iter + 1, nil
+		}
+	}
+
+	return 0, errx.WithMessagef(
+		ErrNotStable,
+		"version stuck at %d after %d iterations",
+		s.wmem.Version(),
+		maxIters)
+}
+
+// Match rules until no more logically supported facts are retracted.
+func (s *Session) match() {
+	for s.matchOnce() {
+	}
+}
+
+// Match the rules that read facts changed since the last match.
+//
+// Returns `true` if withdrawing logical support retracted any facts.
+func (s *Session) matchOnce() bool {
+	var rules []int
+
+	switch {
+	case !s.primed || s.tracker == nil:
+		rules = s.engine.allRules()
+		s.primed = true
+
+	case s.wmem.Version() == s.mark:
+		return false
+
+	default:
+		rules = s.engine.affectedRules(s.tracker.ChangedSince(s.mark))
+	}
+
+	s.mark = s.wmem.Version()
+
+	for _, idx := range rules {
+		s.forgetRule(idx)
+
+		for _, fact := range s.bindings(idx) {
+			s.evals++
+
+			act := s.activate(idx, fact)
+			if act == nil {
+				continue
+			}
+
+			s.matched[act.akey] = struct{}{}
+
+			if s.fired[act.akey] != act.key {
+				s.active[act.akey] = act
+			}
+		}
+	}
+
+	return s.withdrawSupport()
+}
+
+// Forget the activations of the given rule.
+func (s *Session) forgetRule(idx int) {
+	for akey := range s.matched {
+		if akey.rule == idx {
+			delete(s.matched, akey)
+			delete(s.active, akey)
+		}
+	}
+}
+
+// Return the facts a rule should be matched against.
+//
+// Returns a single nil fact if the rule is not bound to a template.
+func (s *Session) bindings(idx int) []*Fact {
+	for _, template := range s.engine.rules[idx].templates {
+		if s.wmem.HasTemplate(template) {
+			return s.wmem.Facts(template)
+		}
+	}
+
+	return []*Fact{nil}
+}
+
+// Match a rule, returning an activation if its conditions hold.
+func (s *Session) activate(idx int, fact *Fact) *Activation {
+	var input WorkingMemory = s.wmem
+
+	rule := s.engine.rules[idx]
+	akey := activationKey{rule: idx}
+
+	if fact != nil {
+		input = &factView{WorkingMemory: s.wmem, fact: fact}
+		akey.fact = fact.ID
+	}
+
+	probe := &matchProbe{WorkingMemory: input}
+
+	rule.matcher.Evaluate(probe)
+
+	if !probe.hit {
+		return nil
+	}
+
+	act := &Activation{
+		rule:        rule,
+		Facts:       make(map[string]any, len(rule.deps)),
+		Fact:        fact,
+		akey:        akey,
+		Rule:        rule.name,
+		Tags:        make([]uint64, len(rule.deps)),
+		Salience:    rule.salience,
+		Specificity: rule.specificity,
+		Order:       idx,
+		Cycle:       s.cycle,
+	}
+
+	for tidx, key := range rule.deps {
+		if val, found := input.Get(key); found {
+			act.Facts[key] = val
+		}
+
+		template, _, _ := splitFieldKey(key)
+
+		switch {
+		case fact != nil && template == fact.Template:
+			act.Tags[tidx] = fact.Version
+
+		case s.tracker != nil:
+			act.Tags[tidx] = s.tracker.ChangedAt(key)
+		}
+	}
+
+	act.key = fmt.Sprintf("%v %v", act.Tags, act.Facts)
+
+	return act
+}
+
+// Retract the facts supported by activations whose conditions no longer
+// hold.
+//
+// Returns `true` if any facts were retracted.
+func (s *Session) withdrawSupport() bool {
+	retracted := false
+
+	lost := slices.SortedFunc(maps.Keys(s.support), func(lhs, rhs activationKey) int {
+		return cmp.Or(cmp.Compare(lhs.rule, rhs.rule), cmp.Compare(lhs.fact, rhs.fact))
+	})
+
+	for _, akey := range lost {
+		if _, holds := s.matched[akey]; holds {
+			continue
+		}
+
+		for _, key := range s.support[akey].keys {
+			retracted = s.wmem.Retract(key) || retracted
+		}
+
+		for _, id := range s.support[akey].facts {
+			retracted = s.wmem.RetractFact(id) || retracted
+		}
+
+		delete(s.support, akey)
+	}
+
+	return retracted
+}
+
+// * session.go ends here.
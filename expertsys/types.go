@@ -56,6 +56,50 @@ type WorkingMemory interface {
 
 	// Return the current version of the working memory.
 	Version() uint64
+
+	// Remove the fact with the given key.
+	//
+	// Returns `true` if the fact existed.
+	Retract(string) bool
+
+	// Define a fact template with the given field names.
+	DefineTemplate(string, ...string) error
+
+	// Does a template with the given name exist?
+	HasTemplate(string) bool
+
+	// Assert a new fact of the given template.
+	Assert(string, map[string]any) (FactID, error)
+
+	// Change a field of an existing fact.
+	//
+	// Returns `true` if the field was changed.
+	Modify(FactID, string, any) (bool, error)
+
+	// Remove the fact with the given ID.
+	//
+	// Returns `true` if the fact existed.
+	RetractFact(FactID) bool
+
+	// Return a copy of the fact with the given ID.
+	Fact(FactID) (*Fact, bool)
+
+	// Return copies of every fact of the given template, oldest first.
+	Facts(string) []*Fact
+}
+
+// Change tracking interface.
+//
+// Working memory that implements this lets the engine re-evaluate only the
+// rules that read facts which have changed.
+type ChangeTracker interface {
+	// Return the keys of facts that were changed after the given
+	// version.
+	ChangedSince(uint64) []string
+
+	// Return the version at which the given fact last changed, or zero
+	// if it has never existed.
+	ChangedAt(string) uint64
 }
 
 // * types.go ends here.
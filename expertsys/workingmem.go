@@ -52,11 +52,16 @@ import (
 
 // Working memory implementation.
 //
-// Implements `WorkingMemory`.
+// Implements `WorkingMemory` and `ChangeTracker`.
 type workingMemory struct {
-	facts   map[string]any
-	version atomic.Uint64
-	mu      sync.RWMutex
+	facts      map[string]any
+	changed    map[string]uint64   // Version at which each fact last changed.
+	templates  map[string][]string // Field names, by template.
+	instances  map[FactID]*Fact    // Templated facts.
+	byTemplate map[string][]FactID // Templated fact IDs, oldest first.
+	lastID     FactID
+	version    atomic.Uint64
+	mu         sync.RWMutex
 }
 
 // ** Methods:
@@ -97,10 +102,28 @@ func (wm *workingMemory) Set(key string, val any) bool {
 		}
 
 		wm.facts[key] = val
+		wm.changed[key] = wm.version.Add(1)
 	}
 	wm.mu.Unlock()
 
-	wm.version.Add(1)
+	return true
+}
+
+// Remove the fact with the given key.
+//
+// Returns `true` if the fact existed.
+//
+// The memory's version number is incremented after successful removal.
+func (wm *workingMemory) Retract(key string) bool {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	if _, found := wm.facts[key]; !found {
+		return false
+	}
+
+	delete(wm.facts, key)
+	wm.changed[key] = wm.version.Add(1)
 
 	return true
 }
@@ -142,6 +165,14 @@ func (wm *workingMemory) String() string {
 		fmt.Fprintf(&sbld, "%s=%v", key, val)
 	}
 
+	for _, fact := range wm.allFacts() {
+		if sbld.Len() > 1 {
+			sbld.WriteString(", ")
+		}
+
+		sbld.WriteString(fact.String())
+	}
+
 	sbld.WriteRune('}')
 
 	return sbld.String()
@@ -152,6 +183,38 @@ func (wm *workingMemory) Version() uint64 {
 	return wm.version.Load()
 }
 
+// Return the version at which the given key last changed.
+func (wm *workingMemory) ChangedAt(key string) uint64 {
+	var version uint64
+
+	wm.mu.RLock()
+	{
+		version = wm.changed[key]
+	}
+	wm.mu.RUnlock()
+
+	return version
+}
+
+// Return a sorted list of keys changed after the given version.
+func (wm *workingMemory) ChangedSince(version uint64) []string {
+	keys := make([]string, 0)
+
+	wm.mu.RLock()
+	{
+		for key, changed := range wm.changed {
+			if changed > version {
+				keys = append(keys, key)
+			}
+		}
+	}
+	wm.mu.RUnlock()
+
+	sort.Strings(keys)
+
+	return keys
+}
+
 // ** Functions:
 
 // Is the lhs sufficiently equal to the rhs?
@@ -218,7 +281,11 @@ func equalish(lhs, rhs any) bool {
 // Create a new working memory instance.
 func NewWorkingMemory() WorkingMemory {
 	return &workingMemory{
-		facts: make(map[string]any),
+		facts:      make(map[string]any),
+		changed:    make(map[string]uint64),
+		templates:  make(map[string][]string),
+		instances:  make(map[FactID]*Fact),
+		byTemplate: make(map[string][]FactID),
 	}
 }
 
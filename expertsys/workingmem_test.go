@@ -38,11 +38,14 @@ package expertsys
 // * Imports:
 
 import (
+	"slices"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/Asmodai/gohacks/errx"
 )
 
 // * Code:
@@ -82,6 +85,155 @@ func TestWorkingMem(t *testing.T) {
 		}
 	})
 
+	t.Run("ChangedSince", func(t *testing.T) {
+		wm := NewWorkingMemory()
+		tracker, ok := wm.(ChangeTracker)
+		if !ok {
+			t.Fatal("working memory does not track changes")
+		}
+
+		wm.Set("a", 1)
+		wm.Set("b", 1)
+		mark := wm.Version()
+		wm.Set("a", 1)
+		wm.Set("c", 1)
+		wm.Set("b", 2)
+
+		got := tracker.ChangedSince(mark)
+		if len(got) != 2 || got[0] != "b" || got[1] != "c" {
+			t.Fatalf("expected [b c], got %v", got)
+		}
+
+		if got := tracker.ChangedSince(wm.Version()); len(got) != 0 {
+			t.Fatalf("expected no changes, got %v", got)
+		}
+	})
+
+	t.Run("Retract", func(t *testing.T) {
+		wm := NewWorkingMemory()
+		tracker, _ := wm.(ChangeTracker)
+
+		wm.Set("a", 1)
+
+		if !wm.Retract("a") {
+			t.Fatal("expected retraction of existing fact")
+		}
+
+		if _, found := wm.Get("a"); found {
+			t.Fatal("retracted fact still present")
+		}
+
+		if got := tracker.ChangedAt("a"); got != wm.Version() {
+			t.Fatalf("expected change at %d, got %d", wm.Version(), got)
+		}
+
+		if wm.Retract("a") {
+			t.Fatal("expected no retraction of missing fact")
+		}
+	})
+
+	t.Run("Templates", func(t *testing.T) {
+		wm := NewWorkingMemory()
+
+		if err := wm.DefineTemplate("order", "id", "status"); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := wm.DefineTemplate("order", "id", "status"); err != nil {
+			t.Fatalf("unexpected error on redefinition: %#v", err)
+		}
+
+		if err := wm.DefineTemplate("order", "id"); !errx.Is(err, ErrTemplateExists) {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		for _, fields := range [][]string{{""}, {"a.b"}} {
+			if err := wm.DefineTemplate("bad", fields...); !errx.Is(err, ErrInvalidTemplate) {
+				t.Fatalf("%v: unexpected error: %#v", fields, err)
+			}
+		}
+
+		if !wm.HasTemplate("order") || wm.HasTemplate("bad") {
+			t.Fatal("template lookup mismatch")
+		}
+	})
+
+	t.Run("Templated facts", func(t *testing.T) {
+		wm := NewWorkingMemory()
+		tracker, _ := wm.(ChangeTracker)
+
+		_ = wm.DefineTemplate("order", "id", "status")
+
+		first, err := wm.Assert("order", map[string]any{"id": 1, "status": "new"})
+		if err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		second, _ := wm.Assert("order", map[string]any{"id": 2})
+
+		if facts := wm.Facts("order"); len(facts) != 2 || facts[0].ID != first {
+			t.Fatalf("unexpected facts: %v", facts)
+		}
+
+		mark := wm.Version()
+
+		if changed, err := wm.Modify(second, "status", "paid"); !changed || err != nil {
+			t.Fatalf("expected change, got %v, %#v", changed, err)
+		}
+
+		if changed, _ := wm.Modify(second, "status", "paid"); changed {
+			t.Fatal("expected no change on identical value")
+		}
+
+		if got := tracker.ChangedSince(mark); !slices.Equal(got, []string{"order.id", "order.status"}) {
+			t.Fatalf("unexpected changes: %v", got)
+		}
+
+		fact, found := wm.Fact(second)
+		if !found || fact.Fields["status"] != "paid" || fact.Version != wm.Version() {
+			t.Fatalf("unexpected fact: %v", fact)
+		}
+
+		// Changing a copy does not change the fact.
+		fact.Fields["status"] = "lost"
+
+		if fact, _ := wm.Fact(second); fact.Fields["status"] != "paid" {
+			t.Fatalf("fact changed through copy: %v", fact)
+		}
+
+		if !wm.RetractFact(first) || wm.RetractFact(first) {
+			t.Fatal("retraction mismatch")
+		}
+
+		if facts := wm.Facts("order"); len(facts) != 1 || facts[0].ID != second {
+			t.Fatalf("unexpected facts: %v", facts)
+		}
+	})
+
+	t.Run("Templated fact errors", func(t *testing.T) {
+		wm := NewWorkingMemory()
+
+		_ = wm.DefineTemplate("order", "id")
+
+		if _, err := wm.Assert("invoice", nil); !errx.Is(err, ErrUnknownTemplate) {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if _, err := wm.Assert("order", map[string]any{"total": 1}); !errx.Is(err, ErrUnknownField) {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		id, _ := wm.Assert("order", nil)
+
+		if _, err := wm.Modify(id, "total", 1); !errx.Is(err, ErrUnknownField) {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if _, err := wm.Modify(id+1, "id", 1); !errx.Is(err, ErrUnknownFact) {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
 	t.Run("Time equality", func(t *testing.T) {
 		wm := NewWorkingMemory()
 
@@ -0,0 +1,131 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// config.go --- Durable key/value store configuration.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package kvstore
+
+// * Imports:
+
+import (
+	"time"
+
+	"github.com/Asmodai/gohacks/types"
+	"github.com/Asmodai/gohacks/wal"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	// Default interval between snapshots.
+	defaultSnapshotInterval types.Duration = types.Duration(5 * time.Minute)
+)
+
+// * Variables:
+
+var (
+	// Signalled when there is no directory given.
+	ErrNoDirectory error = errors.Base("no directory provided")
+)
+
+// * Code:
+
+// ** Types:
+
+type Config struct {
+	// Write-ahead log policy.
+	//
+	// Each logged value carries a one-byte operation code, so values
+	// may be at most `MaxValueBytes - 1` bytes long.
+	Policy wal.Policy `config_hide:"true" json:"-"`
+
+	// Directory holding the snapshot and the write-ahead log.
+	Dir string `json:"dir"`
+
+	// Interval between periodic snapshots.
+	//
+	// Snapshots are only written if something has changed since the
+	// last one.  A negative interval disables periodic snapshots.
+	SnapshotInterval types.Duration `json:"snapshot_interval"`
+
+	// Also write a snapshot after this many changes.
+	//
+	// 0 disables change-based snapshots.
+	SnapshotEvery int `json:"snapshot_every"`
+
+	// Use a segmented write-ahead log rather than a single file.
+	Segmented bool `json:"segmented"`
+
+	// Fsync each change before returning from `Put` or `Delete`.
+	//
+	// Concurrent writers share fsyncs.  A change is visible to readers
+	// as soon as it has been logged, before it is durable, and if the
+	// fsync fails then the change remains applied even though `Put` or
+	// `Delete` returns an error.
+	//
+	// Otherwise durability is left to the write-ahead log policy.
+	SyncWrites bool `json:"sync_writes"`
+}
+
+// ** Methods:
+
+// Validate the configuration.
+func (c *Config) Validate() []error {
+	errs := []error{}
+
+	if c.Dir == "" {
+		errs = append(errs, errors.WithStack(ErrNoDirectory))
+	}
+
+	if c.SnapshotInterval == 0 {
+		c.SnapshotInterval = defaultSnapshotInterval
+	}
+
+	if c.SnapshotEvery < 0 {
+		c.SnapshotEvery = 0
+	}
+
+	return errs
+}
+
+// ** Functions:
+
+// Create a configuration for a store in the given directory.
+func NewDefaultConfig(dir string) *Config {
+	return &Config{
+		Dir:              dir,
+		SnapshotInterval: defaultSnapshotInterval}
+}
+
+// * config.go ends here.
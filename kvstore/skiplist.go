@@ -0,0 +1,186 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// skiplist.go --- Ordered index for the key/value store.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A skip list keeps the keys in order so that range iteration is cheap,
+// while lookups, inserts and deletes stay logarithmic on average.  It is
+// not safe for concurrent use; the store provides the locking.
+
+// * Package:
+
+package kvstore
+
+// * Imports:
+
+import "math/rand/v2"
+
+// * Constants:
+
+const (
+	// Maximum height of the skip list.
+	//
+	// Enough for about 4^16 keys before lookups start to degrade.
+	maxLevel = 16
+
+	// One in this many nodes at each level is promoted to the next.
+	levelFanout = 4
+)
+
+// * Code:
+
+// ** Types:
+
+// A node in the skip list.
+type node struct {
+	key   string
+	value []byte
+	next  []*node
+}
+
+// Skip list of keys to values.
+type skiplist struct {
+	head   node
+	level  int
+	length int
+}
+
+// ** Methods:
+
+// Pick a height for a new node.
+func (sl *skiplist) randomLevel() int {
+	level := 1
+
+	for level < maxLevel && rand.IntN(levelFanout) == 0 { //nolint:gosec
+		level++
+	}
+
+	return level
+}
+
+// Find the first node whose key is not less than the given key.
+//
+// If `update` is non-nil, it is filled in with the last node before that
+// point at each level.
+func (sl *skiplist) seek(key string, update *[maxLevel]*node) *node {
+	cur := &sl.head
+
+	for lvl := sl.level - 1; lvl >= 0; lvl-- {
+		for cur.next[lvl] != nil && cur.next[lvl].key < key {
+			cur = cur.next[lvl]
+		}
+
+		if update != nil {
+			update[lvl] = cur
+		}
+	}
+
+	return cur.next[0]
+}
+
+// Return the first node, if any.
+func (sl *skiplist) first() *node {
+	return sl.head.next[0]
+}
+
+// Look up the value of the given key.
+func (sl *skiplist) get(key string) ([]byte, bool) {
+	found := sl.seek(key, nil)
+	if found == nil || found.key != key {
+		return nil, false
+	}
+
+	return found.value, true
+}
+
+// Set the value of the given key.
+func (sl *skiplist) put(key string, value []byte) {
+	var update [maxLevel]*node
+
+	found := sl.seek(key, &update)
+	if found != nil && found.key == key {
+		found.value = value
+
+		return
+	}
+
+	level := sl.randomLevel()
+	for lvl := sl.level; lvl < level; lvl++ {
+		update[lvl] = &sl.head
+	}
+
+	if level > sl.level {
+		sl.level = level
+	}
+
+	added := &node{key: key, value: value, next: make([]*node, level)}
+
+	for lvl := range level {
+		added.next[lvl] = update[lvl].next[lvl]
+		update[lvl].next[lvl] = added
+	}
+
+	sl.length++
+}
+
+// Remove the given key, returning false if it was not present.
+func (sl *skiplist) delete(key string) bool {
+	var update [maxLevel]*node
+
+	found := sl.seek(key, &update)
+	if found == nil || found.key != key {
+		return false
+	}
+
+	for lvl := range len(found.next) {
+		update[lvl].next[lvl] = found.next[lvl]
+	}
+
+	for sl.level > 1 && sl.head.next[sl.level-1] == nil {
+		sl.level--
+	}
+
+	sl.length--
+
+	return true
+}
+
+// ** Functions:
+
+// Create an empty skip list.
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:  node{next: make([]*node, maxLevel)},
+		level: 1}
+}
+
+// * skiplist.go ends here.
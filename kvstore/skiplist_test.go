@@ -0,0 +1,106 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// skiplist_test.go --- Ordered index tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package kvstore
+
+// * Imports:
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// * Code:
+
+// ** Tests:
+
+func TestSkiplist(t *testing.T) {
+	sl := newSkiplist()
+	want := map[string]string{}
+
+	for idx := range 5000 {
+		key := fmt.Sprintf("k%04d", rand.IntN(1000)) //nolint:gosec
+
+		if idx%3 == 0 {
+			_, had := want[key]
+			if sl.delete(key) != had {
+				t.Fatalf("%d: delete %q mismatch", idx, key)
+			}
+
+			delete(want, key)
+
+			continue
+		}
+
+		sl.put(key, []byte(key+"v"))
+		want[key] = key + "v"
+	}
+
+	if sl.length != len(want) {
+		t.Fatalf("Length mismatch: %d != %d", sl.length, len(want))
+	}
+
+	keys := make([]string, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	idx := 0
+	for cur := sl.first(); cur != nil; cur = cur.next[0] {
+		if cur.key != keys[idx] || string(cur.value) != want[cur.key] {
+			t.Fatalf("%d: %q != %q", idx, cur.key, keys[idx])
+		}
+
+		idx++
+	}
+
+	if idx != len(keys) {
+		t.Fatalf("Visited %d of %d keys", idx, len(keys))
+	}
+
+	if _, found := sl.get("missing"); found {
+		t.Error("Found a missing key")
+	}
+
+	if found := sl.seek("k0500", nil); found != nil && found.key < "k0500" {
+		t.Errorf("Seek landed before its key: %q", found.key)
+	}
+}
+
+// * skiplist_test.go ends here.
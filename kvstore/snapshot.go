@@ -0,0 +1,273 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// snapshot.go --- Key/value store snapshot files.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A snapshot file holds the whole map as of a given LSN:
+//
+//	[magic:u32][version:u32][lsn:u64][count:u64]
+//	count * ([klen:u32][vlen:u32][key][value])
+//	[crc:u32]
+//
+// All integers are little-endian, entries are in key order, and the CRC is
+// a CRC32C of everything before it.  Snapshots are written to a temporary
+// file and renamed into place, so a crash leaves either the old snapshot
+// or the new one.
+
+// * Package:
+
+package kvstore
+
+// * Imports:
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	// Name of the snapshot file within the store's directory.
+	SnapshotName = "SNAPSHOT"
+
+	// Snapshot magic number.
+	//
+	// This is the string `KVSN` expressed as a little-endian integer.
+	SnapshotMagic = 0x4E53564B
+
+	// Snapshot format version.
+	SnapshotVersion = 1
+
+	u32Size = 4
+	u64Size = 8
+
+	snapshotHeaderSize = u32Size + u32Size + u64Size + u64Size
+	snapshotEntrySize  = u32Size + u32Size
+
+	defaultFileMode = 0o644
+	defaultDirMode  = 0o755
+)
+
+// * Variables:
+
+var (
+	ErrInvalidSnapshot = errors.Base("invalid snapshot")
+)
+
+// * Code:
+
+// ** Functions:
+
+// Write the index to the snapshot file in the given directory.
+func writeSnapshot(dir string, lsn uint64, index *skiplist) error {
+	final := filepath.Join(dir, SnapshotName)
+	temp := final + ".tmp"
+
+	fptr, err := os.OpenFile(temp,
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		defaultFileMode)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	crc := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	wtr := bufio.NewWriter(io.MultiWriter(fptr, crc))
+
+	if err := encodeSnapshot(wtr, lsn, index); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	// Flush before taking the sum, so the hash has seen everything.
+	if err := wtr.Flush(); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if _, err := fptr.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32())); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if err := fptr.Sync(); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if err := fptr.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.Rename(temp, final); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return syncDir(dir)
+}
+
+// Encode the snapshot header and entries.
+func encodeSnapshot(wtr io.Writer, lsn uint64, index *skiplist) error {
+	scratch := make([]byte, 0, snapshotHeaderSize)
+	scratch = binary.LittleEndian.AppendUint32(scratch, SnapshotMagic)
+	scratch = binary.LittleEndian.AppendUint32(scratch, SnapshotVersion)
+	scratch = binary.LittleEndian.AppendUint64(scratch, lsn)
+	scratch = binary.LittleEndian.AppendUint64(scratch, uint64(index.length)) //nolint:gosec
+
+	if _, err := wtr.Write(scratch); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for cur := index.first(); cur != nil; cur = cur.next[0] {
+		scratch = scratch[:0]
+		scratch = binary.LittleEndian.AppendUint32(scratch, uint32(len(cur.key)))   //nolint:gosec
+		scratch = binary.LittleEndian.AppendUint32(scratch, uint32(len(cur.value))) //nolint:gosec
+
+		if _, err := wtr.Write(scratch); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := io.WriteString(wtr, cur.key); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := wtr.Write(cur.value); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// Read the snapshot file in the given directory.
+//
+// If there is no snapshot then an empty index and an LSN of zero are
+// returned.
+//
+//nolint:cyclop
+func readSnapshot(dir string) (uint64, *skiplist, error) {
+	index := newSkiplist()
+
+	data, err := os.ReadFile(filepath.Join(dir, SnapshotName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, index, nil
+		}
+
+		return 0, nil, errors.WithStack(err)
+	}
+
+	if len(data) < snapshotHeaderSize+u32Size {
+		return 0, nil, errors.WithMessagef(
+			ErrInvalidSnapshot,
+			"%d bytes is too short",
+			len(data))
+	}
+
+	body := data[:len(data)-u32Size]
+	want := binary.LittleEndian.Uint32(data[len(body):])
+
+	if crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)) != want {
+		return 0, nil, errors.WithMessage(ErrInvalidSnapshot, "CRC mismatch")
+	}
+
+	if binary.LittleEndian.Uint32(body) != SnapshotMagic {
+		return 0, nil, errors.WithMessage(ErrInvalidSnapshot, "bad magic")
+	}
+
+	if ver := binary.LittleEndian.Uint32(body[u32Size:]); ver != SnapshotVersion {
+		return 0, nil, errors.WithMessagef(
+			ErrInvalidSnapshot,
+			"unsupported version %d",
+			ver)
+	}
+
+	lsn := binary.LittleEndian.Uint64(body[u32Size+u32Size:])
+	count := binary.LittleEndian.Uint64(body[u32Size+u32Size+u64Size:])
+	body = body[snapshotHeaderSize:]
+
+	for idx := uint64(0); idx < count; idx++ {
+		if len(body) < snapshotEntrySize {
+			return 0, nil, errors.WithMessagef(
+				ErrInvalidSnapshot,
+				"entry %d is truncated",
+				idx)
+		}
+
+		klen := uint64(binary.LittleEndian.Uint32(body))
+		vlen := uint64(binary.LittleEndian.Uint32(body[u32Size:]))
+		body = body[snapshotEntrySize:]
+
+		if klen+vlen > uint64(len(body)) {
+			return 0, nil, errors.WithMessagef(
+				ErrInvalidSnapshot,
+				"entry %d is truncated",
+				idx)
+		}
+
+		index.put(string(body[:klen]), body[klen:klen+vlen:klen+vlen])
+		body = body[klen+vlen:]
+	}
+
+	if len(body) != 0 {
+		return 0, nil, errors.WithMessagef(
+			ErrInvalidSnapshot,
+			"%d trailing bytes",
+			len(body))
+	}
+
+	return lsn, index, nil
+}
+
+// Fsync a directory so that renames within it are durable.
+func syncDir(dir string) error {
+	fptr, err := os.Open(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer fptr.Close()
+
+	return errors.WithStack(fptr.Sync())
+}
+
+// * snapshot.go ends here.
@@ -0,0 +1,442 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// store.go --- Durable key/value store.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//go:build amd64 || arm64 || riscv64
+
+// * Comments:
+
+//
+// Each change is logged as a record whose key is the store key and whose
+// value is an operation byte followed, for puts, by the new value.
+
+// * Package:
+
+package kvstore
+
+// * Imports:
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Asmodai/gohacks/logger"
+	"github.com/Asmodai/gohacks/wal"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	// Name of the write-ahead log within the store's directory.
+	//
+	// This is a file, or a directory if the log is segmented.
+	LogName = "LOG"
+
+	opPut    byte = 1 // Record sets a key.
+	opDelete byte = 2 // Record is a tombstone.
+)
+
+// * Variables:
+
+var (
+	ErrClosed        = errors.Base("store is closed")
+	ErrInvalidRecord = errors.Base("invalid store record")
+)
+
+// * Code:
+
+// ** Types:
+
+type store struct {
+	lgr     logger.Logger
+	log     wal.WriteAheadLog
+	index   *skiplist
+	stopCh  chan struct{}
+	cfg     Config
+	wg      sync.WaitGroup
+	lsn     uint64 // LSN of the most recent change.
+	snapLSN uint64 // LSN of the most recent snapshot.
+	changes int    // Changes since the most recent snapshot.
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// ** Methods:
+
+func (s *store) Put(key, value []byte) error {
+	rec := make([]byte, 1+len(value))
+	rec[0] = opPut
+	copy(rec[1:], value)
+
+	s.mu.Lock()
+	err := s.changeLocked(key, rec)
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return s.waitDurable()
+}
+
+func (s *store) Delete(key []byte) error {
+	s.mu.Lock()
+
+	if _, found := s.index.get(string(key)); !found {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	err := s.changeLocked(key, []byte{opDelete})
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return s.waitDurable()
+}
+
+func (s *store) Get(key []byte) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, found := s.index.get(string(key))
+	if !found {
+		return nil, false
+	}
+
+	return bytes.Clone(value), true
+}
+
+func (s *store) Range(start, end []byte, visit func(key, value []byte) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cur := s.index.first()
+	if start != nil {
+		cur = s.index.seek(string(start), nil)
+	}
+
+	for ; cur != nil; cur = cur.next[0] {
+		if end != nil && cur.key >= string(end) {
+			return
+		}
+
+		if !visit([]byte(cur.key), bytes.Clone(cur.value)) {
+			return
+		}
+	}
+}
+
+func (s *store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.index.length
+}
+
+func (s *store) LSN() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lsn
+}
+
+func (s *store) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.WithStack(ErrClosed)
+	}
+
+	return s.snapshotLocked()
+}
+
+func (s *store) Close() error {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	s.closed = true
+	close(s.stopCh)
+
+	err := s.snapshotLocked()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	if cerr := s.log.Close(); err == nil {
+		err = cerr
+	}
+
+	return errors.WithStack(err)
+}
+
+// Log a change, apply it to the index, and snapshot if it is time to.
+//
+// The change is written to the log but not synced, so that the write lock
+// is not held across an fsync.  See `waitDurable`.
+//
+// The caller must hold the write lock.
+func (s *store) changeLocked(key, rec []byte) error {
+	if s.closed {
+		return errors.WithStack(ErrClosed)
+	}
+
+	lsn := s.lsn + 1
+
+	if err := s.log.Append(lsn, time.Now().Unix(), key, rec); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := s.apply(lsn, key, rec); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.changes++
+
+	if s.cfg.SnapshotEvery > 0 && s.changes >= s.cfg.SnapshotEvery {
+		return s.snapshotLocked()
+	}
+
+	return nil
+}
+
+// Wait until logged changes are durable if writes are synchronous.
+//
+// This is called without the write lock, so writers that log changes while
+// an fsync is in progress share the next one.  The log is written in LSN
+// order, so an fsync covering a change also covers every earlier change.
+//
+// A snapshot taken in the meantime is itself durable, and leaves nothing
+// to sync.
+func (s *store) waitDurable() error {
+	if !s.cfg.SyncWrites {
+		return nil
+	}
+
+	return errors.WithStack(s.log.Sync())
+}
+
+// Apply a logged change to the index.
+//
+// `rec` must not be modified afterwards, as the index may keep it.
+func (s *store) apply(lsn uint64, key, rec []byte) error {
+	if len(rec) == 0 {
+		return errors.WithMessagef(ErrInvalidRecord, "LSN %d is empty", lsn)
+	}
+
+	switch rec[0] {
+	case opPut:
+		s.index.put(string(key), rec[1:])
+
+	case opDelete:
+		s.index.delete(string(key))
+
+	default:
+		return errors.WithMessagef(
+			ErrInvalidRecord,
+			"LSN %d has unknown operation %d",
+			lsn,
+			rec[0])
+	}
+
+	s.lsn = lsn
+
+	return nil
+}
+
+// Write a snapshot if anything has changed, then truncate the log.
+//
+// The caller must hold the write lock, which keeps new records out of the
+// log until it has been truncated.
+func (s *store) snapshotLocked() error {
+	if s.lsn == s.snapLSN {
+		return nil
+	}
+
+	if err := writeSnapshot(s.cfg.Dir, s.lsn, s.index); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.snapLSN = s.lsn
+	s.changes = 0
+
+	return s.truncateLog()
+}
+
+// Drop records covered by the most recent snapshot from the log.
+func (s *store) truncateLog() error {
+	if seg, ok := s.log.(wal.SegmentedWriteAheadLog); ok {
+		return errors.WithStack(seg.Checkpoint(s.snapLSN))
+	}
+
+	// Every record in a single-file log is covered by the snapshot.
+	return errors.WithStack(s.log.Reset())
+}
+
+// Load the snapshot and replay the log past it.
+func (s *store) recover() error {
+	snapLSN, index, err := readSnapshot(s.cfg.Dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.index = index
+	s.lsn = snapLSN
+	s.snapLSN = snapLSN
+
+	_, err = s.log.Replay(snapLSN, func(lsn uint64, _ int64, key, val []byte) error {
+		// The log reuses its buffers, so keep a copy.
+		return s.apply(lsn, key, bytes.Clone(val))
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if s.lsn > s.snapLSN {
+		s.lgr.Info(
+			"Key/value store recovered changes from log",
+			"dir", s.cfg.Dir,
+			"from", s.snapLSN,
+			"to", s.lsn)
+
+		return s.snapshotLocked()
+	}
+
+	// Clear out anything left over from a crash between writing a
+	// snapshot and truncating the log.
+	return s.truncateLog()
+}
+
+// Take periodic snapshots until the store is closed.
+func (s *store) snapshotter(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil && !errors.Is(err, ErrClosed) {
+				s.lgr.Warn(
+					"Key/value store snapshot failed",
+					"dir", s.cfg.Dir,
+					"err", err.Error())
+			}
+
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// ** Functions:
+
+// Open the store in the directory given by the configuration.
+//
+// The directory is created if it does not exist.
+func Open(ctx context.Context, cfg *Config) (Store, error) {
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	if err := os.MkdirAll(cfg.Dir, defaultDirMode); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var (
+		log wal.WriteAheadLog
+		err error
+	)
+
+	path := filepath.Join(cfg.Dir, LogName)
+
+	if cfg.Segmented {
+		log, err = wal.OpenSegmentedWAL(ctx, path, cfg.Policy)
+	} else {
+		log, err = wal.OpenWALWithPolicy(ctx, path, cfg.Policy)
+	}
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return OpenWithLog(ctx, cfg, log)
+}
+
+// Open the store in the directory given by the configuration, using the
+// given write-ahead log.
+//
+// The store takes ownership of the log and closes it on close or failure.
+// The log must only be written to by the store.  If it is a segmented log
+// then it is checkpointed after each snapshot, otherwise it is reset.
+func OpenWithLog(ctx context.Context, cfg *Config, log wal.WriteAheadLog) (Store, error) {
+	if errs := cfg.Validate(); len(errs) > 0 {
+		_ = log.Close()
+
+		return nil, errs[0]
+	}
+
+	inst := &store{
+		lgr:    logger.MustGetLogger(ctx),
+		log:    log,
+		cfg:    *cfg,
+		stopCh: make(chan struct{})}
+
+	if err := inst.recover(); err != nil {
+		_ = log.Close()
+
+		return nil, errors.WithStack(err)
+	}
+
+	if interval := cfg.SnapshotInterval.Duration(); interval > 0 {
+		inst.wg.Add(1)
+
+		go inst.snapshotter(interval)
+	}
+
+	return inst, nil
+}
+
+// * store.go ends here.
@@ -0,0 +1,473 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// store_test.go --- Durable key/value store tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package kvstore
+
+// * Imports:
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Asmodai/gohacks/logger"
+	"github.com/Asmodai/gohacks/types"
+	"github.com/Asmodai/gohacks/wal"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Utilities:
+
+func makeContext() context.Context {
+	ctx, _ := logger.SetLogger(context.Background(), logger.NewDefaultLogger())
+
+	return ctx
+}
+
+func testConfig(dir string) *Config {
+	cfg := NewDefaultConfig(dir)
+	cfg.SnapshotInterval = types.Duration(-1)
+
+	return cfg
+}
+
+func mustOpen(t *testing.T, cfg *Config) Store {
+	t.Helper()
+
+	inst, err := Open(makeContext(), cfg)
+	if err != nil {
+		t.Fatalf("Open: %#v", err)
+	}
+
+	return inst
+}
+
+func mustPut(t *testing.T, inst Store, key, value string) {
+	t.Helper()
+
+	if err := inst.Put([]byte(key), []byte(value)); err != nil {
+		t.Fatalf("Put %q: %#v", key, err)
+	}
+}
+
+func checkGet(t *testing.T, inst Store, key, want string, found bool) {
+	t.Helper()
+
+	got, ok := inst.Get([]byte(key))
+	if ok != found || string(got) != want {
+		t.Errorf("Get %q: %q, %v != %q, %v", key, got, ok, want, found)
+	}
+}
+
+// Return the keys and values in the range, joined.
+func collectRange(inst Store, start, end []byte) string {
+	var parts []string
+
+	inst.Range(start, end, func(key, value []byte) bool {
+		parts = append(parts, string(key)+"="+string(value))
+
+		return true
+	})
+
+	return strings.Join(parts, ",")
+}
+
+// Simulate a crash by closing the log without a final snapshot.
+func crash(t *testing.T, inst Store) {
+	t.Helper()
+
+	impl, ok := inst.(*store)
+	if !ok {
+		t.Fatal("Not a store")
+	}
+
+	impl.mu.Lock()
+	impl.closed = true
+	impl.mu.Unlock()
+
+	if err := impl.log.Close(); err != nil {
+		t.Fatalf("Close log: %#v", err)
+	}
+}
+
+// ** Tests:
+
+func TestStoreBasics(t *testing.T) {
+	inst := mustOpen(t, testConfig(t.TempDir()))
+	defer inst.Close()
+
+	mustPut(t, inst, "b", "2")
+	mustPut(t, inst, "a", "1")
+	mustPut(t, inst, "c", "3")
+	mustPut(t, inst, "b", "two")
+
+	checkGet(t, inst, "b", "two", true)
+	checkGet(t, inst, "z", "", false)
+
+	if err := inst.Delete([]byte("a")); err != nil {
+		t.Fatalf("Delete: %#v", err)
+	}
+
+	if err := inst.Delete([]byte("missing")); err != nil {
+		t.Fatalf("Delete missing: %#v", err)
+	}
+
+	checkGet(t, inst, "a", "", false)
+
+	if inst.Len() != 2 || inst.LSN() != 5 {
+		t.Errorf("Len %d, LSN %d", inst.Len(), inst.LSN())
+	}
+
+	if got := collectRange(inst, nil, nil); got != "b=two,c=3" {
+		t.Errorf("Range mismatch: %q", got)
+	}
+}
+
+func TestStoreRange(t *testing.T) {
+	inst := mustOpen(t, testConfig(t.TempDir()))
+	defer inst.Close()
+
+	for idx := range 10 {
+		mustPut(t, inst, fmt.Sprintf("k%d", idx), fmt.Sprint(idx))
+	}
+
+	tests := []struct {
+		start string
+		end   string
+		want  string
+	}{
+		{"k3", "k6", "k3=3,k4=4,k5=5"},
+		{"k35", "k5", "k4=4"},
+		{"", "k2", "k0=0,k1=1"},
+		{"k8", "", "k8=8,k9=9"},
+		{"x", "", ""},
+	}
+
+	for _, test := range tests {
+		var start, end []byte
+
+		if test.start != "" {
+			start = []byte(test.start)
+		}
+
+		if test.end != "" {
+			end = []byte(test.end)
+		}
+
+		if got := collectRange(inst, start, end); got != test.want {
+			t.Errorf("[%q, %q): %q != %q", test.start, test.end, got, test.want)
+		}
+	}
+
+	count := 0
+	inst.Range(nil, nil, func(_, _ []byte) bool {
+		count++
+
+		return count < 3
+	})
+
+	if count != 3 {
+		t.Errorf("Range did not stop early: %d", count)
+	}
+}
+
+func TestStoreRecovery(t *testing.T) {
+	for _, segmented := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Segmented=%v", segmented), func(t *testing.T) {
+			cfg := testConfig(t.TempDir())
+			cfg.Segmented = segmented
+
+			inst := mustOpen(t, cfg)
+			mustPut(t, inst, "a", "1")
+			mustPut(t, inst, "b", "2")
+
+			if err := inst.Snapshot(); err != nil {
+				t.Fatalf("Snapshot: %#v", err)
+			}
+
+			// These only exist in the log.
+			mustPut(t, inst, "c", "3")
+
+			if err := inst.Delete([]byte("a")); err != nil {
+				t.Fatalf("Delete: %#v", err)
+			}
+
+			crash(t, inst)
+
+			inst = mustOpen(t, cfg)
+			defer inst.Close()
+
+			if got := collectRange(inst, nil, nil); got != "b=2,c=3" {
+				t.Errorf("Recovered %q", got)
+			}
+
+			if inst.LSN() != 4 {
+				t.Errorf("LSN %d != 4", inst.LSN())
+			}
+
+			// New changes carry on from the recovered LSN.
+			mustPut(t, inst, "d", "4")
+
+			if inst.LSN() != 5 {
+				t.Errorf("LSN %d != 5", inst.LSN())
+			}
+		})
+	}
+}
+
+func TestStoreSnapshotTruncatesLog(t *testing.T) {
+	cfg := testConfig(t.TempDir())
+	cfg.SnapshotEvery = 10
+
+	inst := mustOpen(t, cfg)
+
+	for idx := range 25 {
+		mustPut(t, inst, fmt.Sprintf("k%02d", idx), strings.Repeat("x", 100))
+	}
+
+	// 20 changes are in the snapshot, and 5 remain in the log.
+	var logged int
+
+	_, err := wal.ScanFile(makeContext(), filepath.Join(cfg.Dir, LogName), func(*wal.Record) error {
+		logged++
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanFile: %#v", err)
+	}
+
+	if logged != 5 {
+		t.Errorf("%d records logged, want 5", logged)
+	}
+
+	if err := inst.Close(); err != nil {
+		t.Fatalf("Close: %#v", err)
+	}
+
+	if err := inst.Put([]byte("late"), nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %#v", err)
+	}
+
+	inst = mustOpen(t, cfg)
+	defer inst.Close()
+
+	if inst.Len() != 25 || inst.LSN() != 25 {
+		t.Errorf("Len %d, LSN %d", inst.Len(), inst.LSN())
+	}
+}
+
+func TestStorePeriodicSnapshot(t *testing.T) {
+	cfg := testConfig(t.TempDir())
+	cfg.SnapshotInterval = types.Duration(5 * time.Millisecond)
+	cfg.SyncWrites = true
+
+	inst := mustOpen(t, cfg)
+	defer inst.Close()
+
+	mustPut(t, inst, "a", "1")
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(filepath.Join(cfg.Dir, SnapshotName)); err == nil {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatal("No snapshot was written")
+}
+
+func TestStoreErrors(t *testing.T) {
+	t.Run("No directory", func(t *testing.T) {
+		_, err := Open(makeContext(), &Config{})
+		if !errors.Is(err, ErrNoDirectory) {
+			t.Errorf("Expected ErrNoDirectory, got %#v", err)
+		}
+	})
+
+	t.Run("Corrupt snapshot", func(t *testing.T) {
+		cfg := testConfig(t.TempDir())
+		inst := mustOpen(t, cfg)
+		mustPut(t, inst, "a", "1")
+
+		if err := inst.Close(); err != nil {
+			t.Fatalf("Close: %#v", err)
+		}
+
+		path := filepath.Join(cfg.Dir, SnapshotName)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %#v", err)
+		}
+
+		data[snapshotHeaderSize] ^= 0xFF
+
+		if err := os.WriteFile(path, data, defaultFileMode); err != nil {
+			t.Fatalf("WriteFile: %#v", err)
+		}
+
+		if _, err := Open(makeContext(), cfg); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("Expected ErrInvalidSnapshot, got %#v", err)
+		}
+	})
+
+	t.Run("Value too large", func(t *testing.T) {
+		cfg := testConfig(t.TempDir())
+		cfg.Policy.MaxValueBytes = 8
+
+		inst := mustOpen(t, cfg)
+		defer inst.Close()
+
+		if err := inst.Put([]byte("a"), make([]byte, 8)); !errors.Is(err, wal.ErrValueTooLarge) {
+			t.Errorf("Expected ErrValueTooLarge, got %#v", err)
+		}
+
+		if inst.LSN() != 0 || inst.Len() != 0 {
+			t.Errorf("Failed put changed the store")
+		}
+	})
+}
+
+func TestStoreConcurrentWriters(t *testing.T) {
+	const (
+		writers = 8
+		puts    = 50
+	)
+
+	cfg := testConfig(t.TempDir())
+	cfg.SyncWrites = true
+
+	inst := mustOpen(t, cfg)
+
+	var wg sync.WaitGroup
+
+	for writer := range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range puts {
+				key := fmt.Sprintf("w%d-%03d", writer, idx)
+
+				if err := inst.Put([]byte(key), []byte(key)); err != nil {
+					t.Errorf("Put %q: %#v", key, err)
+
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if inst.LSN() != writers*puts {
+		t.Errorf("LSN: %d != %d", inst.LSN(), writers*puts)
+	}
+
+	crash(t, inst)
+
+	inst = mustOpen(t, cfg)
+	defer inst.Close()
+
+	if inst.Len() != writers*puts {
+		t.Fatalf("Len: %d != %d", inst.Len(), writers*puts)
+	}
+
+	checkGet(t, inst, "w7-049", "w7-049", true)
+}
+
+// ** Benchmarks:
+
+func benchmarkStorePut(b *testing.B, parallel bool) {
+	cfg := testConfig(b.TempDir())
+	cfg.SyncWrites = true
+
+	inst, err := Open(makeContext(), cfg)
+	if err != nil {
+		b.Fatalf("Open: %#v", err)
+	}
+
+	defer inst.Close()
+
+	value := []byte("value")
+	put := func(key []byte) {
+		if err := inst.Put(key, value); err != nil {
+			b.Errorf("Put: %#v", err)
+		}
+	}
+
+	b.ResetTimer()
+
+	if !parallel {
+		for idx := range b.N {
+			put([]byte(fmt.Sprintf("key-%d", idx)))
+		}
+
+		return
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		var idx int
+
+		prefix := fmt.Sprintf("%p-", pb)
+
+		for pb.Next() {
+			idx++
+			put([]byte(fmt.Sprintf("%s%d", prefix, idx)))
+		}
+	})
+}
+
+func BenchmarkStore_SyncPut_Serial(b *testing.B) {
+	benchmarkStorePut(b, false)
+}
+
+func BenchmarkStore_SyncPut_Parallel(b *testing.B) {
+	benchmarkStorePut(b, true)
+}
+
+// * store_test.go ends here.
@@ -0,0 +1,95 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// types.go --- Durable key/value store interface.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//mock:yes
+
+// * Comments:
+
+// * Package:
+
+package kvstore
+
+// * Code:
+
+// Store is an ordered in-memory key/value map made durable by a
+// write-ahead log.
+//
+// Every change is appended to the log under a log sequence number assigned
+// by the store before it is applied to the map.  Periodically the whole
+// map is written to a snapshot file along with the LSN of the last change
+// it contains, after which the log is truncated past that LSN.
+//
+// On open, the snapshot is loaded and the log is replayed from the
+// snapshot's LSN to recover any later changes.
+//
+// Keys and values are copied on the way in and out, so callers may reuse
+// their buffers.
+//
+// All methods are safe to call from multiple goroutines.
+type Store interface {
+	// Set the value of the given key.
+	Put(key, value []byte) error
+
+	// Delete the given key.
+	//
+	// Deletions are logged as tombstones.  Deleting a key that is not
+	// present does nothing.
+	Delete(key []byte) error
+
+	// Get the value of the given key.
+	//
+	// If the key exists, then a copy of its value and `true` will be
+	// returned; otherwise `nil` and `false` will be returned.
+	Get(key []byte) ([]byte, bool)
+
+	// Visit each key in `[start, end)` in ascending order.
+	//
+	// A nil `start` or `end` leaves that side of the range open.
+	// Iteration stops early if `visit` returns false.
+	//
+	// The store is read-locked during iteration, so `visit` must not
+	// modify the store.
+	Range(start, end []byte, visit func(key, value []byte) bool)
+
+	// Return the number of keys in the store.
+	Len() int
+
+	// Return the LSN of the most recent change.
+	LSN() uint64
+
+	// Write a snapshot now and truncate the log past it.
+	Snapshot() error
+
+	// Write a final snapshot and close the store.
+	Close() error
+}
+
+// * types.go ends here.
@@ -0,0 +1,251 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// analyser.go --- Text analysers for full-text fields.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//
+//mock:yes
+
+// * Comments:
+
+//
+// An analyser turns a piece of text into a list of terms.  Text fields name
+// an analyser in their schema entry, and the same analyser is applied both
+// to query literals by the typer and to document values by the virtual
+// machine, so that the two sides of a comparison agree.
+//
+// Analysers are looked up by name in an `AnalyserRegistry`.  A registry
+// created with `NewAnalyserRegistry` is pre-populated with the following:
+//
+//   keyword     The whole value is a single term.
+//   whitespace  Split on whitespace.
+//   simple      Split on non-alphanumerics and lowercase.
+//   standard    As `simple`, with English stop words removed.
+//   english     As `standard`, with ASCII folding and plural stemming.
+//
+// Wildcard patterns are not analysed, as tokenising them would destroy the
+// wildcards.  Instead they are normalised term-wise, which lowercases and
+// folds them without removing stop words or stemming.
+//
+// Term queries on an analysed field match when the literal's terms appear
+// in order among the document value's terms.  Prefix and glob patterns are
+// normalised term-wise too, and match individual terms.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"strings"
+	"sync"
+)
+
+// * Constants:
+
+const (
+	AnalyserKeyword    = "keyword"    // Single-term analyser.
+	AnalyserWhitespace = "whitespace" // Whitespace analyser.
+	AnalyserSimple     = "simple"     // Lowercasing analyser.
+	AnalyserStandard   = "standard"   // Stop word analyser.
+	AnalyserEnglish    = "english"    // Stemming analyser.
+)
+
+// * Variables:
+
+var (
+	// The default analyser registry.
+	//
+	//nolint:gochecknoglobals
+	defaultAnalysers = NewAnalyserRegistry()
+)
+
+// * Code:
+
+// ** Interface:
+
+// Text analyser.
+type Analyser interface {
+	// Break the given text into a list of terms.
+	Analyse(string) []string
+
+	// Normalise a single term without tokenising it.
+	Normalise(string) string
+}
+
+// ** Types:
+
+// Function that splits text into tokens.
+type Tokeniser func(string) []string
+
+// Function that normalises a single token.
+type Normaliser func(string) string
+
+// Function that transforms a token stream.
+type TokenFilter func([]string) []string
+
+// ** Pipeline:
+
+// An analyser built from a tokeniser, a list of normalisers that are
+// applied to each token, and a list of filters that are applied to the
+// resulting token stream.
+type Pipeline struct {
+	Tokeniser   Tokeniser     // Splits text into tokens.
+	Normalisers []Normaliser  // Applied to each token in order.
+	Filters     []TokenFilter // Applied to the token stream in order.
+}
+
+// *** Methods:
+
+// Break the given text into a list of terms.
+func (p *Pipeline) Analyse(text string) []string {
+	var tokens []string
+
+	if p.Tokeniser == nil {
+		tokens = WhitespaceTokeniser(text)
+	} else {
+		tokens = p.Tokeniser(text)
+	}
+
+	out := make([]string, 0, len(tokens))
+
+	for _, token := range tokens {
+		if token = p.Normalise(token); token != "" {
+			out = append(out, token)
+		}
+	}
+
+	for _, filter := range p.Filters {
+		out = filter(out)
+	}
+
+	return out
+}
+
+// Normalise a single term without tokenising it.
+func (p *Pipeline) Normalise(term string) string {
+	for _, norm := range p.Normalisers {
+		term = norm(term)
+	}
+
+	return term
+}
+
+// *** Functions:
+
+// Create a new analyser pipeline.
+func NewPipeline(tokeniser Tokeniser, normalisers []Normaliser, filters ...TokenFilter) *Pipeline {
+	return &Pipeline{
+		Tokeniser:   tokeniser,
+		Normalisers: normalisers,
+		Filters:     filters}
+}
+
+// ** Registry:
+
+// Registry of named analysers.
+//
+// A registry is safe for concurrent use.
+type AnalyserRegistry struct {
+	mu        sync.RWMutex
+	analysers map[string]Analyser
+}
+
+// *** Methods:
+
+// Register an analyser under the given name.
+//
+// An existing analyser with the same name is replaced.
+func (r *AnalyserRegistry) Register(name string, analyser Analyser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.analysers[name] = analyser
+}
+
+// Look up the analyser with the given name.
+func (r *AnalyserRegistry) Lookup(name string) (Analyser, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	analyser, found := r.analysers[name]
+
+	return analyser, found
+}
+
+// Return the names of all registered analysers.
+func (r *AnalyserRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.analysers))
+
+	for name := range r.analysers {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// *** Functions:
+
+// Create a new analyser registry containing the built-in analysers.
+func NewAnalyserRegistry() *AnalyserRegistry {
+	stops := StopWordFilter(EnglishStopWords...)
+	lower := []Normaliser{strings.ToLower}
+	folded := []Normaliser{strings.ToLower, FoldASCII}
+
+	return &AnalyserRegistry{
+		analysers: map[string]Analyser{
+			AnalyserKeyword:    NewPipeline(KeywordTokeniser, nil),
+			AnalyserWhitespace: NewPipeline(WhitespaceTokeniser, nil),
+			AnalyserSimple:     NewPipeline(StandardTokeniser, lower),
+			AnalyserStandard: NewPipeline(
+				StandardTokeniser,
+				lower,
+				stops),
+			AnalyserEnglish: NewPipeline(
+				StandardTokeniser,
+				folded,
+				stops,
+				StemFilter),
+		}}
+}
+
+// Return the default analyser registry.
+//
+// The default registry is used by `NewTyper` and `NewVM`.  Custom analysers
+// registered here are available to all schemas that use the default.
+func DefaultAnalyserRegistry() *AnalyserRegistry {
+	return defaultAnalysers
+}
+
+// * analyser.go ends here.
@@ -0,0 +1,220 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// analyser_filters.go --- Tokenisers, normalisers, and token filters.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// * Constants:
+
+const (
+	// Shortest word that the stemmer will touch.
+	minStemLength = 3
+)
+
+// * Variables:
+
+var (
+	// English stop words.
+	//
+	// This is the traditional list used by many full-text search engines.
+	//
+	//nolint:gochecknoglobals
+	EnglishStopWords = []string{
+		"a", "an", "and", "are", "as", "at", "be", "but", "by", "for",
+		"if", "in", "into", "is", "it", "no", "not", "of", "on", "or",
+		"such", "that", "the", "their", "then", "there", "these",
+		"they", "this", "to", "was", "will", "with",
+	}
+
+	// Characters that do not decompose into an ASCII base character.
+	//
+	//nolint:gochecknoglobals
+	foldExceptions = map[rune]string{
+		'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE",
+		'ø': "o", 'Ø': "O", 'đ': "d", 'Đ': "D", 'ł': "l",
+		'Ł': "L", 'þ': "th", 'Þ': "TH", 'ð': "d", 'Ð': "D",
+		'ı': "i",
+	}
+)
+
+// * Code:
+
+// ** Tokenisers:
+
+// Tokeniser that treats the whole text as a single token.
+//
+// Leading and trailing whitespace is removed.  Empty text has no tokens.
+func KeywordTokeniser(text string) []string {
+	if text = strings.TrimSpace(text); text == "" {
+		return nil
+	}
+
+	return []string{text}
+}
+
+// Tokeniser that splits text on whitespace.
+func WhitespaceTokeniser(text string) []string {
+	return strings.Fields(text)
+}
+
+// Tokeniser that splits text on anything that is not a letter, a digit, or
+// a combining mark.
+func StandardTokeniser(text string) []string {
+	return strings.FieldsFunc(text, func(rch rune) bool {
+		return !unicode.IsLetter(rch) &&
+			!unicode.IsDigit(rch) &&
+			!unicode.Is(unicode.Mn, rch)
+	})
+}
+
+// ** Normalisers:
+
+// Normaliser that folds accented and other non-ASCII Latin characters to
+// their nearest ASCII equivalent.
+//
+// Characters without an ASCII equivalent are left as they are.
+func FoldASCII(term string) string {
+	var sbld strings.Builder
+
+	sbld.Grow(len(term))
+
+	for _, rch := range norm.NFD.String(term) {
+		switch {
+		case rch <= unicode.MaxASCII:
+			sbld.WriteRune(rch)
+
+		case unicode.Is(unicode.Mn, rch):
+			// Drop combining marks.
+
+		default:
+			if repl, found := foldExceptions[rch]; found {
+				sbld.WriteString(repl)
+
+				continue
+			}
+
+			sbld.WriteRune(rch)
+		}
+	}
+
+	return norm.NFC.String(sbld.String())
+}
+
+// ** Filters:
+
+// Create a token filter that removes the given stop words.
+//
+// Stop words are matched exactly, so place this after any normalisers.
+func StopWordFilter(words ...string) TokenFilter {
+	stops := make(map[string]struct{}, len(words))
+
+	for _, word := range words {
+		stops[word] = struct{}{}
+	}
+
+	return func(tokens []string) []string {
+		out := tokens[:0]
+
+		for _, token := range tokens {
+			if _, found := stops[token]; !found {
+				out = append(out, token)
+			}
+		}
+
+		return out
+	}
+}
+
+// Token filter that stems English plurals.
+func StemFilter(tokens []string) []string {
+	for idx := range tokens {
+		tokens[idx] = StemEnglish(tokens[idx])
+	}
+
+	return tokens
+}
+
+// ** Functions:
+
+// Reduce an English plural to its singular form.
+//
+// This is a minimal stemmer that only deals with plurals, so it is
+// conservative and rarely conflates unrelated words:
+//
+//	queries  -> query
+//	messages -> message
+//	class    -> class
+//
+// The word is expected to already be lowercase.
+func StemEnglish(word string) string {
+	size := len(word)
+
+	if size < minStemLength || word[size-1] != 's' {
+		return word
+	}
+
+	switch word[size-2] {
+	case 'u', 's':
+		// "status", "class".
+		return word
+
+	case 'e':
+		if size > minStemLength &&
+			word[size-3] == 'i' &&
+			word[size-4] != 'a' &&
+			word[size-4] != 'e' {
+			// "queries" -> "query".
+			return word[:size-3] + "y"
+		}
+
+		switch word[size-3] {
+		case 'i', 'a', 'o', 'e':
+			// "shoes", "toes".
+			return word
+		}
+	}
+
+	return word[:size-1]
+}
+
+// * analyser_filters.go ends here.
@@ -0,0 +1,326 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// analyser_test.go --- Text analyser tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Utilities:
+
+// Compile a query against a schema whose text fields use the given
+// analyser.
+func compileAnalysed(t *testing.T, analyser, query string) *Program {
+	t.Helper()
+
+	schema := Schema{
+		"message": FieldSpec{
+			Name:     "Message",
+			FType:    FTText,
+			Analyser: analyser},
+	}
+
+	lexed, err := NewLexer().Lex(strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("Lexer error: %#v", err)
+	}
+
+	parsed, diags := NewParser().Parse(lexed)
+	if len(diags) > 0 {
+		t.Fatalf("Parser errors: %v", diags)
+	}
+
+	typed, diags := NewTyper(schema).Type(parsed)
+	if len(diags) > 0 {
+		t.Fatalf("Typer errors: %v", diags)
+	}
+
+	program := NewProgram()
+	program.Emit(NewSimplifier().Simplify(NewNNF().NNF(typed)))
+
+	return program
+}
+
+// ** Tests:
+
+func TestAnalysers(t *testing.T) {
+	reg := NewAnalyserRegistry()
+
+	tests := []struct {
+		analyser string
+		input    string
+		want     []string
+	}{
+		{AnalyserKeyword, "  Hello, World  ", []string{"Hello, World"}},
+		{AnalyserKeyword, "   ", []string{}},
+		{AnalyserWhitespace, "Hello,  World", []string{"Hello,", "World"}},
+		{AnalyserSimple, "Hello, World!", []string{"hello", "world"}},
+		{AnalyserStandard, "The cat and THE hat", []string{"cat", "hat"}},
+		{AnalyserEnglish, "Café Queries are Running", []string{"cafe", "query", "running"}},
+		{AnalyserEnglish, "Straße Œuvres", []string{"strasse", "oeuvre"}},
+	}
+
+	for idx, test := range tests {
+		analyser, found := reg.Lookup(test.analyser)
+		if !found {
+			t.Fatalf("%02d: Analyser %q not found", idx, test.analyser)
+		}
+
+		got := analyser.Analyse(test.input)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%02d: %s: %q != %q",
+				idx,
+				test.analyser,
+				got,
+				test.want)
+		}
+	}
+}
+
+func TestStemEnglish(t *testing.T) {
+	tests := map[string]string{
+		"queries":  "query",
+		"messages": "message",
+		"cats":     "cat",
+		"class":    "class",
+		"status":   "status",
+		"shoes":    "shoes",
+		"is":       "is",
+		"run":      "run",
+	}
+
+	for input, want := range tests {
+		if got := StemEnglish(input); got != want {
+			t.Errorf("%q: %q != %q", input, got, want)
+		}
+	}
+}
+
+func TestAnalyserRegistry(t *testing.T) {
+	reg := NewAnalyserRegistry()
+	custom := NewPipeline(WhitespaceTokeniser, []Normaliser{strings.ToUpper})
+
+	reg.Register("upper", custom)
+
+	analyser, found := reg.Lookup("upper")
+	if !found {
+		t.Fatal("Custom analyser not found")
+	}
+
+	if got := analyser.Analyse("a b"); !slices.Equal(got, []string{"A", "B"}) {
+		t.Errorf("Unexpected terms: %q", got)
+	}
+
+	if !slices.Contains(reg.Names(), "upper") {
+		t.Errorf("Names missing custom analyser: %v", reg.Names())
+	}
+
+	if _, found := reg.Lookup("nope"); found {
+		t.Error("Found an analyser that does not exist")
+	}
+}
+
+func TestAnalysedMatching(t *testing.T) {
+	doc := MapDocument{"Message": "The QUERIES were run at the Café"}
+
+	tests := []struct {
+		analyser string
+		query    string
+		want     bool
+	}{
+		{"", `message:"queries"`, false},
+		{"", `message:"QUERIES"`, true},
+		{AnalyserSimple, `message:"queries"`, true},
+		{AnalyserSimple, `message:"query"`, false},
+		{AnalyserEnglish, `message:"query"`, true},
+		{AnalyserEnglish, `message:"Queries were run"`, true},
+		{AnalyserEnglish, `message:"the cafe"`, true},
+		{AnalyserEnglish, `message:"run query"`, false},
+		{AnalyserEnglish, `message:"QU*Y"`, true},
+		{AnalyserEnglish, `message:"qwery"~1`, true},
+	}
+
+	for idx, test := range tests {
+		got, err := compileAnalysed(t, test.analyser, test.query).Run(doc)
+		if err != nil {
+			t.Fatalf("%02d: Unexpected error: %#v", idx, err)
+		}
+
+		if got != test.want {
+			t.Errorf("%02d: %s with %q: %v != %v",
+				idx,
+				test.query,
+				test.analyser,
+				got,
+				test.want)
+		}
+	}
+}
+
+func TestAnalysedTerms(t *testing.T) {
+	schema := Schema{
+		"message": FieldSpec{
+			Name:     "Message",
+			FType:    FTText,
+			Analyser: AnalyserEnglish},
+	}
+
+	docs := map[string]MapDocument{
+		"one": {"Message": "Running QUERIES at the Café"},
+		"two": {"Message": "a single query"},
+	}
+
+	idx, err := NewIndex(schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	for id, doc := range docs {
+		idx.Add(id, doc)
+	}
+
+	tests := []struct {
+		kind PredicateKind
+		str  string
+		want []string
+	}{
+		{PredicateEQS, "Running", []string{"one"}},
+		{PredicateEQS, "running", []string{"one"}},
+		{PredicateEQS, "Queries", []string{"one", "two"}},
+		{PredicateEQS, "Queries at the café", []string{"one"}},
+		{PredicateEQS, "cafe running", []string{}},
+		{PredicateEQS, "a single query", []string{"two"}},
+		{PredicateEQS, "jumping", []string{}},
+		{PredicateNEQS, "Running", []string{"two"}},
+		{PredicateNEQS, "jumping", []string{"one", "two"}},
+		{PredicatePREFIX, "RUN", []string{"one"}},
+		{PredicatePREFIX, "Sing", []string{"two"}},
+		{PredicatePREFIX, "xyz", []string{}},
+		{PredicateGLOB, "Q*Y", []string{"one", "two"}},
+		{PredicateGLOB, "r?nning", []string{"one"}},
+		{PredicateGLOB, "*ing query", []string{}},
+	}
+
+	for num, test := range tests {
+		node := &ASTPredicate{Kind: test.kind, Field: "message", String: test.str}
+
+		typed, diags := NewTyper(schema).Type(node)
+		if len(diags) > 0 {
+			t.Fatalf("%02d: Typer errors: %v", num, diags)
+		}
+
+		program := NewProgram()
+		program.Emit(NewSimplifier().Simplify(NewNNF().NNF(typed)))
+
+		matched := []string{}
+
+		for _, id := range []string{"one", "two"} {
+			got, err := program.Run(docs[id])
+			if err != nil {
+				t.Fatalf("%02d: Unexpected error: %#v", num, err)
+			}
+
+			if got {
+				matched = append(matched, id)
+			}
+		}
+
+		if !slices.Equal(matched, test.want) {
+			t.Errorf("%02d: %s %q: VM %v != %v",
+				num,
+				PredicateKindToString(test.kind),
+				test.str,
+				matched,
+				test.want)
+		}
+
+		found, err := idx.Search(typed)
+		if err != nil {
+			t.Fatalf("%02d: Unexpected error: %#v", num, err)
+		}
+
+		slices.Sort(found)
+
+		if !slices.Equal(found, test.want) {
+			t.Errorf("%02d: %s %q: index %v != %v",
+				num,
+				PredicateKindToString(test.kind),
+				test.str,
+				found,
+				test.want)
+		}
+	}
+}
+
+func TestAnalyserErrors(t *testing.T) {
+	t.Run("Typer", func(t *testing.T) {
+		schema := Schema{
+			"message": FieldSpec{
+				Name:     "Message",
+				FType:    FTText,
+				Analyser: "nope"},
+		}
+
+		lexed, _ := NewLexer().Lex(strings.NewReader(`message:"x"`))
+		parsed, _ := NewParser().Parse(lexed)
+
+		_, diags := NewTyper(schema).Type(parsed)
+		if len(diags) != 1 {
+			t.Errorf("Unexpected diagnostics: %v", diags)
+		}
+	})
+
+	t.Run("VM", func(t *testing.T) {
+		program := compileAnalysed(t, AnalyserEnglish, `message:"x"`)
+		vm := NewVMWithAnalysers(program, &AnalyserRegistry{
+			analysers: map[string]Analyser{}})
+
+		_, err := vm.Run(MapDocument{"Message": "x"})
+		if !errors.Is(err, ErrUnknownAnalyser) {
+			t.Errorf("Error mismatch: %#v", err)
+		}
+	})
+}
+
+// * analyser_test.go ends here.
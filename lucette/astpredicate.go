@@ -99,6 +99,7 @@ type ASTPredicate struct {
 	Kind       PredicateKind  // Predicate kind.
 	Number     float64        // Target numeric value.
 	Proximity  int            // String promity.
+	Fuzzy      bool           // Was `~` given?
 }
 
 // ** Methods:
@@ -121,6 +122,8 @@ func (n ASTPredicate) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Regexp:     %q", n.Regex)
 	dbg.Printf("Proximity:  %d", n.Proximity)
 
+	dbg.Printf("Fuzzy:      %v", n.Fuzzy)
+
 	if n.Fuzz != nil {
 		dbg.Printf("Fuzz:       %g", *n.Fuzz)
 	}
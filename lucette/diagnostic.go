@@ -59,8 +59,12 @@ type Diagnostic struct {
 func (d Diagnostic) String() string {
 	var sbld strings.Builder
 
-	sbld.WriteString(d.At.String())
-	sbld.WriteString(": ")
+	// Diagnostics raised against IR nodes have no source location.
+	if d.At != nil {
+		sbld.WriteString(d.At.String())
+		sbld.WriteString(": ")
+	}
+
 	sbld.WriteString(d.Msg)
 
 	if len(d.Hint) > 0 {
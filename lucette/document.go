@@ -0,0 +1,75 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// document.go --- Documents queries are evaluated against.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//mock:yes
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Code:
+
+// ** Interface:
+
+// Document interface.
+//
+// A document is anything that can supply field values to the virtual
+// machine.  Field names are those given by `FieldSpec.Name` in the schema
+// the query was typed against.
+//
+// This is deliberately compatible with `dag.Filterable`, so anything that
+// can be filtered by the DAG can also be searched with a lucette query.
+type Document interface {
+	// Get the value of the given field.
+	//
+	// If the field exists, its value is returned along with `true`.
+	//
+	// If the field does not exist, `false` is returned.
+	Get(string) (any, bool)
+}
+
+// ** Types:
+
+// A document backed by a map of field names to values.
+type MapDocument map[string]any
+
+// ** Methods:
+
+// Get the value of the given field.
+func (d MapDocument) Get(field string) (any, bool) {
+	val, found := d[field]
+
+	return val, found
+}
+
+// * document.go ends here.
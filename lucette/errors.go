@@ -42,9 +42,23 @@ import "gitlab.com/tozd/go/errors"
 // * Code:
 
 var (
+	// Returned when the virtual machine encounters a constant index that
+	// is out of range for its constant pool.
+	ErrBadConstant = errors.Base("constant index out of range")
+
+	// Returned when a compiled program cannot be decoded.
+	ErrBadProgram = errors.Base("malformed program")
+
 	// Returned when the typer detects an invalid datetime.
 	ErrBadDateTime = errors.Base("bad datetime value")
 
+	// Returned when the virtual machine encounters an unknown opcode.
+	ErrBadOpcode = errors.Base("bad opcode")
+
+	// Returned when the virtual machine encounters an instruction with a
+	// missing or mistyped operand.
+	ErrBadOperand = errors.Base("bad operand")
+
 	// Returned should an attempt be made to `unread` after a rune has
 	// already been put back into the reader.
 	ErrDoubleUnread = errors.Base("double unread")
@@ -75,13 +89,39 @@ var (
 	// Returned when the lexer detects a newline in a regular expression.
 	ErrNewlineInRegex = errors.Base("embedded newline in regular expression")
 
+	// Returned when the virtual machine is asked to evaluate a field
+	// predicate before a field has been loaded.
+	ErrNoFieldLoaded = errors.Base("no field loaded")
+
+	// Returned if the virtual machine is run without a program.
+	ErrNoProgram = errors.Base("no program")
+
+	// Returned when the formatter is given a node that cannot be written
+	// in query syntax.
+	ErrNoSyntax = errors.Base("no query syntax for node")
+
 	// Returned if no tokens were provided.
 	ErrNoTokens = errors.Base("no tokens")
 
+	// Returned when the virtual machine's program counter leaves the
+	// bytecode.
+	ErrPCOutOfRange = errors.Base("program counter out of range")
+
+	// Returned when a compiled program's checksum does not match its
+	// contents.
+	ErrProgramChecksum = errors.Base("program checksum mismatch")
+
+	// Returned when a compiled program has an unsupported format version.
+	ErrProgramVersion = errors.Base("unsupported program version")
+
 	// Returned when the lexer detects unsupported flags in a regular
 	// expression.
 	ErrRegexFlags = errors.Base("regex flags not supported")
 
+	// Returned when the virtual machine executes more instructions than
+	// a program could legitimately require, e.g. due to a backward jump.
+	ErrRunaway = errors.Base("runaway program")
+
 	// Returned when the code generator detects a label that has not been
 	// bound to a target.
 	ErrUnboundLabel = errors.Base("unbound label")
@@ -97,6 +137,13 @@ var (
 	// code.
 	ErrUnexpectedToken = errors.Base("unexpected token")
 
+	// Returned when the virtual machine encounters a field whose analyser
+	// is not registered.
+	ErrUnknownAnalyser = errors.Base("unknown analyser")
+
+	// Returned when no SQL dialect is known for a database driver.
+	ErrUnknownDialect = errors.Base("unknown SQL dialect")
+
 	// Returned when the typer detects an unknown literal.
 	ErrUnknownLiteral = errors.Base("unknown literal")
 
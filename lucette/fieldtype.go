@@ -73,7 +73,7 @@ type Schema map[string]FieldSpec
 type FieldSpec struct {
 	Name     string    // Name of the field.
 	FType    FieldType // Field type of the field.
-	Analyser string    // Unused.
+	Analyser string    // Name of the analyser used by text fields.
 	Layouts  []string  // Layouts used for type parsers.
 }
 
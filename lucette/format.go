@@ -0,0 +1,464 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// format.go --- Render AST nodes as query source.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// The formatter renders a query tree back into canonical lucette syntax:
+//
+//   - Logical operators are written as `AND`, `OR`, and `NOT`.
+//   - Parentheses are only used where precedence requires them, with the
+//     exception of a unary operator applied to another unary operator,
+//     which is always parenthesised as the parser cannot handle `NOT NOT`.
+//   - Fields are written bare where the lexer would read them as an
+//     identifier, and are quoted otherwise.
+//   - Fuzziness is written before boost.  Boost is always explicit, and
+//     fuzziness is too unless the query gave `~` without a number.
+//
+// Parsing the output of `FormatAST` yields an AST that is equal to the
+// input, ignoring source spans and with nested `AND` and `OR` nodes
+// flattened, as the parser would do.
+//
+// Not every tree has a syntax.  Predicate kinds that the parser never
+// produces (such as `EQ.S` or `PREFIX`), negative numbers, and NaNs and
+// infinities cannot be written, and result in `ErrNoSyntax`.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	fmtPrecOr    fmtPrec = iota + 1 // Precedence of `OR`.
+	fmtPrecAnd                      // Precedence of `AND`.
+	fmtPrecUnary                    // Precedence of `NOT` and modifiers.
+	fmtPrecAtom                     // Precedence of predicates.
+)
+
+// * Code:
+
+// ** Types:
+
+// Formatter precedence.
+type fmtPrec int
+
+// ** Functions:
+
+// Wrap the source in parentheses if its precedence is below the minimum.
+func parenthesise(src string, prec, minPrec fmtPrec) string {
+	if prec < minPrec {
+		return "(" + src + ")"
+	}
+
+	return src
+}
+
+// Render a list of kids joined by the given operator.
+func formatKids(kids []ASTNode, oper string, prec fmtPrec) (string, error) {
+	if len(kids) == 0 {
+		return "", errors.WithMessagef(ErrNoSyntax, "empty %s", oper)
+	}
+
+	parts := make([]string, 0, len(kids))
+
+	for _, kid := range kids {
+		src, kprec, err := formatNode(kid)
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, parenthesise(src, kprec, prec))
+	}
+
+	return strings.Join(parts, " "+oper+" "), nil
+}
+
+// Render a unary operator applied to a node.
+func formatUnary(oper string, kid ASTNode) (string, error) {
+	src, prec, err := formatNode(kid)
+	if err != nil {
+		return "", err
+	}
+
+	return oper + parenthesise(src, prec, fmtPrecAtom), nil
+}
+
+// Render a node, returning its source and precedence.
+func formatNode(node ASTNode) (string, fmtPrec, error) {
+	var (
+		src  string
+		prec fmtPrec
+		err  error
+	)
+
+	switch val := node.(type) {
+	case *ASTOr:
+		src, err = formatKids(val.Kids, "OR", fmtPrecOr)
+		prec = fmtPrecOr
+
+	case *ASTAnd:
+		src, err = formatKids(val.Kids, "AND", fmtPrecAnd)
+		prec = fmtPrecAnd
+
+	case *ASTNot:
+		src, err = formatUnary("NOT ", val.Kid)
+		prec = fmtPrecUnary
+
+	case *ASTModifier:
+		oper := "+"
+		if val.Kind == ModProhibit {
+			oper = "-"
+		}
+
+		src, err = formatUnary(oper, val.Kid)
+		prec = fmtPrecUnary
+
+	case *ASTPredicate:
+		src, err = formatPredicate(val)
+		prec = fmtPrecAtom
+
+	default:
+		err = errors.WithMessagef(ErrNoSyntax, "%T", node)
+	}
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	return src, prec, nil
+}
+
+// Render a predicate.
+//
+//nolint:cyclop
+func formatPredicate(pred *ASTPredicate) (string, error) {
+	var (
+		sbld strings.Builder
+		body string
+		err  error
+	)
+
+	switch pred.Kind {
+	case PredicatePHRASE:
+		body = formatPhrase(pred.String)
+
+	case PredicateREGEX:
+		body = formatRegex(pred.Regex)
+
+	case PredicateCMP:
+		body, err = formatComparator(pred.Comparator)
+
+	case PredicateRANGE:
+		body, err = formatRange(pred.Range)
+
+	default:
+		err = errors.WithMessagef(ErrNoSyntax,
+			"%s predicate",
+			PredicateKindToString(pred.Kind))
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if pred.Field != "" {
+		sbld.WriteString(formatField(pred.Field))
+		sbld.WriteRune(':')
+	}
+
+	sbld.WriteString(body)
+
+	fuzz := pred.Fuzz
+	if fuzz == nil && pred.Proximity != 0 {
+		// Proximity shares its syntax with fuzziness.
+		prox := float64(pred.Proximity)
+		fuzz = &prox
+	}
+
+	switch {
+	case fuzz != nil:
+		num, err := formatNumber(*fuzz)
+		if err != nil {
+			return "", err
+		}
+
+		sbld.WriteString("~" + num)
+
+	case pred.Fuzzy:
+		sbld.WriteRune('~')
+	}
+
+	if pred.Boost != nil {
+		num, err := formatNumber(*pred.Boost)
+		if err != nil {
+			return "", err
+		}
+
+		sbld.WriteString("^" + num)
+	}
+
+	return sbld.String(), nil
+}
+
+// Render a comparator.
+func formatComparator(cmp *ASTComparator) (string, error) {
+	if cmp == nil {
+		return "", errors.WithMessagef(ErrNoSyntax, "missing comparator")
+	}
+
+	var oper string
+
+	switch cmp.Op {
+	case ComparatorLT:
+		oper = "<"
+
+	case ComparatorLTE:
+		oper = "<="
+
+	case ComparatorGT:
+		oper = ">"
+
+	case ComparatorGTE:
+		oper = ">="
+
+	case ComparatorEQ:
+		// Only numeric equality has a syntax of its own; a string would
+		// be read back as a phrase.
+		if cmp.Atom.Kind != LNumber {
+			return "", errors.WithMessagef(ErrNoSyntax,
+				"%s equality",
+				LiteralKindToString(cmp.Atom.Kind))
+		}
+
+	default:
+		return "", errors.WithMessagef(ErrNoSyntax,
+			"%s comparator",
+			ComparatorKindToString(cmp.Op))
+	}
+
+	if cmp.Atom.Kind == LUnbounded {
+		return "", errors.WithMessagef(ErrNoSyntax, "unbounded comparator")
+	}
+
+	atom, err := formatLiteral(&cmp.Atom)
+	if err != nil {
+		return "", err
+	}
+
+	return oper + atom, nil
+}
+
+// Render a range.
+func formatRange(rng *ASTRange) (string, error) {
+	if rng == nil {
+		return "", errors.WithMessagef(ErrNoSyntax, "missing range")
+	}
+
+	low, err := formatLiteral(rng.Lo)
+	if err != nil {
+		return "", err
+	}
+
+	high, err := formatLiteral(rng.Hi)
+	if err != nil {
+		return "", err
+	}
+
+	open, shut := "{", "}"
+
+	if rng.IncL {
+		open = "["
+	}
+
+	if rng.IncH {
+		shut = "]"
+	}
+
+	return open + low + " TO " + high + shut, nil
+}
+
+// Render a literal.  A nil literal is unbounded.
+func formatLiteral(lit *ASTLiteral) (string, error) {
+	if lit == nil {
+		return "*", nil
+	}
+
+	switch lit.Kind {
+	case LNumber:
+		return formatNumber(lit.Number)
+
+	case LString:
+		return formatPhrase(lit.String), nil
+
+	default:
+		return "*", nil
+	}
+}
+
+// Render a number.
+//
+// The lexer reads a leading `-` as the prohibit modifier, so negative
+// numbers have no syntax.
+func formatNumber(num float64) (string, error) {
+	if num < 0 || math.IsNaN(num) || math.IsInf(num, 0) {
+		return "", errors.WithMessagef(ErrNoSyntax, "number %g", num)
+	}
+
+	return strconv.FormatFloat(num, 'g', -1, 64), nil
+}
+
+// Render a field name, quoting it if needed.
+func formatField(field string) string {
+	if isBareField(field) {
+		return field
+	}
+
+	return "'" + escapeString(field, '\'') + "'"
+}
+
+// Can the field name be written without quotes?
+func isBareField(field string) bool {
+	for idx, rch := range field {
+		switch {
+		case unicode.IsLetter(rch):
+
+		case idx == 0:
+			return false
+
+		case unicode.IsDigit(rch), strings.ContainsRune(".-+_", rch):
+
+		default:
+			return false
+		}
+	}
+
+	return field != ""
+}
+
+// Render a phrase.
+func formatPhrase(phrase string) string {
+	return `"` + escapeString(phrase, '"') + `"`
+}
+
+// Render a regular expression.
+//
+// The pattern is written as it is, bar any unescaped slashes and line
+// breaks, which are escaped in a way that the regular expression engine
+// treats the same.
+func formatRegex(pattern string) string {
+	var sbld strings.Builder
+
+	sbld.WriteRune('/')
+
+	escaped := false
+
+	for _, rch := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+
+		case rch == '\\':
+			escaped = true
+
+		case rch == '/':
+			sbld.WriteRune('\\')
+
+		case rch == '\n':
+			sbld.WriteString(`\n`)
+
+			continue
+
+		case rch == '\r':
+			sbld.WriteString(`\r`)
+
+			continue
+		}
+
+		sbld.WriteRune(rch)
+	}
+
+	sbld.WriteRune('/')
+
+	return sbld.String()
+}
+
+// Escape a string for use within the given quote character.
+func escapeString(str string, quote rune) string {
+	var sbld strings.Builder
+
+	for _, rch := range str {
+		switch {
+		case rch == quote || rch == '\\':
+			sbld.WriteRune('\\')
+			sbld.WriteRune(rch)
+
+		case rch == '\t':
+			sbld.WriteString(`\t`)
+
+		case rch == '\b':
+			sbld.WriteString(`\b`)
+
+		case rch == '\f':
+			sbld.WriteString(`\f`)
+
+		case rch < ' ' || rch == unicode.MaxASCII:
+			fmt.Fprintf(&sbld, `\u%04x`, rch)
+
+		default:
+			sbld.WriteRune(rch)
+		}
+	}
+
+	return sbld.String()
+}
+
+// Render the given AST node as lucette query source.
+func FormatAST(node ASTNode) (string, error) {
+	src, _, err := formatNode(node)
+
+	return src, err
+}
+
+// * format.go ends here.
@@ -0,0 +1,360 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// format_ir.go --- Render IR nodes as query source.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// Rendering IR is useful for showing a simplified or NNF query to the user.
+//
+// The IR refers to fields by their schema name rather than the name used
+// in the query, so a schema is needed to map them back.  Where several
+// query names map to the same field, the lexically-first one is used.
+//
+// Literals are rendered such that typing the output against the same schema
+// gives the same IR, or one with the same meaning:
+//
+//   - Equality on a date/time, or on a negative number, becomes a range
+//     with equal bounds, as neither has a syntax of its own.
+//   - Inequality becomes `NOT` applied to equality.
+//   - Negative numbers are written as strings, which the typer parses.
+//
+// Nodes that only the typer can produce for invalid queries, such as
+// `IRStringEQ` and `IRAny`, and the constants `IRTrue` and `IRFalse`,
+// have no syntax and result in `ErrNoSyntax`.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Types:
+
+type irFormatter struct {
+	names map[string]string // Map of `schema name -> query name`.
+}
+
+// ** Methods:
+
+// Render a field name.
+func (f *irFormatter) field(name string) string {
+	if query, found := f.names[name]; found {
+		return formatField(query)
+	}
+
+	return formatField(name)
+}
+
+// Render a list of kids joined by the given operator.
+func (f *irFormatter) kids(kids []IRNode, oper string, prec fmtPrec) (string, error) {
+	if len(kids) == 0 {
+		return "", errors.WithMessagef(ErrNoSyntax, "empty %s", oper)
+	}
+
+	parts := make([]string, 0, len(kids))
+
+	for _, kid := range kids {
+		src, kprec, err := f.node(kid)
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, parenthesise(src, kprec, prec))
+	}
+
+	return strings.Join(parts, " "+oper+" "), nil
+}
+
+// Render a comparison.
+//
+// `exact` is true if equality can be written with the bare atom.
+func (f *irFormatter) compare(field string, oper ComparatorKind, atom string, exact bool, boost *float64) (string, fmtPrec, error) {
+	var body string
+
+	switch oper {
+	case ComparatorLT:
+		body = "<" + atom
+
+	case ComparatorLTE:
+		body = "<=" + atom
+
+	case ComparatorGT:
+		body = ">" + atom
+
+	case ComparatorGTE:
+		body = ">=" + atom
+
+	case ComparatorEQ, ComparatorNEQ:
+		body = atom
+
+		if !exact {
+			body = "[" + atom + " TO " + atom + "]"
+		}
+
+		if oper == ComparatorNEQ {
+			// A negated predicate does not contribute to the score,
+			// so the boost is dropped.
+			return "NOT " + f.field(field) + ":" + body, fmtPrecUnary, nil
+		}
+
+	default:
+		return "", 0, errors.WithMessagef(ErrNoSyntax,
+			"%s comparator",
+			ComparatorKindToString(oper))
+	}
+
+	suffix, err := formatBoost(boost)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return f.field(field) + ":" + body + suffix, fmtPrecAtom, nil
+}
+
+// Render a range.
+func (f *irFormatter) between(field, low, high string, incl, inch bool, boost *float64) (string, fmtPrec, error) {
+	open, shut := "{", "}"
+
+	if incl {
+		open = "["
+	}
+
+	if inch {
+		shut = "]"
+	}
+
+	suffix, err := formatBoost(boost)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return f.field(field) + ":" + open + low + " TO " + high + shut + suffix,
+		fmtPrecAtom,
+		nil
+}
+
+// Render a phrase.
+func (f *irFormatter) phrase(node *IRPhrase) (string, fmtPrec, error) {
+	src := f.field(node.Field) + ":" + formatPhrase(node.Phrase)
+
+	fuzz := node.Fuzz
+	if fuzz == nil && node.Proximity != 0 {
+		prox := float64(node.Proximity)
+		fuzz = &prox
+	}
+
+	if fuzz != nil {
+		num, err := formatNumber(*fuzz)
+		if err != nil {
+			return "", 0, err
+		}
+
+		src += "~" + num
+	}
+
+	suffix, err := formatBoost(node.Boost)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return src + suffix, fmtPrecAtom, nil
+}
+
+// Render a node, returning its source and precedence.
+//
+//nolint:cyclop,funlen
+func (f *irFormatter) node(node IRNode) (string, fmtPrec, error) {
+	switch val := node.(type) {
+	case *IROr:
+		src, err := f.kids(val.Kids, "OR", fmtPrecOr)
+
+		return src, fmtPrecOr, err
+
+	case *IRAnd:
+		src, err := f.kids(val.Kids, "AND", fmtPrecAnd)
+
+		return src, fmtPrecAnd, err
+
+	case *IRNot:
+		src, prec, err := f.node(val.Kid)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return "NOT " + parenthesise(src, prec, fmtPrecAtom), fmtPrecUnary, nil
+
+	case *IRPhrase:
+		return f.phrase(val)
+
+	case *IRRegex:
+		suffix, err := formatBoost(val.Boost)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return f.field(val.Field) + ":" + formatRegex(val.Pattern) + suffix,
+			fmtPrecAtom,
+			nil
+
+	case *IRNumberCmp:
+		atom, exact := numberAtom(val.Value)
+
+		return f.compare(val.Field, val.Op, atom, exact, val.Boost)
+
+	case *IRNumberRange:
+		return f.between(val.Field,
+			optionalAtom(val.Lo, numberAtom),
+			optionalAtom(val.Hi, numberAtom),
+			val.IncL,
+			val.IncH,
+			val.Boost)
+
+	case *IRTimeCmp:
+		atom, exact := timeAtom(val.Value)
+
+		return f.compare(val.Field, val.Op, atom, exact, val.Boost)
+
+	case *IRTimeRange:
+		return f.between(val.Field,
+			optionalAtom(val.Lo, timeAtom),
+			optionalAtom(val.Hi, timeAtom),
+			val.IncL,
+			val.IncH,
+			val.Boost)
+
+	case *IRIPCmp:
+		// The typer uses the zero address when parsing fails.
+		if !val.Value.IsValid() {
+			return "", 0, errors.WithMessagef(ErrNoSyntax,
+				"invalid address")
+		}
+
+		atom, exact := ipAtom(val.Value)
+
+		return f.compare(val.Field, val.Op, atom, exact, val.Boost)
+
+	case *IRIPRange:
+		low, _ := ipAtom(val.Lo)
+		high, _ := ipAtom(val.Hi)
+
+		return f.between(val.Field, low, high, val.IncL, val.IncH, val.Boost)
+
+	default:
+		return "", 0, errors.WithMessagef(ErrNoSyntax, "%T", node)
+	}
+}
+
+// ** Functions:
+
+// Render a boost suffix, if there is a boost.
+func formatBoost(boost *float64) (string, error) {
+	if boost == nil {
+		return "", nil
+	}
+
+	num, err := formatNumber(*boost)
+	if err != nil {
+		return "", err
+	}
+
+	return "^" + num, nil
+}
+
+// Render a number as a literal.
+//
+// Returns `false` if the number has to be written as a string.
+func numberAtom(num float64) (string, bool) {
+	if num >= 0 && !math.IsInf(num, 0) {
+		str, _ := formatNumber(num)
+
+		return str, true
+	}
+
+	return formatPhrase(strconv.FormatFloat(num, 'g', -1, 64)), false
+}
+
+// Render a Unix epoch in nanoseconds as a literal.
+func timeAtom(epoch int64) (string, bool) {
+	return formatPhrase(time.Unix(0, epoch).UTC().Format(time.RFC3339Nano)),
+		false
+}
+
+// Render an IP address as a literal.  The zero address is unbounded.
+func ipAtom(addr netip.Addr) (string, bool) {
+	if !addr.IsValid() {
+		return "*", false
+	}
+
+	return formatPhrase(addr.String()), true
+}
+
+// Render an optional value as a literal.  A nil value is unbounded.
+func optionalAtom[T any](val *T, atom func(T) (string, bool)) string {
+	if val == nil {
+		return "*"
+	}
+
+	str, _ := atom(*val)
+
+	return str
+}
+
+// Render the given IR node as lucette query source.
+//
+// Field names are mapped back to their query names using the given schema.
+func FormatIR(node IRNode, schema Schema) (string, error) {
+	fmtr := &irFormatter{names: make(map[string]string, len(schema))}
+
+	for query, spec := range schema {
+		if have, found := fmtr.names[spec.Name]; !found || query < have {
+			fmtr.names[spec.Name] = query
+		}
+	}
+
+	src, _, err := fmtr.node(node)
+
+	return src, err
+}
+
+// * format_ir.go ends here.
@@ -0,0 +1,345 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// format_test.go --- Query formatter tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Utilities:
+
+// Parse a query into an AST.
+func parseQuery(t *testing.T, query string) ASTNode {
+	t.Helper()
+
+	lexed, err := NewLexer().Lex(strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("%s: lexer error: %#v", query, err)
+	}
+
+	parsed, diags := NewParser().Parse(lexed)
+	if len(diags) > 0 {
+		t.Fatalf("%s: parser errors: %v", query, diags)
+	}
+
+	return parsed
+}
+
+// Return a copy of the AST without source spans or compiled regexes, so
+// that trees can be compared structurally.
+func stripAST(node ASTNode) ASTNode {
+	switch val := node.(type) {
+	case *ASTAnd:
+		kids := make([]ASTNode, len(val.Kids))
+
+		for idx := range val.Kids {
+			kids[idx] = stripAST(val.Kids[idx])
+		}
+
+		return &ASTAnd{Kids: kids}
+
+	case *ASTOr:
+		kids := make([]ASTNode, len(val.Kids))
+
+		for idx := range val.Kids {
+			kids[idx] = stripAST(val.Kids[idx])
+		}
+
+		return &ASTOr{Kids: kids}
+
+	case *ASTNot:
+		return &ASTNot{Kid: stripAST(val.Kid)}
+
+	case *ASTModifier:
+		return &ASTModifier{Kind: val.Kind, Kid: stripAST(val.Kid)}
+
+	case *ASTPredicate:
+		res := *val
+		res.span = nil
+		res.compiled = nil
+
+		if res.Comparator != nil {
+			cmp := *res.Comparator
+			cmp.Atom.span = nil
+			res.Comparator = &cmp
+		}
+
+		if res.Range != nil {
+			rng := *res.Range
+			rng.Lo = stripLiteral(rng.Lo)
+			rng.Hi = stripLiteral(rng.Hi)
+			res.Range = &rng
+		}
+
+		return &res
+	}
+
+	return node
+}
+
+func stripLiteral(lit *ASTLiteral) *ASTLiteral {
+	if lit == nil {
+		return nil
+	}
+
+	res := *lit
+	res.span = nil
+
+	return &res
+}
+
+// ** Tests:
+
+func TestFormatAST(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`level:42`, `level:42`},
+		{`level:>=1.5e3`, `level:>=1500`},
+		{`message:"hello"`, `message:"hello"`},
+		{`message:"a \"quoted\" \\ word"`, `message:"a \"quoted\" \\ word"`},
+		{`message:"tab\there"`, `message:"tab\there"`},
+		{`message:"x"^2~1`, `message:"x"~1^2`},
+		{`message:"x"~`, `message:"x"~`},
+		{`message:/^a\/b$/i`, `message:/(?i)^a\/b$/`},
+		{`'the message':"x"`, `'the message':"x"`},
+		{`'it\'s':1`, `'it\'s':1`},
+		{`level:[1 TO 5}`, `level:[1 TO 5}`},
+		{`timestamp:{* TO "2025-01-01T00:00:00Z"]`, `timestamp:{* TO "2025-01-01T00:00:00Z"]`},
+		{`a:1 && b:2 || c:3`, `a:1 AND b:2 OR c:3`},
+		{`a:1 && (b:2 || c:3)`, `a:1 AND (b:2 OR c:3)`},
+		{`(a:1 || b:2) c:3`, `(a:1 OR b:2) AND c:3`},
+		{`!a:1 and -b:2 and +c:3`, `NOT a:1 AND -b:2 AND +c:3`},
+		{`!(a:1 || b:2)`, `NOT (a:1 OR b:2)`},
+		{`NOT (NOT a:1)`, `NOT (NOT a:1)`},
+		{`-(+a:1)`, `-(+a:1)`},
+		{`f:(a:1 || b:2)`, `f:1 OR f:2`},
+		{`"bare"`, `"bare"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			parsed := parseQuery(t, test.query)
+
+			got, err := FormatAST(parsed)
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("Source mismatch: %s != %s", got, test.want)
+			}
+
+			again := parseQuery(t, got)
+			if !reflect.DeepEqual(stripAST(again), stripAST(parsed)) {
+				t.Errorf("Round trip changed the AST for %s", got)
+			}
+		})
+	}
+}
+
+func TestFormatASTProgrammatic(t *testing.T) {
+	boost := 1.5
+	tree := &ASTAnd{Kids: []ASTNode{
+		&ASTPredicate{
+			Kind:   PredicatePHRASE,
+			Field:  "9lives",
+			String: "line one\nline \"two\"\u007f",
+			Boost:  &boost},
+		&ASTAnd{Kids: []ASTNode{
+			&ASTPredicate{
+				Kind:  PredicateREGEX,
+				Field: "path",
+				Regex: "^/usr/(s?bin)\n"},
+			&ASTNot{Kid: &ASTOr{Kids: []ASTNode{
+				&ASTPredicate{
+					Kind:  PredicateRANGE,
+					Field: "n",
+					Range: &ASTRange{
+						Lo:   &ASTLiteral{Kind: LNumber, Number: 0.25},
+						Hi:   nil,
+						IncL: true}},
+				&ASTPredicate{
+					Kind:  PredicateCMP,
+					Field: "s",
+					Comparator: &ASTComparator{
+						Op:   ComparatorLT,
+						Atom: ASTLiteral{Kind: LString, String: "m"}}},
+			}}},
+		}},
+	}}
+
+	got, err := FormatAST(tree)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	want := `'9lives':"line one\u000aline \"two\"\u007f"^1.5` +
+		` AND path:/^\/usr\/(s?bin)\n/` +
+		` AND NOT (n:[0.25 TO *} OR s:<"m")`
+
+	if got != want {
+		t.Errorf("Source mismatch:\ngot:  %s\nwant: %s", got, want)
+	}
+
+	parsed := parseQuery(t, got)
+
+	// The regex is rewritten, so compare it separately.
+	pred := parsed.(*ASTAnd).Kids[1].(*ASTPredicate)
+	if pred.compiled == nil || !pred.compiled.MatchString("/usr/sbin\n") {
+		t.Errorf("Regex mismatch: %q", pred.Regex)
+	}
+
+	phrase := parsed.(*ASTAnd).Kids[0].(*ASTPredicate)
+	if phrase.String != tree.Kids[0].(*ASTPredicate).String {
+		t.Errorf("Phrase mismatch: %q", phrase.String)
+	}
+}
+
+func TestFormatASTErrors(t *testing.T) {
+	neg := -1.0
+
+	tests := []struct {
+		name string
+		node ASTNode
+	}{
+		{"EQ.S", &ASTPredicate{Kind: PredicateEQS, Field: "a", String: "x"}},
+		{"PREFIX", &ASTPredicate{Kind: PredicatePREFIX, Field: "a"}},
+		{"NEQ", &ASTPredicate{
+			Kind:       PredicateCMP,
+			Field:      "a",
+			Comparator: &ASTComparator{Op: ComparatorNEQ}}},
+		{"Negative", &ASTPredicate{
+			Kind:  PredicateCMP,
+			Field: "a",
+			Comparator: &ASTComparator{
+				Op:   ComparatorEQ,
+				Atom: ASTLiteral{Kind: LNumber, Number: -4}}}},
+		{"Boost", &ASTPredicate{Kind: PredicatePHRASE, Boost: &neg}},
+		{"Empty", &ASTOr{}},
+		{"Nil", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := FormatAST(test.node); !errors.Is(err, ErrNoSyntax) {
+				t.Errorf("Error mismatch: %#v", err)
+			}
+		})
+	}
+}
+
+func TestFormatIR(t *testing.T) {
+	schema := MakeStructSchema()
+	docs := []Document{
+		MapDocument{
+			"Timestamp": time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			"Level":     42,
+			"Percent":   -2.5,
+			"Source":    "192.168.1.20",
+			"Message":   "hello brave new world",
+		},
+		MapDocument{"Level": 7, "Message": "goodbye"},
+	}
+
+	queries := []string{
+		`level:42 && message:"brave new"~1^2`,
+		`!level:[1 TO 10]`,
+		`!(level:42 || message:/^good/)`,
+		`percent:<"-1"`,
+		`percent:["-2.5" TO "-2.5"]`,
+		`timestamp:["2025-06-01T12:00:00Z" TO "2025-06-01T12:00:00Z"]`,
+		`!timestamp:>"2025-01-01T00:00:00.5Z"`,
+		`source:"192.168.1.20" || !source:["10.0.0.1" TO *}`,
+		`'the message':"hello"`,
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			node := typeQuery(t, query)
+
+			src, err := FormatIR(node, schema)
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			again := typeQuery(t, src)
+
+			if got, _ := FormatIR(again, schema); got != src {
+				t.Errorf("Not a fixed point: %s != %s", got, src)
+			}
+
+			want := NewProgram()
+			want.Emit(node)
+
+			got := NewProgram()
+			got.Emit(again)
+
+			for idx, doc := range docs {
+				lhs, _ := got.Evaluate(doc)
+				rhs, _ := want.Evaluate(doc)
+
+				if lhs != rhs {
+					t.Errorf("%s: doc %d: %#v != %#v", src, idx, lhs, rhs)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatIRErrors(t *testing.T) {
+	for _, node := range []IRNode{
+		&IRTrue{},
+		&IRStringEQ{Field: "Message", Value: "x"},
+		&IRIPCmp{Field: "Source", Op: ComparatorEQ},
+	} {
+		if _, err := FormatIR(node, nil); !errors.Is(err, ErrNoSyntax) {
+			t.Errorf("%T: error mismatch: %#v", node, err)
+		}
+	}
+}
+
+// * format_test.go ends here.
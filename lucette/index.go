@@ -0,0 +1,425 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// index.go --- In-memory inverted index.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// The index keeps the following structures for each field in its schema:
+//
+//   present  Documents that have any value at all for the field.
+//   values   Whole string values, for equality, prefix, glob, and regular
+//            expression predicates.
+//   terms    Terms of keyword and text fields, for phrase predicates.
+//            Text fields are tokenised by their analyser, if they have
+//            one, otherwise on whitespace, which is exactly how the
+//            virtual machine sees them.
+//   sorted   Numeric, date/time, and IP address values in sorted order,
+//            for comparison and range predicates.
+//
+// Documents are stored as well, so that predicates the index cannot answer
+// exactly can be verified by running the compiled query over the
+// candidates that the index produced.  Documents must therefore not be
+// modified once they have been added.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"cmp"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Types:
+
+// Keys under which a document was indexed for a field.
+type docKeys struct {
+	values []string
+	terms  []string
+}
+
+// A stored document.
+type indexedDoc struct {
+	id   string
+	doc  Document
+	keys map[string]docKeys
+}
+
+// Index structures for a single field.
+type fieldIndex struct {
+	spec     FieldSpec
+	analyser Analyser
+	present  postings
+	values   map[string]postings
+	terms    map[string]postings
+	numbers  *sortedValues[float64]
+	times    *sortedValues[int64]
+	ips      *sortedValues[netip.Addr]
+}
+
+// ** Field index methods:
+
+// Return the terms of the given value.
+func (f *fieldIndex) tokenise(str string) []string {
+	if f.analyser != nil {
+		return f.analyser.Analyse(str)
+	}
+
+	return strings.Fields(str)
+}
+
+// Add a document's values to the field index.
+func (f *fieldIndex) add(vals []any, ord uint32) docKeys {
+	keys := docKeys{}
+
+	for _, val := range vals {
+		if val == nil {
+			continue
+		}
+
+		f.present = f.present.add(ord)
+
+		if str, ok := valueString(val); ok {
+			f.values[str] = f.values[str].add(ord)
+			keys.values = append(keys.values, str)
+
+			if f.terms != nil {
+				for _, term := range f.tokenise(str) {
+					f.terms[term] = f.terms[term].add(ord)
+					keys.terms = append(keys.terms, term)
+				}
+			}
+		}
+
+		f.addSorted(val, ord)
+	}
+
+	return keys
+}
+
+// Add a value to the field's sorted value list, if it has one.
+func (f *fieldIndex) addSorted(val any, ord uint32) {
+	switch {
+	case f.numbers != nil:
+		if num, ok := valueNumber(val); ok {
+			f.numbers.add(num, ord)
+		}
+
+	case f.times != nil:
+		if epoch, ok := valueTime(val); ok {
+			f.times.add(epoch, ord)
+		}
+
+	case f.ips != nil:
+		if addr, ok := valueIP(val); ok {
+			f.ips.add(addr, ord)
+		}
+	}
+}
+
+// Remove a document from the field index.
+func (f *fieldIndex) remove(keys docKeys, ord uint32) {
+	f.present = f.present.remove(ord)
+
+	removeKeys(f.values, keys.values, ord)
+	removeKeys(f.terms, keys.terms, ord)
+
+	switch {
+	case f.numbers != nil:
+		f.numbers.remove(ord)
+
+	case f.times != nil:
+		f.times.remove(ord)
+
+	case f.ips != nil:
+		f.ips.remove(ord)
+	}
+}
+
+// ** Index:
+
+// In-memory inverted index of documents.
+//
+// Queries are run against the index by passing the typed IR of a query to
+// `Search`.  The IR must have been typed against the same schema as the
+// index.
+//
+// An index is safe for concurrent use.
+type Index struct {
+	mu        sync.RWMutex
+	fields    map[string]*fieldIndex
+	analysers *AnalyserRegistry
+	ordinals  map[string]uint32
+	docs      []*indexedDoc
+	live      postings
+}
+
+// *** Methods:
+
+// Return the number of documents in the index.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.live)
+}
+
+// Add a document to the index under the given ID.
+//
+// If a document with the same ID already exists, then it is replaced.
+func (idx *Index) Add(id string, doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+
+	ord := uint32(len(idx.docs)) //nolint:gosec
+	stored := &indexedDoc{
+		id:   id,
+		doc:  doc,
+		keys: make(map[string]docKeys, len(idx.fields))}
+
+	for name, field := range idx.fields {
+		raw, found := doc.Get(name)
+		if !found {
+			continue
+		}
+
+		stored.keys[name] = field.add(fieldValues(raw), ord)
+	}
+
+	idx.docs = append(idx.docs, stored)
+	idx.ordinals[id] = ord
+	idx.live = idx.live.add(ord)
+}
+
+// Remove the document with the given ID from the index.
+//
+// Returns `false` if there is no such document.
+func (idx *Index) Remove(id string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	return idx.remove(id)
+}
+
+// Remove a document.  The caller must hold the write lock.
+func (idx *Index) remove(id string) bool {
+	ord, found := idx.ordinals[id]
+	if !found {
+		return false
+	}
+
+	stored := idx.docs[ord]
+
+	for name, keys := range stored.keys {
+		idx.fields[name].remove(keys, ord)
+	}
+
+	idx.docs[ord] = nil
+	idx.live = idx.live.remove(ord)
+	delete(idx.ordinals, id)
+
+	return true
+}
+
+// Return the document with the given ID.
+func (idx *Index) Get(id string) (Document, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	ord, found := idx.ordinals[id]
+	if !found {
+		return nil, false
+	}
+
+	return idx.docs[ord].doc, true
+}
+
+// Search the index for documents matching the given query IR.
+//
+// Returns the IDs of the matching documents in the order in which they
+// were added.
+func (idx *Index) Search(node IRNode) ([]string, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	plan := idx.plan(node)
+	docs := plan.docs
+
+	if !plan.exact {
+		var err error
+
+		if docs, err = idx.verify(node, docs); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]string, len(docs))
+
+	for pos, ord := range docs {
+		ids[pos] = idx.docs[ord].id
+	}
+
+	return ids, nil
+}
+
+// Run the query over the candidate documents and return those that match.
+func (idx *Index) verify(node IRNode, candidates postings) (postings, error) {
+	program := NewProgram()
+	program.Emit(node)
+
+	vm := NewVMWithAnalysers(program, idx.analysers)
+	out := make(postings, 0, len(candidates))
+
+	for _, ord := range candidates {
+		match, err := vm.Run(idx.docs[ord].doc)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			out = append(out, ord)
+		}
+	}
+
+	return out, nil
+}
+
+// ** Functions:
+
+// Remove a document ordinal from the postings of each of the given keys.
+//
+// Keys left without any postings are deleted.
+func removeKeys(index map[string]postings, keys []string, ord uint32) {
+	for _, key := range keys {
+		post, found := index[key]
+		if !found {
+			continue
+		}
+
+		if post = post.remove(ord); len(post) == 0 {
+			delete(index, key)
+
+			continue
+		}
+
+		index[key] = post
+	}
+}
+
+// Create a new field index for the given field.
+func newFieldIndex(spec FieldSpec, analysers *AnalyserRegistry) (*fieldIndex, error) {
+	field := &fieldIndex{
+		spec:   spec,
+		values: make(map[string]postings)}
+
+	switch spec.FType {
+	case FTKeyword:
+		field.terms = make(map[string]postings)
+
+	case FTText:
+		field.terms = make(map[string]postings)
+
+		if spec.Analyser == "" {
+			break
+		}
+
+		analyser, found := analysers.Lookup(spec.Analyser)
+		if !found {
+			return nil, errors.WithMessagef(ErrUnknownAnalyser,
+				"%q for field %q",
+				spec.Analyser,
+				spec.Name)
+		}
+
+		field.analyser = analyser
+
+	case FTNumeric:
+		field.numbers = newSortedValues(cmp.Compare[float64])
+
+	case FTDateTime:
+		field.times = newSortedValues(cmp.Compare[int64])
+
+	case FTIP:
+		field.ips = newSortedValues(func(lhs, rhs netip.Addr) int {
+			return lhs.Compare(rhs)
+		})
+	}
+
+	return field, nil
+}
+
+// Create a new index for the given schema.
+//
+// Analysers named by the schema are looked up in the default analyser
+// registry.
+func NewIndex(schema Schema) (*Index, error) {
+	return NewIndexWithAnalysers(schema, DefaultAnalyserRegistry())
+}
+
+// Create a new index for the given schema that looks up analysers in the
+// given registry.
+func NewIndexWithAnalysers(schema Schema, analysers *AnalyserRegistry) (*Index, error) {
+	idx := &Index{
+		fields:    make(map[string]*fieldIndex, len(schema)),
+		analysers: analysers,
+		ordinals:  make(map[string]uint32),
+		docs:      []*indexedDoc{},
+		live:      postings{}}
+
+	// Several schema entries may refer to the same field.
+	for _, spec := range schema {
+		if _, found := idx.fields[spec.Name]; found {
+			continue
+		}
+
+		field, err := newFieldIndex(spec, analysers)
+		if err != nil {
+			return nil, err
+		}
+
+		idx.fields[spec.Name] = field
+	}
+
+	return idx, nil
+}
+
+// * index.go ends here.
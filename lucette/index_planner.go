@@ -0,0 +1,424 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// index_planner.go --- Query planner for the inverted index.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// The planner walks a query's IR tree and turns each node into a list of
+// candidate documents:
+//
+//   AND   Intersection of the kids' candidates.
+//   OR    Union of the kids' candidates.
+//   NOT   Complement of the kid's candidates, if those are exact.
+//
+// Leaves are answered from the field structures.  A plan is exact when its
+// candidates are precisely the matching documents.  Leaves that the index
+// cannot answer exactly, such as multi-term, fuzzy, or wildcard phrases,
+// produce a superset of the matches instead, and the final candidates are
+// then verified by running the compiled query over them.
+//
+// The rules used are those of the virtual machine, so a search returns
+// exactly what a linear scan with the same query would.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// * Code:
+
+// ** Types:
+
+// Candidate documents for a query or part of one.
+type queryPlan struct {
+	docs  postings // Candidate documents.
+	exact bool     // Are the candidates exactly the matches?
+}
+
+// ** Methods:
+
+// Return a plan that must examine every document.
+func (idx *Index) scan() queryPlan {
+	return queryPlan{docs: idx.live, exact: false}
+}
+
+// Return the index for the given field, or nil if there is none.
+func (idx *Index) field(name string) *fieldIndex {
+	return idx.fields[name]
+}
+
+// Plan the given IR node.
+//
+//nolint:cyclop
+func (idx *Index) plan(node IRNode) queryPlan {
+	switch val := node.(type) {
+	case *IRTrue:
+		return queryPlan{docs: idx.live, exact: true}
+
+	case *IRFalse:
+		return queryPlan{docs: postings{}, exact: true}
+
+	case *IRAnd:
+		return idx.planAnd(val)
+
+	case *IROr:
+		return idx.planOr(val)
+
+	case *IRNot:
+		return idx.planNot(val)
+
+	case *IRAny:
+		if field := idx.field(val.Field); field != nil {
+			return queryPlan{docs: field.present, exact: true}
+		}
+
+	case *IRStringEQ:
+		if val.Analyser != "" {
+			return idx.planAnalysedEQ(val.Field, val.Value)
+		}
+
+		if field := idx.field(val.Field); field != nil {
+			return queryPlan{docs: field.values[val.Value], exact: true}
+		}
+
+	case *IRStringNEQ:
+		if val.Analyser != "" {
+			return idx.planNot(&IRNot{Kid: &IRStringEQ{
+				Field:    val.Field,
+				Value:    val.Value,
+				Analyser: val.Analyser}})
+		}
+
+		if field := idx.field(val.Field); field != nil {
+			return queryPlan{
+				docs:  idx.live.difference(field.values[val.Value]),
+				exact: true}
+		}
+
+	case *IRPrefix:
+		pred := func(str string) bool {
+			return strings.HasPrefix(str, val.Prefix)
+		}
+
+		if val.Analyser != "" {
+			return idx.planTerms(val.Field, pred)
+		}
+
+		return idx.planValues(val.Field, pred)
+
+	case *IRGlob:
+		pred := func(str string) bool {
+			return matchGlob(val.Glob, str)
+		}
+
+		if val.Analyser != "" {
+			return idx.planTerms(val.Field, pred)
+		}
+
+		return idx.planValues(val.Field, pred)
+
+	case *IRRegex:
+		return idx.planRegex(val)
+
+	case *IRPhrase:
+		return idx.planPhrase(val)
+
+	default:
+		return idx.planSorted(node)
+	}
+
+	return idx.scan()
+}
+
+// Plan a conjunction.
+func (idx *Index) planAnd(node *IRAnd) queryPlan {
+	out := queryPlan{docs: idx.live, exact: true}
+
+	for _, kid := range node.Kids {
+		plan := idx.plan(kid)
+
+		out.docs = out.docs.intersect(plan.docs)
+		out.exact = out.exact && plan.exact
+	}
+
+	return out
+}
+
+// Plan a disjunction.
+func (idx *Index) planOr(node *IROr) queryPlan {
+	out := queryPlan{docs: postings{}, exact: true}
+
+	for _, kid := range node.Kids {
+		plan := idx.plan(kid)
+
+		out.docs = out.docs.union(plan.docs)
+		out.exact = out.exact && plan.exact
+	}
+
+	return out
+}
+
+// Plan a negation.
+//
+// The complement of a superset says nothing about the matches, so an
+// inexact kid means every document is a candidate.
+func (idx *Index) planNot(node *IRNot) queryPlan {
+	plan := idx.plan(node.Kid)
+	if !plan.exact {
+		return idx.scan()
+	}
+
+	return queryPlan{docs: idx.live.difference(plan.docs), exact: true}
+}
+
+// Plan a predicate on whole string values.
+func (idx *Index) planValues(name string, pred func(string) bool) queryPlan {
+	field := idx.field(name)
+	if field == nil {
+		return idx.scan()
+	}
+
+	docs := []uint32{}
+
+	for str, post := range field.values {
+		if pred(str) {
+			docs = append(docs, post...)
+		}
+	}
+
+	return queryPlan{docs: makePostings(docs), exact: true}
+}
+
+// Plan a predicate on the terms of an analysed field.
+func (idx *Index) planTerms(name string, pred func(string) bool) queryPlan {
+	field := idx.field(name)
+	if field == nil || field.terms == nil || field.analyser == nil {
+		return idx.scan()
+	}
+
+	docs := []uint32{}
+
+	for term, post := range field.terms {
+		if pred(term) {
+			docs = append(docs, post...)
+		}
+	}
+
+	return queryPlan{docs: makePostings(docs), exact: true}
+}
+
+// Plan an equality predicate on an analysed field.
+//
+// A single term is answered exactly from the term postings.  Several
+// terms give the documents that contain all of them, which must then be
+// checked for the terms being in order.
+func (idx *Index) planAnalysedEQ(name, value string) queryPlan {
+	field := idx.field(name)
+	terms := strings.Fields(value)
+
+	if field == nil || field.terms == nil || field.analyser == nil || len(terms) == 0 {
+		return idx.scan()
+	}
+
+	docs := field.terms[terms[0]]
+
+	for _, term := range terms[1:] {
+		docs = docs.intersect(field.terms[term])
+	}
+
+	return queryPlan{docs: docs, exact: len(terms) == 1}
+}
+
+// Plan a regular expression predicate.
+func (idx *Index) planRegex(node *IRRegex) queryPlan {
+	rex := node.Compiled
+
+	if rex == nil {
+		var err error
+
+		// Leave it to the VM to complain.
+		if rex, err = regexp.Compile(node.Pattern); err != nil {
+			return idx.scan()
+		}
+	}
+
+	return idx.planValues(node.Field, rex.MatchString)
+}
+
+// Plan a phrase predicate.
+//
+// A single term is answered exactly from the term postings.  Several
+// terms give the documents that contain all of them, which must then be
+// checked for the terms being in order.  Fuzzy, proximity, and wildcard
+// phrases are left to the virtual machine.
+func (idx *Index) planPhrase(node *IRPhrase) queryPlan {
+	field := idx.field(node.Field)
+
+	switch {
+	case field == nil || field.terms == nil:
+		return idx.scan()
+
+	case node.Proximity > 0 || hasWildcard(node.Phrase):
+		return idx.scan()
+
+	case node.Fuzz != nil && int(*node.Fuzz) > 0:
+		return idx.scan()
+	}
+
+	terms := strings.Fields(node.Phrase)
+	if len(terms) == 0 {
+		return idx.scan()
+	}
+
+	docs := field.terms[terms[0]]
+
+	for _, term := range terms[1:] {
+		docs = docs.intersect(field.terms[term])
+	}
+
+	return queryPlan{docs: docs, exact: len(terms) == 1}
+}
+
+// Plan a comparison or range predicate on a sorted field.
+//
+//nolint:cyclop
+func (idx *Index) planSorted(node IRNode) queryPlan {
+	switch val := node.(type) {
+	case *IRNumberCmp:
+		if field := idx.field(val.Field); field != nil && field.numbers != nil {
+			return compareSorted(idx.live, field.numbers, val.Op, val.Value)
+		}
+
+	case *IRNumberRange:
+		if field := idx.field(val.Field); field != nil && field.numbers != nil {
+			return rangeSorted(field.numbers,
+				makeBound(val.Lo, true, val.IncL),
+				makeBound(val.Hi, false, val.IncH))
+		}
+
+	case *IRTimeCmp:
+		if field := idx.field(val.Field); field != nil && field.times != nil {
+			return compareSorted(idx.live, field.times, val.Op, val.Value)
+		}
+
+	case *IRTimeRange:
+		if field := idx.field(val.Field); field != nil && field.times != nil {
+			return rangeSorted(field.times,
+				makeBound(val.Lo, true, val.IncL),
+				makeBound(val.Hi, false, val.IncH))
+		}
+
+	case *IRIPCmp:
+		if field := idx.field(val.Field); field != nil && field.ips != nil {
+			return compareSorted(idx.live, field.ips, val.Op, val.Value.Unmap())
+		}
+
+	case *IRIPRange:
+		if field := idx.field(val.Field); field != nil && field.ips != nil {
+			return rangeSorted(field.ips,
+				makeIPBound(val.Lo, true, val.IncL),
+				makeIPBound(val.Hi, false, val.IncH))
+		}
+	}
+
+	return idx.scan()
+}
+
+// ** Functions:
+
+// Plan a comparison against a sorted value list.
+//
+// Inequality holds for documents that have no equal value, which includes
+// those that lack the field entirely.
+func compareSorted[T any](live postings, vals *sortedValues[T], kind ComparatorKind, val T) queryPlan {
+	low := rangeBound[T]{value: val, lower: true}
+	high := rangeBound[T]{value: val}
+
+	switch kind {
+	case ComparatorLT, ComparatorLTE:
+		high.bounded = true
+		high.incl = kind == ComparatorLTE
+
+	case ComparatorGT, ComparatorGTE:
+		low.bounded = true
+		low.incl = kind == ComparatorGTE
+
+	default:
+		low.bounded, low.incl = true, true
+		high.bounded, high.incl = true, true
+	}
+
+	docs := vals.search(low, high)
+
+	if kind == ComparatorNEQ {
+		docs = live.difference(docs)
+	}
+
+	return queryPlan{docs: docs, exact: true}
+}
+
+// Plan a range against a sorted value list.
+func rangeSorted[T any](vals *sortedValues[T], low, high rangeBound[T]) queryPlan {
+	return queryPlan{docs: vals.search(low, high), exact: true}
+}
+
+// Make a range bound from an optional value.
+func makeBound[T any](val *T, lower, incl bool) rangeBound[T] {
+	bound := rangeBound[T]{lower: lower, incl: incl}
+
+	if val != nil {
+		bound.value = *val
+		bound.bounded = true
+	}
+
+	return bound
+}
+
+// Make a range bound from an IP address.
+//
+// The zero address denotes an unbounded end.
+func makeIPBound(addr netip.Addr, lower, incl bool) rangeBound[netip.Addr] {
+	return rangeBound[netip.Addr]{
+		value:   addr.Unmap(),
+		bounded: addr.IsValid(),
+		lower:   lower,
+		incl:    incl}
+}
+
+// * index_planner.go ends here.
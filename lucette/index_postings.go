@@ -0,0 +1,226 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// index_postings.go --- Postings lists and sorted value lists.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A postings list is a sorted list of document ordinals without
+// duplicates.  Documents are given ordinals in the order they are added
+// and ordinals are never reused, so adding a document only ever appends
+// to a postings list.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"slices"
+	"sort"
+)
+
+// * Code:
+
+// ** Postings:
+
+// Sorted list of document ordinals.
+type postings []uint32
+
+// *** Methods:
+
+// Add a document ordinal to the postings list.
+func (p postings) add(doc uint32) postings {
+	if len(p) == 0 || p[len(p)-1] < doc {
+		return append(p, doc)
+	}
+
+	idx, found := slices.BinarySearch(p, doc)
+	if found {
+		return p
+	}
+
+	return slices.Insert(p, idx, doc)
+}
+
+// Remove a document ordinal from the postings list.
+func (p postings) remove(doc uint32) postings {
+	idx, found := slices.BinarySearch(p, doc)
+	if !found {
+		return p
+	}
+
+	return slices.Delete(p, idx, idx+1)
+}
+
+// Return the ordinals that are in both postings lists.
+func (p postings) intersect(other postings) postings {
+	out := make(postings, 0, min(len(p), len(other)))
+
+	for lhs, rhs := 0, 0; lhs < len(p) && rhs < len(other); {
+		switch {
+		case p[lhs] < other[rhs]:
+			lhs++
+
+		case p[lhs] > other[rhs]:
+			rhs++
+
+		default:
+			out = append(out, p[lhs])
+			lhs++
+			rhs++
+		}
+	}
+
+	return out
+}
+
+// Return the ordinals that are in either postings list.
+func (p postings) union(other postings) postings {
+	out := make(postings, 0, len(p)+len(other))
+	lhs, rhs := 0, 0
+
+	for lhs < len(p) && rhs < len(other) {
+		switch {
+		case p[lhs] < other[rhs]:
+			out = append(out, p[lhs])
+			lhs++
+
+		case p[lhs] > other[rhs]:
+			out = append(out, other[rhs])
+			rhs++
+
+		default:
+			out = append(out, p[lhs])
+			lhs++
+			rhs++
+		}
+	}
+
+	out = append(out, p[lhs:]...)
+
+	return append(out, other[rhs:]...)
+}
+
+// Return the ordinals that are in this postings list but not the other.
+func (p postings) difference(other postings) postings {
+	out := make(postings, 0, len(p))
+	rhs := 0
+
+	for _, doc := range p {
+		for rhs < len(other) && other[rhs] < doc {
+			rhs++
+		}
+
+		if rhs < len(other) && other[rhs] == doc {
+			continue
+		}
+
+		out = append(out, doc)
+	}
+
+	return out
+}
+
+// *** Functions:
+
+// Build a postings list from an unsorted list of ordinals.
+func makePostings(docs []uint32) postings {
+	slices.Sort(docs)
+
+	return slices.Compact(postings(docs))
+}
+
+// ** Sorted values:
+
+// A value along with the document that holds it.
+type sortedEntry[T any] struct {
+	value T
+	doc   uint32
+}
+
+// A list of values kept in sorted order so that ranges can be found by
+// binary search.
+type sortedValues[T any] struct {
+	entries []sortedEntry[T]
+	compare func(T, T) int
+}
+
+// *** Methods:
+
+// Add a value held by the given document.
+func (s *sortedValues[T]) add(val T, doc uint32) {
+	idx := sort.Search(len(s.entries), func(idx int) bool {
+		return s.compare(s.entries[idx].value, val) > 0
+	})
+
+	s.entries = slices.Insert(s.entries, idx, sortedEntry[T]{val, doc})
+}
+
+// Remove all values held by the given document.
+func (s *sortedValues[T]) remove(doc uint32) {
+	s.entries = slices.DeleteFunc(s.entries, func(ent sortedEntry[T]) bool {
+		return ent.doc == doc
+	})
+}
+
+// Return the documents holding a value within the given bounds.
+func (s *sortedValues[T]) search(low, high rangeBound[T]) postings {
+	start := sort.Search(len(s.entries), func(idx int) bool {
+		return low.admits(s.entries[idx].value, s.compare)
+	})
+
+	end := sort.Search(len(s.entries), func(idx int) bool {
+		return !high.admits(s.entries[idx].value, s.compare)
+	})
+
+	if start >= end {
+		return postings{}
+	}
+
+	docs := make([]uint32, 0, end-start)
+
+	for _, ent := range s.entries[start:end] {
+		docs = append(docs, ent.doc)
+	}
+
+	return makePostings(docs)
+}
+
+// *** Functions:
+
+// Create a new sorted value list using the given comparison function.
+func newSortedValues[T any](compare func(T, T) int) *sortedValues[T] {
+	return &sortedValues[T]{compare: compare}
+}
+
+// * index_postings.go ends here.
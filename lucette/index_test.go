@@ -0,0 +1,313 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// index_test.go --- Inverted index tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// * Code:
+
+// ** Utilities:
+
+// Type a query against the test schema and return its simplified IR.
+func typeQuery(t testing.TB, query string) IRNode {
+	t.Helper()
+
+	lexed, err := NewLexer().Lex(strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("Lexer error: %#v", err)
+	}
+
+	parsed, diags := NewParser().Parse(lexed)
+	if len(diags) > 0 {
+		t.Fatalf("Parser errors: %v", diags)
+	}
+
+	typed, diags := NewTyper(MakeStructSchema()).Type(parsed)
+	if len(diags) > 0 {
+		t.Fatalf("Typer errors: %v", diags)
+	}
+
+	return NewSimplifier().Simplify(NewNNF().NNF(typed))
+}
+
+// Build an index of generated documents.
+func makeTestIndex(t testing.TB, count int) (*Index, map[string]Document) {
+	t.Helper()
+
+	idx, err := NewIndex(MakeStructSchema())
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	words := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	docs := make(map[string]Document, count)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for num := range count {
+		doc := MapDocument{
+			"Timestamp": base.Add(time.Duration(num) * time.Hour),
+			"Facility":  []string{"kern", "user", "mail"}[num%3],
+			"Level":     num % 10,
+			"Percent":   float64(num) / 4,
+			"Source":    fmt.Sprintf("10.0.%d.%d", num/256, num%256),
+			"Message": words[num%5] + " " +
+				words[(num/5)%5] + " " +
+				words[(num/25)%5],
+		}
+
+		// Leave some fields missing.
+		if num%7 == 0 {
+			delete(doc, "Level")
+		}
+
+		id := fmt.Sprintf("doc-%03d", num)
+		docs[id] = doc
+		idx.Add(id, doc)
+	}
+
+	return idx, docs
+}
+
+// Return the IDs of the documents that match by linear scan.
+func scanDocs(t testing.TB, program *Program, docs map[string]Document) []string {
+	t.Helper()
+
+	out := []string{}
+
+	for id, doc := range docs {
+		match, err := program.Run(doc)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if match {
+			out = append(out, id)
+		}
+	}
+
+	slices.Sort(out)
+
+	return out
+}
+
+// ** Tests:
+
+func TestIndexSearch(t *testing.T) {
+	idx, docs := makeTestIndex(t, 300)
+
+	queries := []string{
+		`level:3`,
+		`!level:3`,
+		`level:>7 || level:<1`,
+		`level:[2 TO 4} && facility:"kern"`,
+		`percent:[10 TO 20]`,
+		`message:"gamma"`,
+		`message:"beta gamma"`,
+		`message:"gam*"`,
+		`message:"gamna"~1`,
+		`message:/^alpha .* delta$/`,
+		`!message:"alpha" && !facility:"mail"`,
+		`source:["10.0.0.10" TO "10.0.0.20"]`,
+		`source:>="10.0.1.0"`,
+		`!source:"10.0.0.5"`,
+		`timestamp:>"2025-01-05T00:00:00Z" && level:5`,
+		`(message:"delta" || level:9) && !(facility:"user" || percent:<5)`,
+		`!(message:"alpha beta" || level:[* TO 5])`,
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			node := typeQuery(t, query)
+
+			got, err := idx.Search(node)
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			program := NewProgram()
+			program.Emit(node)
+
+			want := scanDocs(t, program, docs)
+
+			if !slices.Equal(got, want) {
+				t.Errorf("Result mismatch:\ngot:  %v\nwant: %v",
+					got,
+					want)
+			}
+		})
+	}
+}
+
+func TestIndexRemove(t *testing.T) {
+	idx, _ := makeTestIndex(t, 20)
+	node := typeQuery(t, `message:"alpha" && level:[0 TO 9]`)
+
+	before, err := idx.Search(node)
+	if err != nil || len(before) == 0 {
+		t.Fatalf("Unexpected result: %v, %v", before, err)
+	}
+
+	if !idx.Remove(before[0]) {
+		t.Fatalf("Remove of %q failed", before[0])
+	}
+
+	if idx.Remove(before[0]) {
+		t.Error("Removed the same document twice")
+	}
+
+	if idx.Len() != 19 {
+		t.Errorf("Length mismatch: %d != 19", idx.Len())
+	}
+
+	after, _ := idx.Search(node)
+	if !slices.Equal(after, before[1:]) {
+		t.Errorf("Result mismatch: %v != %v", after, before[1:])
+	}
+
+	// Replacing a document re-indexes it.
+	idx.Add(before[1], MapDocument{"Message": "nothing", "Level": 1})
+
+	after, _ = idx.Search(node)
+	if slices.Contains(after, before[1]) {
+		t.Errorf("Replaced document still matches: %v", after)
+	}
+
+	if doc, found := idx.Get(before[1]); !found {
+		t.Error("Replaced document not found")
+	} else if msg, _ := doc.Get("Message"); msg != "nothing" {
+		t.Errorf("Replaced document mismatch: %v", msg)
+	}
+}
+
+func TestIndexPlanner(t *testing.T) {
+	idx, _ := makeTestIndex(t, 50)
+
+	tests := []struct {
+		query string
+		exact bool
+	}{
+		{`level:3`, true},
+		{`message:"gamma"`, true},
+		{`message:"beta gamma"`, false},
+		{`message:"gam*"`, false},
+		{`!message:"beta gamma"`, false},
+		{`!message:"gamma" || source:"10.0.0.1"`, true},
+		{`message:/^al/ && percent:[1 TO 2]`, true},
+	}
+
+	for _, test := range tests {
+		plan := idx.plan(typeQuery(t, test.query))
+
+		if plan.exact != test.exact {
+			t.Errorf("%s: exact %v != %v",
+				test.query,
+				plan.exact,
+				test.exact)
+		}
+	}
+}
+
+func TestIndexAnalysers(t *testing.T) {
+	schema := Schema{
+		"message": FieldSpec{
+			Name:     "Message",
+			FType:    FTText,
+			Analyser: AnalyserEnglish},
+	}
+
+	idx, err := NewIndex(schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	idx.Add("one", MapDocument{"Message": "Running QUERIES"})
+	idx.Add("two", MapDocument{"Message": "a single query"})
+
+	got, err := idx.Search(compileAnalysedIR(t, schema, `message:"queries"`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if !slices.Equal(got, []string{"one", "two"}) {
+		t.Errorf("Result mismatch: %v", got)
+	}
+
+	schema["message"] = FieldSpec{Name: "Message", FType: FTText, Analyser: "nope"}
+
+	if _, err := NewIndex(schema); err == nil {
+		t.Error("Expected an error for an unknown analyser")
+	}
+}
+
+// Type a query against the given schema.
+func compileAnalysedIR(t *testing.T, schema Schema, query string) IRNode {
+	t.Helper()
+
+	lexed, _ := NewLexer().Lex(strings.NewReader(query))
+	parsed, _ := NewParser().Parse(lexed)
+
+	typed, diags := NewTyper(schema).Type(parsed)
+	if len(diags) > 0 {
+		t.Fatalf("Typer errors: %v", diags)
+	}
+
+	return typed
+}
+
+// ** Benchmarks:
+
+func BenchmarkIndexSearch(b *testing.B) {
+	idx, _ := makeTestIndex(b, 10000)
+	node := typeQuery(b, `message:"gamma" && level:[2 TO 4] && !facility:"kern"`)
+
+	b.ReportAllocs()
+
+	for range b.N {
+		if _, err := idx.Search(node); err != nil {
+			b.Fatalf("Unexpected error: %#v", err)
+		}
+	}
+}
+
+// * index_test.go ends here.
@@ -480,4 +480,12 @@ func (isn Instr) IsJump() bool {
 	return isn.Op == OpJump || isn.Op == OpJumpNZ || isn.Op == OpJumpZ
 }
 
+// Is the instruction a predicate of some kind?
+//
+// Predicates test the current field and store their result in the
+// accumulator.
+func (isn Instr) IsPredicate() bool {
+	return isn.Op >= OpStringEQ && isn.Op <= OpInCIDR
+}
+
 // * instruction.go ends here.
@@ -65,8 +65,8 @@ func (n IRFalse) Debug(params ...any) *debug.Debug {
 }
 
 // Emit opcode.
-func (n IRFalse) Emit(_ *Program, _, _ LabelID) {
-	// Nothing to emit.
+func (n IRFalse) Emit(program *Program, _, falseLabel LabelID) {
+	program.AppendJump(OpJump, falseLabel) // JMP false continuation
 }
 
 // * irfalse.go ends here.
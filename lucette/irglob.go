@@ -45,8 +45,9 @@ import "github.com/Asmodai/gohacks/debug"
 // ** Structure:
 
 type IRGlob struct {
-	Field string
-	Glob  string
+	Field    string
+	Glob     string
+	Analyser string // Name of the field's analyser, if any.
 }
 
 // ** Methods:
@@ -61,8 +62,12 @@ func (n IRGlob) Debug(params ...any) *debug.Debug {
 	dbg := debug.NewDebug("Glob")
 
 	dbg.Init(params...)
-	dbg.Printf("Field:  %s", n.Field)
-	dbg.Printf("Glob:   %q", n.Glob)
+	dbg.Printf("Field:    %s", n.Field)
+	dbg.Printf("Glob:     %q", n.Glob)
+
+	if n.Analyser != "" {
+		dbg.Printf("Analyser: %s", n.Analyser)
+	}
 
 	dbg.End()
 	dbg.Print()
@@ -75,6 +80,10 @@ func (n IRGlob) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	fidx := program.AddFieldConstant(n.Field)
 	sidx := program.AddStringConstant(n.Glob)
 
+	if n.Analyser != "" {
+		program.SetFieldAnalyser(n.Field, n.Analyser)
+	}
+
 	program.AppendIsn(OpLoadField, fidx)    // LDFLD fIdx
 	program.AppendIsn(OpGlob, sidx)         // GLB.S sIdx
 	program.AppendJump(OpJumpNZ, trueLabel) // JNZ true continuation
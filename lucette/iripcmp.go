@@ -54,6 +54,7 @@ type IRIPCmp struct {
 	Field string
 	Op    ComparatorKind
 	Value netip.Addr
+	Boost *float64
 }
 
 // ** Methods:
@@ -72,6 +73,10 @@ func (n IRIPCmp) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Op:     %s", ComparatorKindToString(n.Op))
 	dbg.Printf("Value:  %s", n.Value.String())
 
+	if n.Boost != nil {
+		dbg.Printf("Boost:  %g", *n.Boost)
+	}
+
 	dbg.End()
 	dbg.Print()
 
@@ -85,6 +90,7 @@ func (n IRIPCmp) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	nidx := program.AddIPConstant(n.Value)
 
 	program.AppendIsn(OpLoadField, fidx)    // LDFLD fIdx
+	program.AppendModifiers(nil, n.Boost)   // LDBST boost
 	program.AppendIsn(operator, nidx)       // <op> ipIdx
 	program.AppendJump(OpJumpNZ, trueLabel) // JNZ true continuation
 	program.AppendJump(OpJump, falseLabel)  // JMP false continuation
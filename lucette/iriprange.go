@@ -54,6 +54,7 @@ type IRIPRange struct {
 	Hi    netip.Addr
 	IncL  bool
 	IncH  bool
+	Boost *float64
 }
 
 // ** Methods:
@@ -79,6 +80,10 @@ func (n IRIPRange) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Inclusive Low:  %v", n.IncL)
 	dbg.Printf("Inclusive High: %v", n.IncH)
 
+	if n.Boost != nil {
+		dbg.Printf("Boost:          %g", *n.Boost)
+	}
+
 	dbg.End()
 	dbg.Print()
 
@@ -91,7 +96,8 @@ func (n IRIPRange) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	lidx := program.AddIPConstant(n.Lo)
 	hidx := program.AddIPConstant(n.Hi)
 
-	program.AppendIsn(OpLoadField, fidx) // LDFLD fIdx
+	program.AppendIsn(OpLoadField, fidx)  // LDFLD fIdx
+	program.AppendModifiers(nil, n.Boost) // LDBST boost
 
 	program.AppendIsn(OpIPRange, // RNG.IP lIdx hIdx IncL IncH
 		lidx,
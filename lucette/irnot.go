@@ -79,12 +79,15 @@ func (n IRNot) Emit(program *Program, trueLabel, falseLabel LabelID) {
 		return
 	}
 
-	cont := program.NewLabel()
-
-	n.Kid.Emit(program, trueLabel, cont)
-
-	program.AppendIsn(OpNot)
-	program.BindLabel(cont)
+	// Negation in continuation-passing style is simply a matter of
+	// swapping the continuations.
+	//
+	// A negated predicate that matches cannot contribute to a
+	// document's score, so polarity is tracked for the benefit of
+	// `AppendModifiers`.
+	program.negated = !program.negated
+	n.Kid.Emit(program, falseLabel, trueLabel)
+	program.negated = !program.negated
 }
 
 // * irnot.go ends here.
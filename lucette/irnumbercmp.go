@@ -54,6 +54,7 @@ type IRNumberCmp struct {
 	Field string
 	Op    ComparatorKind
 	Value float64
+	Boost *float64
 }
 
 // ** Methods:
@@ -72,6 +73,10 @@ func (n IRNumberCmp) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Op:     %s", ComparatorKindToString(n.Op))
 	dbg.Printf("Value:  %f", n.Value)
 
+	if n.Boost != nil {
+		dbg.Printf("Boost:  %g", *n.Boost)
+	}
+
 	dbg.End()
 	dbg.Print()
 
@@ -85,6 +90,7 @@ func (n IRNumberCmp) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	nidx := program.AddNumberConstant(n.Value)
 
 	program.AppendIsn(OpLoadField, fidx)    // LDFLD fIdx
+	program.AppendModifiers(nil, n.Boost)   // LDBST boost
 	program.AppendIsn(operator, nidx)       // <op> nIdx
 	program.AppendJump(OpJumpNZ, trueLabel) // JNZ true continuation
 	program.AppendJump(OpJump, falseLabel)  // JMP false continuation
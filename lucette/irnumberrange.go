@@ -53,6 +53,7 @@ type IRNumberRange struct {
 	Hi    *float64
 	IncL  bool
 	IncH  bool
+	Boost *float64
 }
 
 // ** Methods:
@@ -95,6 +96,10 @@ func (n IRNumberRange) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Inclusive Low:  %v", n.IncL)
 	dbg.Printf("Inclusive High: %v", n.IncH)
 
+	if n.Boost != nil {
+		dbg.Printf("Boost:          %g", *n.Boost)
+	}
+
 	dbg.End()
 	dbg.Print()
 
@@ -115,7 +120,8 @@ func (n IRNumberRange) Emit(program *Program, trueLabel, falseLabel LabelID) {
 		hidx = program.AddNumberConstant(*n.Hi)
 	}
 
-	program.AppendIsn(OpLoadField, fidx) // LDFLD fIdx
+	program.AppendIsn(OpLoadField, fidx)  // LDFLD fIdx
+	program.AppendModifiers(nil, n.Boost) // LDBST boost
 
 	program.AppendIsn(OpNumberRange, // RNG.N lIdx hIdx IncL IncH
 		lidx,
@@ -53,6 +53,7 @@ type IRPhrase struct {
 	Proximity int
 	Fuzz      *float64
 	Boost     *float64
+	Analyser  string // Name of the field's analyser, if any.
 }
 
 // ** Methods:
@@ -75,6 +76,10 @@ func (n IRPhrase) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Phrase:    %q", n.Phrase)
 	dbg.Printf("Proximity: %d", n.Proximity)
 
+	if n.Analyser != "" {
+		dbg.Printf("Analyser:  %s", n.Analyser)
+	}
+
 	if n.Fuzz != nil {
 		dbg.Printf("Fuzziness: %g", *n.Fuzz)
 	}
@@ -94,16 +99,12 @@ func (n IRPhrase) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	fidx := program.AddFieldConstant(n.Field)
 	sidx := program.AddStringConstant(n.Phrase)
 
-	program.AppendIsn(OpLoadField, fidx) // LDFLD fIdx
-
-	if n.Fuzz != nil {
-		program.AppendIsn(OpLoadFuzzy, *n.Fuzz) // LDFZY fuzz
-	}
-
-	if n.Boost != nil {
-		program.AppendIsn(OpLoadBoost, *n.Boost) // LDBST boost
+	if n.Analyser != "" {
+		program.SetFieldAnalyser(n.Field, n.Analyser)
 	}
 
+	program.AppendIsn(OpLoadField, fidx)           // LDFLD fIdx
+	program.AppendModifiers(n.Fuzz, n.Boost)       // LDFZY fuzz, LDBST boost
 	program.AppendIsn(OpPhrase, sidx, n.Proximity) // PHR.S sIdx prox
 	program.AppendIsn(OpJumpNZ, trueLabel)         // JNZ true cont.
 	program.AppendIsn(OpJump, falseLabel)          // JMP false cont.
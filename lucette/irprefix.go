@@ -45,8 +45,9 @@ import "github.com/Asmodai/gohacks/debug"
 // ** Structure:
 
 type IRPrefix struct {
-	Field  string
-	Prefix string
+	Field    string
+	Prefix   string
+	Analyser string // Name of the field's analyser, if any.
 }
 
 // ** Methods:
@@ -61,8 +62,12 @@ func (n IRPrefix) Debug(params ...any) *debug.Debug {
 	dbg := debug.NewDebug("Prefix")
 
 	dbg.Init(params...)
-	dbg.Printf("Field:  %s", n.Field)
-	dbg.Printf("Prefix: %q", n.Prefix)
+	dbg.Printf("Field:    %s", n.Field)
+	dbg.Printf("Prefix:   %q", n.Prefix)
+
+	if n.Analyser != "" {
+		dbg.Printf("Analyser: %s", n.Analyser)
+	}
 
 	dbg.End()
 	dbg.Print()
@@ -75,6 +80,10 @@ func (n IRPrefix) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	fidx := program.AddFieldConstant(n.Field)
 	sidx := program.AddStringConstant(n.Prefix)
 
+	if n.Analyser != "" {
+		program.SetFieldAnalyser(n.Field, n.Analyser)
+	}
+
 	program.AppendIsn(OpLoadField, fidx)    // LDFLD fIdx
 	program.AppendIsn(OpPrefix, sidx)       // PFX.S sIdx
 	program.AppendJump(OpJumpNZ, trueLabel) // JNZ true continuation
@@ -51,6 +51,7 @@ type IRRegex struct {
 	Field    string
 	Pattern  string
 	Compiled *regexp.Regexp
+	Boost    *float64
 }
 
 // ** Methods:
@@ -73,6 +74,10 @@ func (n IRRegex) Debug(params ...any) *debug.Debug {
 		dbg.Printf("Regex is compiled")
 	}
 
+	if n.Boost != nil {
+		dbg.Printf("Boost:   %g", *n.Boost)
+	}
+
 	dbg.End()
 	dbg.Print()
 
@@ -85,6 +90,7 @@ func (n IRRegex) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	ridx := program.AddRegexConstant(n.Compiled)
 
 	program.AppendIsn(OpLoadField, fidx)    // LDFLD fIdx
+	program.AppendModifiers(nil, n.Boost)   // LDBST boost
 	program.AppendIsn(OpRegex, ridx)        // REX.S rIdx
 	program.AppendJump(OpJumpNZ, trueLabel) // JNZ true continuation
 	program.AppendJump(OpJump, falseLabel)  // JMP false continuation
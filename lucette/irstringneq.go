@@ -45,8 +45,9 @@ import "github.com/Asmodai/gohacks/debug"
 // ** Structure:
 
 type IRStringNEQ struct {
-	Field string
-	Value string
+	Field    string
+	Value    string
+	Analyser string // Name of the field's analyser, if any.
 }
 
 // ** Methods:
@@ -61,8 +62,12 @@ func (n IRStringNEQ) Debug(params ...any) *debug.Debug {
 	dbg := debug.NewDebug("NEQ.S")
 
 	dbg.Init(params...)
-	dbg.Printf("Field: %s", n.Field)
-	dbg.Printf("Value: %q", n.Value)
+	dbg.Printf("Field:    %s", n.Field)
+	dbg.Printf("Value:    %q", n.Value)
+
+	if n.Analyser != "" {
+		dbg.Printf("Analyser: %s", n.Analyser)
+	}
 
 	dbg.End()
 	dbg.Print()
@@ -75,6 +80,10 @@ func (n IRStringNEQ) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	fidx := program.AddFieldConstant(n.Field)
 	sidx := program.AddStringConstant(n.Value)
 
+	if n.Analyser != "" {
+		program.SetFieldAnalyser(n.Field, n.Analyser)
+	}
+
 	program.AppendIsn(OpLoadField, fidx)    // LDFLD fIdx
 	program.AppendIsn(OpStringNEQ, sidx)    // NEQ.S sIdx
 	program.AppendJump(OpJumpNZ, trueLabel) // JNZ true continuation
@@ -54,6 +54,7 @@ type IRTimeCmp struct {
 	Field string
 	Op    ComparatorKind
 	Value int64
+	Boost *float64
 }
 
 // ** Methods:
@@ -72,6 +73,10 @@ func (n IRTimeCmp) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Op:     %s", ComparatorKindToString(n.Op))
 	dbg.Printf("Value:  %d", n.Value)
 
+	if n.Boost != nil {
+		dbg.Printf("Boost:  %g", *n.Boost)
+	}
+
 	dbg.End()
 	dbg.Print()
 
@@ -80,11 +85,12 @@ func (n IRTimeCmp) Debug(params ...any) *debug.Debug {
 
 // Generate opcode.
 func (n IRTimeCmp) Emit(program *Program, trueLabel, falseLabel LabelID) {
-	operator := GetNumberComparator(n.Op, OpTimeEQ)
+	operator := GetTimeComparator(n.Op, OpTimeEQ)
 	fidx := program.AddFieldConstant(n.Field)
 	tidx := program.AddTimeConstant(n.Value)
 
 	program.AppendIsn(OpLoadField, fidx)    // LDFLD fIdx
+	program.AppendModifiers(nil, n.Boost)   // LDBST boost
 	program.AppendIsn(operator, tidx)       // <op> tIdx
 	program.AppendJump(OpJumpNZ, trueLabel) // JNZ true continuation
 	program.AppendJump(OpJump, falseLabel)  // JMP false continuation
@@ -54,6 +54,7 @@ type IRTimeRange struct {
 	Hi    *int64
 	IncL  bool
 	IncH  bool
+	Boost *float64
 }
 
 // ** Methods:
@@ -96,6 +97,10 @@ func (n IRTimeRange) Debug(params ...any) *debug.Debug {
 	dbg.Printf("Inclusive Low:  %v", n.IncL)
 	dbg.Printf("Inclusive High: %v", n.IncH)
 
+	if n.Boost != nil {
+		dbg.Printf("Boost:          %g", *n.Boost)
+	}
+
 	dbg.End()
 	dbg.Print()
 
@@ -105,8 +110,8 @@ func (n IRTimeRange) Debug(params ...any) *debug.Debug {
 // Generate opcode.
 func (n IRTimeRange) Emit(program *Program, trueLabel, falseLabel LabelID) {
 	fidx := program.AddFieldConstant(n.Field)
-	lidx := 0
-	hidx := 0
+	lidx := -1
+	hidx := -1
 
 	if n.Lo != nil {
 		lidx = program.AddTimeConstant(*n.Lo)
@@ -116,7 +121,8 @@ func (n IRTimeRange) Emit(program *Program, trueLabel, falseLabel LabelID) {
 		hidx = program.AddTimeConstant(*n.Hi)
 	}
 
-	program.AppendIsn(OpLoadField, fidx) // LDFLD fIdx
+	program.AppendIsn(OpLoadField, fidx)  // LDFLD fIdx
+	program.AppendModifiers(nil, n.Boost) // LDBST boost
 
 	program.AppendIsn(OpTimeRange, // RNG.T lIdx hIdx IncL IncH
 		lidx,
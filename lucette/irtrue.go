@@ -65,8 +65,8 @@ func (n IRTrue) Debug(params ...any) *debug.Debug {
 }
 
 // Emit opcode.
-func (n IRTrue) Emit(_ *Program, _, _ LabelID) {
-	// Nothing to emit.
+func (n IRTrue) Emit(program *Program, trueLabel, _ LabelID) {
+	program.AppendJump(OpJump, trueLabel) // JMP true continuation
 }
 
 // * irtrue.go ends here.
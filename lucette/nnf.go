@@ -246,10 +246,16 @@ func (n *nnf) invertRangeIP(node *IRIPRange) IRNode {
 func (n *nnf) invertLeaf(node IRNode) IRNode {
 	switch val := node.(type) {
 	case *IRStringEQ:
-		return &IRStringNEQ{Field: val.Field, Value: val.Value}
+		return &IRStringNEQ{
+			Field:    val.Field,
+			Value:    val.Value,
+			Analyser: val.Analyser}
 
 	case *IRStringNEQ:
-		return &IRStringEQ{Field: val.Field, Value: val.Value}
+		return &IRStringEQ{
+			Field:    val.Field,
+			Value:    val.Value,
+			Analyser: val.Analyser}
 
 	case *IRNumberCmp:
 		return &IRNumberCmp{
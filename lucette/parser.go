@@ -30,6 +30,11 @@
 // SOFTWARE.
 
 // * Comments:
+//
+// Postfix modifiers are attached to the predicate they follow exactly as
+// written.  In particular, a `~` without a number marks the predicate as
+// fuzzy but leaves its `Fuzz` nil; the default edit distance is applied
+// when the predicate is typed, not by the parser.
 
 // * Package:
 
@@ -253,10 +258,13 @@ func attachBoost(node ASTNode, val float64) ASTNode {
 }
 
 // Attach a fuzz to the node.
+//
+// `val` is nil when `~` is not followed by a number.
 func attachFuzz(node ASTNode, val *float64) ASTNode {
 	if pred, ok := node.(*ASTPredicate); ok {
 		res := *pred
 
+		res.Fuzzy = true
 		res.Fuzz = val
 
 		return &res
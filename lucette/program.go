@@ -67,14 +67,16 @@ type LabelID int
 // ** Structure:
 
 type Program struct {
-	Fields      []string         // Field constants.
-	Strings     []string         // String constants.
-	Numbers     []float64        // Number constants.
-	Times       []int64          // Date/time constants.
-	IPs         []netip.Addr     // IP address constants.
-	Patterns    []*regexp.Regexp // Regular expression constants.
-	Code        []Instr          // Bytecode.
-	nextLabelID LabelID          // Label ID counter.
+	Fields      []string          // Field constants.
+	Strings     []string          // String constants.
+	Numbers     []float64         // Number constants.
+	Times       []int64           // Date/time constants.
+	IPs         []netip.Addr      // IP address constants.
+	Patterns    []*regexp.Regexp  // Regular expression constants.
+	Analysers   map[string]string // Analyser names, keyed by field.
+	Code        []Instr           // Bytecode.
+	nextLabelID LabelID           // Label ID counter.
+	negated     bool              // Is emission under a negation?
 }
 
 // ** Accessors:
@@ -259,6 +261,27 @@ func (p *Program) AppendIsn(opCode OpCode, args ...any) {
 	p.Code = append(p.Code, Instr{Op: opCode, Args: args})
 }
 
+// Append instructions to load the fuzzy and boost registers for the
+// predicate that follows.
+//
+// Either may be nil, in which case the register is left at its default.
+//
+// Predicates emitted under a negation are given a boost of zero, as a
+// document that matches one cannot be said to be relevant because of it.
+func (p *Program) AppendModifiers(fuzz, boost *float64) {
+	if fuzz != nil {
+		p.AppendIsn(OpLoadFuzzy, *fuzz)
+	}
+
+	switch {
+	case p.negated:
+		p.AppendIsn(OpLoadBoost, float64(0))
+
+	case boost != nil:
+		p.AppendIsn(OpLoadBoost, *boost)
+	}
+}
+
 // ** Constant methods:
 
 // Add a field name constant.
@@ -357,6 +380,15 @@ func (p *Program) AddRegexConstant(val *regexp.Regexp) int {
 	return len(p.Patterns) - 1
 }
 
+// Record the name of the analyser to apply to values of the given field.
+func (p *Program) SetFieldAnalyser(field, analyser string) {
+	if p.Analysers == nil {
+		p.Analysers = make(map[string]string)
+	}
+
+	p.Analysers[field] = analyser
+}
+
 // ** Generation methods:
 
 func (p *Program) Emit(irNode IRNode) {
@@ -428,13 +460,14 @@ func GetIPComparator(cmp ComparatorKind, def OpCode) OpCode {
 // Create a new program instance.
 func NewProgram() *Program {
 	return &Program{
-		Fields:   []string{},
-		Strings:  []string{},
-		Numbers:  []float64{},
-		Times:    []int64{},
-		IPs:      []netip.Addr{},
-		Patterns: []*regexp.Regexp{},
-		Code:     []Instr{}}
+		Fields:    []string{},
+		Strings:   []string{},
+		Numbers:   []float64{},
+		Times:     []int64{},
+		IPs:       []netip.Addr{},
+		Patterns:  []*regexp.Regexp{},
+		Analysers: map[string]string{},
+		Code:      []Instr{}}
 }
 
 // * program.go ends here.
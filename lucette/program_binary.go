@@ -0,0 +1,688 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// program_binary.go --- Binary serialisation of compiled programs.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A compiled program can be encoded to a versioned binary blob so that it
+// can be cached on disk or shipped to another process.  All integers are
+// little-endian.  The layout of version 1 is:
+//
+//   magic      u32      `LUCP'
+//   version    u32      Format version.
+//   fields     strings  Field constants.
+//   strings    strings  String constants.
+//   numbers    count, then one f64 per constant.
+//   times      count, then one varint per constant.
+//   ips        count, then a length-prefixed byte slice per constant.
+//   patterns   strings  Regular expression sources.
+//   analysers  count, then a field and analyser name per entry.
+//   code       count, then per instruction:
+//                uvarint opcode, uvarint operand count, and per operand a
+//                tag byte followed by a varint, f64, or u8.
+//   checksum   u32      CRC-32C of everything before it.
+//
+// Counts are uvarints, and strings are a count followed by a list of
+// length-prefixed byte strings.
+//
+// Decoding validates the whole program before returning it: every opcode
+// must be known, every operand must be of the right type, every constant
+// index must be within its pool, and every jump must land on an
+// instruction.  Regular expressions are recompiled.  A decoded program can
+// therefore be run without the VM tripping over malformed bytecode.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"hash/crc32"
+	"net/netip"
+	"regexp"
+	"sort"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	// Compiled program magic number.
+	//
+	// This is the string `LUCP` expressed as a little-endian integer.
+	ProgramMagic = 0x5043554C
+
+	// Version number of the compiled program format.
+	ProgramVersion = 1
+
+	// Initial capacity of the encoder's buffer.
+	initialEncoderSize = 256
+)
+
+const (
+	tagInt   uint8 = iota + 1 // Integer operand.
+	tagFloat                  // Floating-point operand.
+	tagBool                   // Boolean operand.
+)
+
+// Operand kinds used by the validator.
+const (
+	operandImmInt   operandKind = iota // Integer immediate.
+	operandImmFloat                    // Numeric immediate.
+	operandTarget                      // Jump target.
+	operandFlag                        // Boolean immediate.
+	operandConst                       // Constant pool index.
+	operandBound                       // Constant pool index or unbounded.
+)
+
+// * Variables:
+
+var (
+	//nolint:gochecknoglobals
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	//nolint:gochecknoglobals
+	poolFields = func(p *Program) int { return len(p.Fields) }
+
+	//nolint:gochecknoglobals
+	poolStrings = func(p *Program) int { return len(p.Strings) }
+
+	//nolint:gochecknoglobals
+	poolNumbers = func(p *Program) int { return len(p.Numbers) }
+
+	//nolint:gochecknoglobals
+	poolTimes = func(p *Program) int { return len(p.Times) }
+
+	//nolint:gochecknoglobals
+	poolIPs = func(p *Program) int { return len(p.IPs) }
+
+	//nolint:gochecknoglobals
+	poolPatterns = func(p *Program) int { return len(p.Patterns) }
+
+	// Map of `opcode -> operands` used to validate programs.
+	//
+	//nolint:gochecknoglobals
+	opOperands = map[OpCode][]operandSpec{
+		OpNoOp:        nil,
+		OpReturn:      nil,
+		OpJump:        {{kind: operandTarget}},
+		OpJumpZ:       {{kind: operandTarget}},
+		OpJumpNZ:      {{kind: operandTarget}},
+		OpNot:         nil,
+		OpLoadA:       {{kind: operandImmInt}},
+		OpLoadField:   {{operandConst, poolFields}},
+		OpLoadBoost:   {{kind: operandImmFloat}},
+		OpLoadFuzzy:   {{kind: operandImmFloat}},
+		OpStringEQ:    {{operandConst, poolStrings}},
+		OpStringNEQ:   {{operandConst, poolStrings}},
+		OpPrefix:      {{operandConst, poolStrings}},
+		OpGlob:        {{operandConst, poolStrings}},
+		OpRegex:       {{operandConst, poolPatterns}},
+		OpPhrase:      {{operandConst, poolStrings}, {kind: operandImmInt}},
+		OpAny:         nil,
+		OpNumberEQ:    {{operandConst, poolNumbers}},
+		OpNumberNEQ:   {{operandConst, poolNumbers}},
+		OpNumberLT:    {{operandConst, poolNumbers}},
+		OpNumberLTE:   {{operandConst, poolNumbers}},
+		OpNumberGT:    {{operandConst, poolNumbers}},
+		OpNumberGTE:   {{operandConst, poolNumbers}},
+		OpNumberRange: rangeOperands(poolNumbers),
+		OpTimeEQ:      {{operandConst, poolTimes}},
+		OpTimeNEQ:     {{operandConst, poolTimes}},
+		OpTimeLT:      {{operandConst, poolTimes}},
+		OpTimeLTE:     {{operandConst, poolTimes}},
+		OpTimeGT:      {{operandConst, poolTimes}},
+		OpTimeGTE:     {{operandConst, poolTimes}},
+		OpTimeRange:   rangeOperands(poolTimes),
+		OpIPEQ:        {{operandConst, poolIPs}},
+		OpIPNEQ:       {{operandConst, poolIPs}},
+		OpIPLT:        {{operandConst, poolIPs}},
+		OpIPLTE:       {{operandConst, poolIPs}},
+		OpIPGT:        {{operandConst, poolIPs}},
+		OpIPGTE:       {{operandConst, poolIPs}},
+		OpIPRange:     rangeOperands(poolIPs),
+		OpInCIDR:      {{operandConst, poolIPs}, {kind: operandImmInt}},
+	}
+)
+
+// * Code:
+
+// ** Types:
+
+// Kind of instruction operand.
+type operandKind int
+
+// Description of an instruction operand.
+type operandSpec struct {
+	kind operandKind          // Kind of operand.
+	pool func(p *Program) int // Size of the constant pool, if any.
+}
+
+// ** Validation methods:
+
+// Validate a single operand.
+func (p *Program) validateOperand(isn Instr, idx, pc int, spec operandSpec) error {
+	switch spec.kind {
+	case operandImmFloat:
+		_, err := operandFloat(isn, idx, pc)
+
+		return err
+
+	case operandFlag:
+		_, err := operandBool(isn, idx, pc)
+
+		return err
+	}
+
+	val, err := operandInt(isn, idx, pc)
+	if err != nil {
+		return err
+	}
+
+	switch spec.kind {
+	case operandTarget:
+		if val < 0 || val >= len(p.Code) {
+			return errors.WithMessagef(ErrBadOperand,
+				"jump target %d at %d",
+				val,
+				pc)
+		}
+
+	case operandConst, operandBound:
+		if spec.kind == operandBound && val < 0 {
+			return nil
+		}
+
+		if val < 0 || val >= spec.pool(p) {
+			return errors.WithMessagef(ErrBadConstant,
+				"%d at %d",
+				val,
+				pc)
+		}
+	}
+
+	return nil
+}
+
+// Validate the program's bytecode.
+//
+// Every opcode must be known and have the operands it expects, every
+// constant index must be within its pool, and every jump target must be an
+// instruction.  Labels must have been resolved.
+func (p *Program) Validate() error {
+	for pc, isn := range p.Code {
+		specs, found := opOperands[isn.Op]
+		if !found {
+			return errors.WithMessagef(ErrBadOpcode,
+				"%d at %d",
+				isn.Op,
+				pc)
+		}
+
+		if len(isn.Args) != len(specs) {
+			return errors.WithMessagef(ErrBadOperand,
+				"%s expects %d operands at %d",
+				opNames[isn.Op],
+				len(specs),
+				pc)
+		}
+
+		for idx, spec := range specs {
+			if err := p.validateOperand(isn, idx, pc, spec); err != nil {
+				return err
+			}
+		}
+	}
+
+	for idx, rex := range p.Patterns {
+		if rex == nil {
+			return errors.WithMessagef(ErrBadConstant,
+				"nil regex at %d",
+				idx)
+		}
+	}
+
+	return nil
+}
+
+// ** Encoding methods:
+
+// Encode a single operand.
+func encodeOperand(enc *encoder, arg any, pc int) error {
+	switch val := arg.(type) {
+	case int:
+		enc.u8(tagInt)
+		enc.varint(int64(val))
+
+	case float64:
+		enc.u8(tagFloat)
+		enc.f64(val)
+
+	case bool:
+		enc.u8(tagBool)
+
+		if val {
+			enc.u8(1)
+		} else {
+			enc.u8(0)
+		}
+
+	default:
+		return errors.WithMessagef(ErrBadOperand,
+			"cannot encode %T at %d",
+			arg,
+			pc)
+	}
+
+	return nil
+}
+
+// Encode the program to its binary form.
+//
+// Only complete programs, i.e. those whose labels have been resolved by
+// `Emit`, may be encoded.
+//
+//nolint:cyclop,funlen
+func (p *Program) MarshalBinary() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	enc := newEncoder()
+
+	enc.u32(ProgramMagic)
+	enc.u32(ProgramVersion)
+
+	for _, pool := range [][]string{p.Fields, p.Strings} {
+		enc.count(len(pool))
+
+		for _, str := range pool {
+			enc.str(str)
+		}
+	}
+
+	enc.count(len(p.Numbers))
+
+	for _, num := range p.Numbers {
+		enc.f64(num)
+	}
+
+	enc.count(len(p.Times))
+
+	for _, epoch := range p.Times {
+		enc.varint(epoch)
+	}
+
+	enc.count(len(p.IPs))
+
+	for _, addr := range p.IPs {
+		enc.bytes(addr.AsSlice())
+	}
+
+	enc.count(len(p.Patterns))
+
+	for _, rex := range p.Patterns {
+		enc.str(rex.String())
+	}
+
+	// Sort analysers so that equal programs encode identically.
+	fields := make([]string, 0, len(p.Analysers))
+
+	for field := range p.Analysers {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+	enc.count(len(fields))
+
+	for _, field := range fields {
+		enc.str(field)
+		enc.str(p.Analysers[field])
+	}
+
+	enc.count(len(p.Code))
+
+	for pc, isn := range p.Code {
+		enc.count(int(isn.Op))
+		enc.count(len(isn.Args))
+
+		for _, arg := range isn.Args {
+			if err := encodeOperand(&enc, arg, pc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	enc.u32(crc32.Checksum(enc.data, crcTable))
+
+	return enc.data, nil
+}
+
+// ** Decoding methods:
+
+// Decode a list of strings.
+func decodeStrings(dec *decoder, what string) ([]string, error) {
+	count, ok := dec.count()
+	if !ok {
+		return nil, malformed("%s count", what)
+	}
+
+	out := make([]string, count)
+
+	for idx := range count {
+		if out[idx], ok = dec.str(); !ok {
+			return nil, malformed("%s %d", what, idx)
+		}
+	}
+
+	return out, nil
+}
+
+// Decode the numeric constant pools.
+func decodeNumbers(dec *decoder, prog *Program) error {
+	count, ok := dec.count()
+	if !ok {
+		return malformed("number count")
+	}
+
+	prog.Numbers = make([]float64, count)
+
+	for idx := range count {
+		if prog.Numbers[idx], ok = dec.f64(); !ok {
+			return malformed("number %d", idx)
+		}
+	}
+
+	if count, ok = dec.count(); !ok {
+		return malformed("time count")
+	}
+
+	prog.Times = make([]int64, count)
+
+	for idx := range count {
+		if prog.Times[idx], ok = dec.varint(); !ok {
+			return malformed("time %d", idx)
+		}
+	}
+
+	return nil
+}
+
+// Decode the IP address constant pool.
+func decodeIPs(dec *decoder, prog *Program) error {
+	count, ok := dec.count()
+	if !ok {
+		return malformed("address count")
+	}
+
+	prog.IPs = make([]netip.Addr, count)
+
+	for idx := range count {
+		raw, ok := dec.bytes()
+		if !ok {
+			return malformed("address %d", idx)
+		}
+
+		// The zero address is used for unbounded range ends.
+		if len(raw) == 0 {
+			continue
+		}
+
+		if prog.IPs[idx], ok = netip.AddrFromSlice(raw); !ok {
+			return malformed("address %d", idx)
+		}
+	}
+
+	return nil
+}
+
+// Decode and recompile the regular expression constant pool.
+func decodePatterns(dec *decoder, prog *Program) error {
+	sources, err := decodeStrings(dec, "pattern")
+	if err != nil {
+		return err
+	}
+
+	prog.Patterns = make([]*regexp.Regexp, len(sources))
+
+	for idx, src := range sources {
+		rex, err := regexp.Compile(src)
+		if err != nil {
+			return errors.WithMessagef(ErrBadProgram,
+				"pattern %d: %v",
+				idx,
+				err)
+		}
+
+		prog.Patterns[idx] = rex
+	}
+
+	return nil
+}
+
+// Decode the analyser names.
+func decodeAnalysers(dec *decoder, prog *Program) error {
+	count, ok := dec.count()
+	if !ok {
+		return malformed("analyser count")
+	}
+
+	prog.Analysers = make(map[string]string, count)
+
+	for idx := range count {
+		field, ok := dec.str()
+		if !ok {
+			return malformed("analyser %d", idx)
+		}
+
+		name, ok := dec.str()
+		if !ok {
+			return malformed("analyser %d", idx)
+		}
+
+		prog.Analysers[field] = name
+	}
+
+	return nil
+}
+
+// Decode a single operand.
+func decodeOperand(dec *decoder, pc int) (any, error) {
+	tag, ok := dec.u8()
+	if !ok {
+		return nil, malformed("operand at %d", pc)
+	}
+
+	switch tag {
+	case tagInt:
+		val, ok := dec.varint()
+		if !ok {
+			return nil, malformed("operand at %d", pc)
+		}
+
+		return int(val), nil
+
+	case tagFloat:
+		val, ok := dec.f64()
+		if !ok {
+			return nil, malformed("operand at %d", pc)
+		}
+
+		return val, nil
+
+	case tagBool:
+		val, ok := dec.u8()
+		if !ok || val > 1 {
+			return nil, malformed("operand at %d", pc)
+		}
+
+		return val == 1, nil
+
+	default:
+		return nil, malformed("operand tag %d at %d", tag, pc)
+	}
+}
+
+// Decode the bytecode.
+func decodeCode(dec *decoder, prog *Program) error {
+	count, ok := dec.count()
+	if !ok {
+		return malformed("instruction count")
+	}
+
+	prog.Code = make([]Instr, count)
+
+	for pc := range count {
+		opc, ok := dec.uvarint()
+		if !ok || opc >= uint64(OpMaximum) {
+			return errors.WithMessagef(ErrBadOpcode, "at %d", pc)
+		}
+
+		argc, ok := dec.count()
+		if !ok {
+			return malformed("operand count at %d", pc)
+		}
+
+		isn := Instr{Op: OpCode(opc), Args: make([]any, argc)}
+
+		for idx := range argc {
+			arg, err := decodeOperand(dec, pc)
+			if err != nil {
+				return err
+			}
+
+			isn.Args[idx] = arg
+		}
+
+		prog.Code[pc] = isn
+	}
+
+	return nil
+}
+
+// Decode the program from its binary form.
+//
+// The program is only modified if the whole of the data decodes and
+// validates successfully.
+//
+//nolint:cyclop
+func (p *Program) UnmarshalBinary(data []byte) error {
+	if len(data) < u32Size*3 {
+		return malformed("truncated")
+	}
+
+	body := data[:len(data)-u32Size]
+	tail := newDecoder(data[len(data)-u32Size:])
+
+	if sum, _ := tail.u32(); sum != crc32.Checksum(body, crcTable) {
+		return errors.WithStack(ErrProgramChecksum)
+	}
+
+	dec := newDecoder(body)
+
+	if magic, _ := dec.u32(); magic != ProgramMagic {
+		return malformed("bad magic number")
+	}
+
+	if version, _ := dec.u32(); version != ProgramVersion {
+		return errors.WithMessagef(ErrProgramVersion, "%d", version)
+	}
+
+	var (
+		prog = NewProgram()
+		err  error
+	)
+
+	if prog.Fields, err = decodeStrings(&dec, "field"); err != nil {
+		return err
+	}
+
+	if prog.Strings, err = decodeStrings(&dec, "string"); err != nil {
+		return err
+	}
+
+	for _, fn := range []func(*decoder, *Program) error{
+		decodeNumbers,
+		decodeIPs,
+		decodePatterns,
+		decodeAnalysers,
+		decodeCode,
+	} {
+		if err := fn(&dec, prog); err != nil {
+			return err
+		}
+	}
+
+	if dec.remaining() != 0 {
+		return malformed("%d trailing bytes", dec.remaining())
+	}
+
+	if err := prog.Validate(); err != nil {
+		return err
+	}
+
+	*p = *prog
+
+	return nil
+}
+
+// ** Functions:
+
+// Decode a compiled program from its binary form.
+func DecodeProgram(data []byte) (*Program, error) {
+	prog := &Program{}
+
+	if err := prog.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return prog, nil
+}
+
+// Return a range instruction's operands for the given constant pool.
+func rangeOperands(pool func(*Program) int) []operandSpec {
+	return []operandSpec{
+		{operandBound, pool},
+		{operandBound, pool},
+		{kind: operandFlag},
+		{kind: operandFlag},
+	}
+}
+
+// Return an error describing malformed program data.
+func malformed(msg string, args ...any) error {
+	return errors.WithMessagef(ErrBadProgram, msg, args...)
+}
+
+// * program_binary.go ends here.
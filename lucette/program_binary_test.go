@@ -0,0 +1,300 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// program_binary_test.go --- Binary serialisation tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"net/netip"
+	"testing"
+	"time"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Utilities:
+
+// Recompute the checksum of tampered program data.
+func reseal(data []byte) []byte {
+	body := data[:len(data)-u32Size]
+
+	binary.LittleEndian.PutUint32(data[len(body):],
+		crc32.Checksum(body, crcTable))
+
+	return data
+}
+
+// Encode a compiled query.
+func encodeQuery(t *testing.T, query string) []byte {
+	t.Helper()
+
+	data, err := compileQuery(t, query).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	return data
+}
+
+// ** Tests:
+
+func TestProgramBinaryRoundTrip(t *testing.T) {
+	doc := MapDocument{
+		"Timestamp": time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		"Level":     42,
+		"Percent":   12.5,
+		"Source":    "192.168.1.20",
+		"Message":   "hello brave new world",
+	}
+
+	queries := []string{
+		`level:42`,
+		`level:[1 TO 42} || percent:>12`,
+		`message:"brave new"^2 && !message:/^bye/`,
+		`message:"wrld"~1`,
+		`source:["192.168.1.1" TO *] && timestamp:>"2025-01-01T00:00:00Z"`,
+		`source:"::1" || message:"h*o"`,
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			orig := compileQuery(t, query)
+
+			data, err := orig.MarshalBinary()
+			if err != nil {
+				t.Fatalf("Marshal error: %#v", err)
+			}
+
+			decoded, err := DecodeProgram(data)
+			if err != nil {
+				t.Fatalf("Decode error: %#v", err)
+			}
+
+			again, err := decoded.MarshalBinary()
+			if err != nil {
+				t.Fatalf("Re-marshal error: %#v", err)
+			}
+
+			if !bytes.Equal(data, again) {
+				t.Error("Re-encoded program differs")
+			}
+
+			want, err := orig.Evaluate(doc)
+			if err != nil {
+				t.Fatalf("Evaluate error: %#v", err)
+			}
+
+			got, err := decoded.Evaluate(doc)
+			if err != nil {
+				t.Fatalf("Evaluate error: %#v", err)
+			}
+
+			if got != want {
+				t.Errorf("Result mismatch: %#v != %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestProgramBinaryAnalysers(t *testing.T) {
+	orig := compileAnalysed(t, AnalyserEnglish, `message:"queries"`)
+
+	data, err := orig.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Marshal error: %#v", err)
+	}
+
+	decoded, err := DecodeProgram(data)
+	if err != nil {
+		t.Fatalf("Decode error: %#v", err)
+	}
+
+	if decoded.Analysers["Message"] != AnalyserEnglish {
+		t.Errorf("Analyser mismatch: %v", decoded.Analysers)
+	}
+
+	if got, err := decoded.Run(MapDocument{"Message": "Query"}); err != nil || !got {
+		t.Errorf("Unexpected result: %v, %v", got, err)
+	}
+}
+
+//nolint:funlen
+func TestProgramBinaryErrors(t *testing.T) {
+	data := encodeQuery(t, `message:/^hel+o/ || level:42`)
+
+	t.Run("Truncated", func(t *testing.T) {
+		for size := range len(data) {
+			if _, err := DecodeProgram(data[:size]); err == nil {
+				t.Fatalf("Decoded truncated program of %d bytes", size)
+			}
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		bad := bytes.Clone(data)
+		bad[len(bad)/2] ^= 0xff
+
+		_, err := DecodeProgram(bad)
+		if !errors.Is(err, ErrProgramChecksum) {
+			t.Errorf("Error mismatch: %#v", err)
+		}
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		bad := bytes.Clone(data)
+		binary.LittleEndian.PutUint32(bad[u32Size:], ProgramVersion+1)
+
+		_, err := DecodeProgram(reseal(bad))
+		if !errors.Is(err, ErrProgramVersion) {
+			t.Errorf("Error mismatch: %#v", err)
+		}
+	})
+
+	t.Run("Magic", func(t *testing.T) {
+		bad := bytes.Clone(data)
+		bad[0] = 'X'
+
+		_, err := DecodeProgram(reseal(bad))
+		if !errors.Is(err, ErrBadProgram) {
+			t.Errorf("Error mismatch: %#v", err)
+		}
+	})
+
+	t.Run("Regex", func(t *testing.T) {
+		bad := bytes.Clone(data)
+		idx := bytes.Index(bad, []byte("^hel+o"))
+		bad[idx+4] = '('
+
+		_, err := DecodeProgram(reseal(bad))
+		if !errors.Is(err, ErrBadProgram) {
+			t.Errorf("Error mismatch: %#v", err)
+		}
+	})
+
+	t.Run("Trailing", func(t *testing.T) {
+		bad := append(bytes.Clone(data[:len(data)-u32Size]), 0, 0, 0, 0, 0)
+
+		_, err := DecodeProgram(reseal(bad))
+		if !errors.Is(err, ErrBadProgram) {
+			t.Errorf("Error mismatch: %#v", err)
+		}
+	})
+
+	t.Run("Unresolved", func(t *testing.T) {
+		prog := NewProgram()
+		prog.AppendJump(OpJump, prog.NewLabel())
+
+		if _, err := prog.MarshalBinary(); !errors.Is(err, ErrBadOperand) {
+			t.Errorf("Error mismatch: %#v", err)
+		}
+	})
+}
+
+func TestProgramValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		code []Instr
+		err  error
+	}{
+		{"Bad opcode", []Instr{{Op: OpMaximum}}, ErrBadOpcode},
+		{"Label", []Instr{{Op: OpLabel, Args: []any{LabelID(0)}}}, ErrBadOpcode},
+		{"Operand count", []Instr{{Op: OpReturn, Args: []any{1}}}, ErrBadOperand},
+		{"Operand type", []Instr{{Op: OpLoadA, Args: []any{true}}}, ErrBadOperand},
+		{"Jump target", []Instr{{Op: OpJump, Args: []any{1}}}, ErrBadOperand},
+		{"Field", []Instr{{Op: OpLoadField, Args: []any{1}}}, ErrBadConstant},
+		{"Number", []Instr{{Op: OpNumberEQ, Args: []any{0}}}, ErrBadConstant},
+		{"Range", []Instr{{Op: OpIPRange, Args: []any{0, -1, true, true}}}, nil},
+		{"Range high", []Instr{{Op: OpIPRange, Args: []any{0, 1, true, true}}}, ErrBadConstant},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prog := NewProgram()
+			prog.AddFieldConstant("Field")
+			prog.AddIPConstant(netip.MustParseAddr("10.0.0.1"))
+			prog.Code = test.code
+
+			err := prog.Validate()
+			if test.err == nil && err != nil {
+				t.Errorf("Unexpected error: %#v", err)
+			}
+
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("Error mismatch: %#v != %#v", err, test.err)
+			}
+		})
+	}
+}
+
+// ** Fuzzing:
+
+func FuzzDecodeProgram(f *testing.F) {
+	f.Add([]byte{})
+
+	for _, query := range []string{`level:42`, `message:"a" && !source:"::1"`} {
+		prog := compileQuery(f, query)
+
+		data, err := prog.MarshalBinary()
+		if err != nil {
+			f.Fatalf("Unexpected error: %#v", err)
+		}
+
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		prog, err := DecodeProgram(data)
+		if err != nil {
+			return
+		}
+
+		// Anything that decodes must run without tripping the VM's
+		// operand checks.
+		if _, err := prog.Run(MapDocument{}); err != nil &&
+			!errors.Is(err, ErrRunaway) &&
+			!errors.Is(err, ErrPCOutOfRange) &&
+			!errors.Is(err, ErrUnknownAnalyser) {
+			t.Errorf("Unexpected error: %#v", err)
+		}
+	})
+}
+
+// * program_binary_test.go ends here.
@@ -0,0 +1,220 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// program_codec.go --- Binary encoder and decoder primitives.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// * Constants:
+
+const (
+	u8Size  = 1
+	u32Size = 4
+	u64Size = 8
+)
+
+// * Code:
+
+// ** Encoder:
+
+type encoder struct {
+	data []byte
+}
+
+// *** Methods:
+
+func (e *encoder) u8(val uint8) {
+	e.data = append(e.data, val)
+}
+
+func (e *encoder) u32(val uint32) {
+	e.data = binary.LittleEndian.AppendUint32(e.data, val)
+}
+
+func (e *encoder) u64(val uint64) {
+	e.data = binary.LittleEndian.AppendUint64(e.data, val)
+}
+
+func (e *encoder) uvarint(val uint64) {
+	e.data = binary.AppendUvarint(e.data, val)
+}
+
+func (e *encoder) varint(val int64) {
+	e.data = binary.AppendVarint(e.data, val)
+}
+
+func (e *encoder) f64(val float64) {
+	e.u64(math.Float64bits(val))
+}
+
+func (e *encoder) count(val int) {
+	e.uvarint(uint64(val)) //nolint:gosec
+}
+
+func (e *encoder) bytes(val []byte) {
+	e.count(len(val))
+	e.data = append(e.data, val...)
+}
+
+func (e *encoder) str(val string) {
+	e.count(len(val))
+	e.data = append(e.data, val...)
+}
+
+// *** Functions:
+
+func newEncoder() encoder {
+	return encoder{data: make([]byte, 0, initialEncoderSize)}
+}
+
+// ** Decoder:
+
+type decoder struct {
+	data   []byte
+	offset int
+}
+
+// *** Methods:
+
+func (d *decoder) remaining() int {
+	return len(d.data) - d.offset
+}
+
+func (d *decoder) u8() (uint8, bool) {
+	if d.remaining() < u8Size {
+		return 0, false
+	}
+
+	val := d.data[d.offset]
+	d.offset += u8Size
+
+	return val, true
+}
+
+func (d *decoder) u32() (uint32, bool) {
+	if d.remaining() < u32Size {
+		return 0, false
+	}
+
+	val := binary.LittleEndian.Uint32(d.data[d.offset : d.offset+u32Size])
+	d.offset += u32Size
+
+	return val, true
+}
+
+func (d *decoder) u64() (uint64, bool) {
+	if d.remaining() < u64Size {
+		return 0, false
+	}
+
+	val := binary.LittleEndian.Uint64(d.data[d.offset : d.offset+u64Size])
+	d.offset += u64Size
+
+	return val, true
+}
+
+func (d *decoder) uvarint() (uint64, bool) {
+	val, size := binary.Uvarint(d.data[d.offset:])
+	if size <= 0 {
+		return 0, false
+	}
+
+	d.offset += size
+
+	return val, true
+}
+
+func (d *decoder) varint() (int64, bool) {
+	val, size := binary.Varint(d.data[d.offset:])
+	if size <= 0 {
+		return 0, false
+	}
+
+	d.offset += size
+
+	return val, true
+}
+
+func (d *decoder) f64() (float64, bool) {
+	bits, ok := d.u64()
+
+	return math.Float64frombits(bits), ok
+}
+
+// Decode an element count.
+//
+// Every element occupies at least one byte, so a count that exceeds the
+// remaining data is rejected before anything is allocated for it.
+func (d *decoder) count() (int, bool) {
+	val, ok := d.uvarint()
+	if !ok || val > uint64(d.remaining()) { //nolint:gosec
+		return 0, false
+	}
+
+	return int(val), true //nolint:gosec
+}
+
+func (d *decoder) bytes() ([]byte, bool) {
+	length, ok := d.count()
+	if !ok {
+		return nil, false
+	}
+
+	val := d.data[d.offset : d.offset+length]
+	d.offset += length
+
+	return val, true
+}
+
+func (d *decoder) str() (string, bool) {
+	val, ok := d.bytes()
+
+	return string(val), ok
+}
+
+// *** Functions:
+
+func newDecoder(data []byte) decoder {
+	return decoder{
+		data:   data,
+		offset: 0}
+}
+
+// * program_codec.go ends here.
@@ -50,7 +50,11 @@ func (p *Program) Peephole() {
 		switch isn.Op {
 		case OpLabel:
 			// Keep labels as-is.
+			//
+			// A label can be reached by a jump from anywhere, so
+			// the field register cannot be assumed past it.
 			newCode = append(newCode, isn)
+			lastfid = -1
 			idx++
 
 			continue
@@ -80,7 +84,7 @@ func (p *Program) Peephole() {
 			low, high := isn.Args[0].(int), isn.Args[1].(int)
 			incl, inch := isn.Args[2].(bool), isn.Args[3].(bool)
 
-			if low == high && incl && inch {
+			if low >= 0 && low == high && incl && inch {
 				isn = Instr{Op: OpNumberEQ, Args: []any{low}}
 			}
 
@@ -89,7 +93,7 @@ func (p *Program) Peephole() {
 			low, high := isn.Args[0].(int), isn.Args[1].(int)
 			incl, inch := isn.Args[2].(bool), isn.Args[3].(bool)
 
-			if low == high && incl && inch {
+			if low >= 0 && low == high && incl && inch {
 				isn = Instr{Op: OpTimeEQ, Args: []any{low}}
 			}
 
@@ -98,7 +102,8 @@ func (p *Program) Peephole() {
 			low, high := isn.Args[0].(int), isn.Args[1].(int)
 			incl, inch := isn.Args[2].(bool), isn.Args[3].(bool)
 
-			if low == high && incl && inch {
+			// The zero address denotes an unbounded end.
+			if low == high && incl && inch && p.IPs[low].IsValid() {
 				isn = Instr{Op: OpIPEQ, Args: []any{low}}
 			}
 
@@ -0,0 +1,584 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// sql.go --- Translate IR trees into SQL WHERE clauses.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// The translator turns a typed IR tree into a parameterised WHERE clause.
+// Schema fields are mapped onto column expressions, which are copied into
+// the clause verbatim.
+//
+// Not everything can be pushed down to the database.  Fuzzy phrases,
+// phrases and terms on fields whose analyser rewrites terms, fields
+// without a column, and nodes with no SQL equivalent are replaced with a
+// constant and reported as diagnostics.  The constant is chosen by
+// polarity (`TRUE' where the node is asserted, `FALSE' where it is
+// negated) so that the clause only ever widens the result set.
+//
+// Phrases are matched by the virtual machine against runs of words, which
+// `LIKE' cannot express, so they are widened in the same manner.
+//
+// Whenever widening has happened, `SQLWhere.Exact' is false and rows
+// returned by the query should be re-checked with the compiled program.
+//
+// SQL's three-valued logic would let `NOT' turn a NULL column into a
+// non-match, whereas the virtual machine treats missing fields as false.
+// Negations are therefore wrapped in `COALESCE'.
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// * Constants:
+
+const (
+	sqlTrue      = "TRUE"
+	sqlFalse     = "FALSE"
+	sqlEscape    = '!'
+	sqlLikeTail  = " ESCAPE '!'"
+	sqlWidenHint = "clause widened; re-check rows with the program"
+)
+
+// * Variables:
+
+//nolint:gochecknoglobals
+var (
+	// Map of `ComparatorKind -> SQL operator`.
+	sqlOperators = map[ComparatorKind]string{
+		ComparatorLT:  "<",
+		ComparatorLTE: "<=",
+		ComparatorGT:  ">",
+		ComparatorGTE: ">=",
+		ComparatorEQ:  "=",
+	}
+)
+
+// * Code:
+
+// ** Interface:
+
+// Placeholder rebinder.
+//
+// `database.Database` satisfies this interface.
+type SQLRebinder interface {
+	// Rebind `?' placeholders to the backend's placeholder style.
+	Rebind(string) string
+}
+
+// ** Types:
+
+// A translated WHERE clause.
+type SQLWhere struct {
+	Clause string // Clause, without the `WHERE' keyword.
+	Args   []any  // Bind arguments in placeholder order.
+	Exact  bool   // Does the clause select exactly the matching rows?
+}
+
+// SQL WHERE clause translator.
+type SQLTranslator struct {
+	dialect  SQLDialect
+	rebinder SQLRebinder
+	columns  map[string]string
+}
+
+// Translation state.
+type sqlBuilder struct {
+	trans *SQLTranslator
+	args  []any
+	exact bool
+	diags []Diagnostic
+}
+
+// ** Methods:
+
+// Translate the given IR tree into a WHERE clause.
+//
+// Diagnostics are returned for every node that could not be pushed down.
+// They do not prevent translation.
+func (t *SQLTranslator) Translate(node IRNode) (*SQLWhere, []Diagnostic) {
+	bld := &sqlBuilder{trans: t, exact: true}
+	clause := bld.node(node, true)
+
+	if t.rebinder != nil {
+		clause = t.rebinder.Rebind(clause)
+	}
+
+	return &SQLWhere{
+		Clause: clause,
+		Args:   bld.args,
+		Exact:  bld.exact,
+	}, bld.diags
+}
+
+// Add a bind argument, returning its placeholder.
+func (b *sqlBuilder) bind(val any) string {
+	b.args = append(b.args, val)
+
+	return "?"
+}
+
+// Replace a node that cannot be pushed down.
+func (b *sqlBuilder) widen(positive bool, msg string, args ...any) string {
+	b.exact = false
+	b.diags = append(b.diags,
+		NewDiagnosticHint(fmt.Sprintf(msg, args...), sqlWidenHint, nil))
+
+	if positive {
+		return sqlTrue
+	}
+
+	return sqlFalse
+}
+
+// Look up the column for a field.
+func (b *sqlBuilder) column(field string, positive bool) (string, string, bool) {
+	col, found := b.trans.columns[field]
+	if !found || col == "" {
+		return "", b.widen(positive, "no column for field %q", field), false
+	}
+
+	return col, "", true
+}
+
+// Negate an expression, treating NULL as false.
+func (b *sqlBuilder) not(expr string) string {
+	switch expr {
+	case sqlTrue:
+		return sqlFalse
+
+	case sqlFalse:
+		return sqlTrue
+	}
+
+	return "NOT COALESCE(" + expr + ", " + sqlFalse + ")"
+}
+
+// Join the translated kids of a junction.
+//
+// `empty` is the junction's identity; constant kids equal to it are
+// dropped, and any other constant kid absorbs the whole junction.
+func (b *sqlBuilder) junction(kids []IRNode, join, empty string, positive bool) string {
+	absorb := sqlFalse
+	if empty == sqlFalse {
+		absorb = sqlTrue
+	}
+
+	mark := len(b.args)
+	absorbed := false
+	parts := make([]string, 0, len(kids))
+
+	// Keep going after absorption so every kid is diagnosed.
+	for idx := range kids {
+		part := b.node(kids[idx], positive)
+
+		switch part {
+		case empty:
+			continue
+
+		case absorb:
+			absorbed = true
+		}
+
+		parts = append(parts, part)
+	}
+
+	if absorbed {
+		// The absorbed kids' placeholders are gone, so are their
+		// arguments.
+		b.args = b.args[:mark]
+
+		return absorb
+	}
+
+	switch len(parts) {
+	case 0:
+		return empty
+
+	case 1:
+		return parts[0]
+	}
+
+	return "(" + strings.Join(parts, " "+join+" ") + ")"
+}
+
+// Compare a column against a placeholder.
+func (b *sqlBuilder) compare(col string, op ComparatorKind, rhs string) string {
+	if op == ComparatorNEQ {
+		return b.not(col + " = " + rhs)
+	}
+
+	return col + " " + sqlOperators[op] + " " + rhs
+}
+
+// Bound a column by optional placeholders.
+//
+// An empty placeholder leaves that end of the range unbounded.
+func (b *sqlBuilder) between(col, low, high string, incL, incH bool) string {
+	parts := make([]string, 0, 2) //nolint:mnd
+
+	if low != "" {
+		op := ComparatorGT
+		if incL {
+			op = ComparatorGTE
+		}
+
+		parts = append(parts, b.compare(col, op, low))
+	}
+
+	if high != "" {
+		op := ComparatorLT
+		if incH {
+			op = ComparatorLTE
+		}
+
+		parts = append(parts, b.compare(col, op, high))
+	}
+
+	switch len(parts) {
+	case 0:
+		return col + " IS NOT NULL"
+
+	case 1:
+		return parts[0]
+	}
+
+	return "(" + strings.Join(parts, " AND ") + ")"
+}
+
+// Match a column against a `LIKE' pattern.
+func (b *sqlBuilder) like(col, pattern string) string {
+	return col + " LIKE " + b.bind(pattern) + sqlLikeTail
+}
+
+// Translate a phrase.
+//
+// Asserted phrases match any value containing the phrase's words in order;
+// negated phrases only match values equal to the whole phrase.  Both are
+// approximations, so the clause is never exact.
+func (b *sqlBuilder) phrase(node *IRPhrase, positive bool) string {
+	col, widened, ok := b.column(node.Field, positive)
+	if !ok {
+		return widened
+	}
+
+	if node.Fuzz != nil || node.Proximity != 0 {
+		return b.widen(positive, "fuzzy phrase on field %q", node.Field)
+	}
+
+	if !sqlComparable(node.Analyser) {
+		return b.widen(positive,
+			"phrase on field %q uses the %q analyser",
+			node.Field,
+			node.Analyser)
+	}
+
+	b.exact = false
+	wildcard := hasWildcard(node.Phrase)
+
+	if !positive {
+		if wildcard {
+			return b.like(col, globToLike(node.Phrase))
+		}
+
+		return col + " = " + b.bind(node.Phrase)
+	}
+
+	words := strings.Fields(node.Phrase)
+
+	for idx := range words {
+		if wildcard {
+			words[idx] = globToLike(words[idx])
+		} else {
+			words[idx] = escapeLike(words[idx])
+		}
+	}
+
+	return b.like(col, "%"+strings.Join(words, "%")+"%")
+}
+
+// Translate an IP address comparison.
+func (b *sqlBuilder) ipCompare(node *IRIPCmp, positive bool) string {
+	col, widened, ok := b.column(node.Field, positive)
+	if !ok {
+		return widened
+	}
+
+	if !node.Value.IsValid() {
+		return b.widen(positive, "invalid address for field %q", node.Field)
+	}
+
+	dialect := b.trans.dialect
+
+	return b.compare(dialect.IP(col),
+		node.Op,
+		dialect.IP(b.bind(node.Value.Unmap().String())))
+}
+
+// Translate an IP address range.
+//
+// The zero address denotes an unbounded end.
+func (b *sqlBuilder) ipRange(node *IRIPRange, positive bool) string {
+	col, widened, ok := b.column(node.Field, positive)
+	if !ok {
+		return widened
+	}
+
+	dialect := b.trans.dialect
+	bound := func(addr netip.Addr) string {
+		if !addr.IsValid() {
+			return ""
+		}
+
+		return dialect.IP(b.bind(addr.Unmap().String()))
+	}
+
+	low := bound(node.Lo)
+	high := bound(node.Hi)
+
+	return b.between(dialect.IP(col), low, high, node.IncL, node.IncH)
+}
+
+// Translate a leaf node that compares a single column.
+//
+//nolint:cyclop,funlen
+func (b *sqlBuilder) leaf(node IRNode, positive bool) string {
+	var field, analyser string
+
+	switch val := node.(type) {
+	case *IRAny:
+		field = val.Field
+	case *IRStringEQ:
+		field, analyser = val.Field, val.Analyser
+	case *IRStringNEQ:
+		field, analyser = val.Field, val.Analyser
+	case *IRPrefix:
+		field, analyser = val.Field, val.Analyser
+	case *IRGlob:
+		field, analyser = val.Field, val.Analyser
+	case *IRRegex:
+		field = val.Field
+	case *IRNumberCmp:
+		field = val.Field
+	case *IRNumberRange:
+		field = val.Field
+	case *IRTimeCmp:
+		field = val.Field
+	case *IRTimeRange:
+		field = val.Field
+	default:
+		return b.widen(positive, "%T has no SQL equivalent", node)
+	}
+
+	col, widened, ok := b.column(field, positive)
+	if !ok {
+		return widened
+	}
+
+	// Analysed literals are compared against analysed values, which the
+	// database does not have.
+	if !sqlComparable(analyser) {
+		return b.widen(positive,
+			"term on field %q uses the %q analyser",
+			field,
+			analyser)
+	}
+
+	switch val := node.(type) {
+	case *IRAny:
+		return col + " IS NOT NULL"
+
+	case *IRStringEQ:
+		return col + " = " + b.bind(val.Value)
+
+	case *IRStringNEQ:
+		return b.not(col + " = " + b.bind(val.Value))
+
+	case *IRPrefix:
+		return b.like(col, escapeLike(val.Prefix)+"%")
+
+	case *IRGlob:
+		return b.like(col, globToLike(val.Glob))
+
+	case *IRRegex:
+		return b.trans.dialect.Regex(col, b.bind(val.Pattern))
+
+	case *IRNumberCmp:
+		return b.compare(col, val.Op, b.bind(val.Value))
+
+	case *IRNumberRange:
+		var low, high string
+
+		if val.Lo != nil {
+			low = b.bind(*val.Lo)
+		}
+
+		if val.Hi != nil {
+			high = b.bind(*val.Hi)
+		}
+
+		return b.between(col, low, high, val.IncL, val.IncH)
+
+	case *IRTimeCmp:
+		return b.compare(col, val.Op, b.bind(sqlTime(val.Value)))
+
+	case *IRTimeRange:
+		var low, high string
+
+		if val.Lo != nil {
+			low = b.bind(sqlTime(*val.Lo))
+		}
+
+		if val.Hi != nil {
+			high = b.bind(sqlTime(*val.Hi))
+		}
+
+		return b.between(col, low, high, val.IncL, val.IncH)
+	}
+
+	return b.widen(positive, "%T has no SQL equivalent", node)
+}
+
+// Translate a node.
+//
+// `positive` is false when the node sits under an odd number of negations.
+func (b *sqlBuilder) node(node IRNode, positive bool) string {
+	switch val := node.(type) {
+	case *IRTrue:
+		return sqlTrue
+
+	case *IRFalse:
+		return sqlFalse
+
+	case *IRAnd:
+		return b.junction(val.Kids, "AND", sqlTrue, positive)
+
+	case *IROr:
+		return b.junction(val.Kids, "OR", sqlFalse, positive)
+
+	case *IRNot:
+		return b.not(b.node(val.Kid, !positive))
+
+	case *IRPhrase:
+		return b.phrase(val, positive)
+
+	case *IRIPCmp:
+		return b.ipCompare(val, positive)
+
+	case *IRIPRange:
+		return b.ipRange(val, positive)
+	}
+
+	return b.leaf(node, positive)
+}
+
+// ** Functions:
+
+// Can literals for a field with the given analyser be compared directly
+// against stored column values?
+//
+// Only analysers that leave the case and spelling of terms alone qualify.
+func sqlComparable(analyser string) bool {
+	switch analyser {
+	case "", AnalyserKeyword, AnalyserWhitespace:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Escape `LIKE' metacharacters in a literal string.
+func escapeLike(str string) string {
+	var sbld strings.Builder
+
+	for _, chr := range str {
+		switch chr {
+		case '%', '_', sqlEscape:
+			sbld.WriteRune(sqlEscape)
+		}
+
+		sbld.WriteRune(chr)
+	}
+
+	return sbld.String()
+}
+
+// Convert a glob pattern into a `LIKE' pattern.
+func globToLike(glob string) string {
+	var sbld strings.Builder
+
+	for _, chr := range glob {
+		switch chr {
+		case globAnyRunes:
+			sbld.WriteRune('%')
+
+		case globAnyRune:
+			sbld.WriteRune('_')
+
+		case '%', '_', sqlEscape:
+			sbld.WriteRune(sqlEscape)
+			sbld.WriteRune(chr)
+
+		default:
+			sbld.WriteRune(chr)
+		}
+	}
+
+	return sbld.String()
+}
+
+// Convert a time from the IR into a bind argument.
+func sqlTime(nanos int64) time.Time {
+	return time.Unix(0, nanos).UTC()
+}
+
+// Create a new SQL WHERE clause translator.
+//
+// `columns` maps schema field names, as found in the IR, to column
+// expressions.  If `rebinder` is nil then `?' placeholders are left as-is.
+func NewSQLTranslator(dialect SQLDialect, rebinder SQLRebinder, columns map[string]string) *SQLTranslator {
+	return &SQLTranslator{
+		dialect:  dialect,
+		rebinder: rebinder,
+		columns:  columns,
+	}
+}
+
+// * sql.go ends here.
@@ -0,0 +1,144 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// sql_dialect.go --- SQL dialects for the WHERE clause translator.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// The translator emits `?' placeholders throughout; dialects only cover the
+// constructs that have no portable spelling.
+//
+// MySQL string comparisons, `LIKE' included, follow the column's
+// collation, so a case-insensitive collation makes string predicates wider
+// than their lucette equivalents.  Regular expressions are run by the
+// database's own engine (ICU for MySQL, Spencer ARE for PostgreSQL) and
+// only the common subset of Go's RE2 syntax behaves identically.
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import "gitlab.com/tozd/go/errors"
+
+// * Constants:
+
+const (
+	SQLDialectMySQL    = "mysql"    // MySQL and MariaDB.
+	SQLDialectPostgres = "postgres" // PostgreSQL.
+)
+
+// * Code:
+
+// ** Interface:
+
+// SQL dialect.
+type SQLDialect interface {
+	// Return the name of the dialect.
+	Name() string
+
+	// Return an expression that matches the column against a regular
+	// expression given by the placeholder.
+	Regex(column, placeholder string) string
+
+	// Return an expression converting the given IP address expression
+	// into a form that compares in address order.
+	IP(expr string) string
+}
+
+// ** MySQL:
+
+type mysqlDialect struct{}
+
+// Return the name of the dialect.
+func (mysqlDialect) Name() string {
+	return SQLDialectMySQL
+}
+
+// Return a case-sensitive regular expression match.
+//
+// Inline flags such as `(?i)' in the pattern take precedence.
+func (mysqlDialect) Regex(column, placeholder string) string {
+	return "REGEXP_LIKE(" + column + ", " + placeholder + ", 'c')"
+}
+
+// Return the binary form of the IP address.
+func (mysqlDialect) IP(expr string) string {
+	return "INET6_ATON(" + expr + ")"
+}
+
+// ** PostgreSQL:
+
+type postgresDialect struct{}
+
+// Return the name of the dialect.
+func (postgresDialect) Name() string {
+	return SQLDialectPostgres
+}
+
+// Return a case-sensitive regular expression match.
+func (postgresDialect) Regex(column, placeholder string) string {
+	return column + " ~ " + placeholder
+}
+
+// Return the IP address as an `inet' value.
+func (postgresDialect) IP(expr string) string {
+	return "CAST(" + expr + " AS inet)"
+}
+
+// ** Functions:
+
+// Return the MySQL dialect.
+func MySQLDialect() SQLDialect {
+	return mysqlDialect{}
+}
+
+// Return the PostgreSQL dialect.
+func PostgresDialect() SQLDialect {
+	return postgresDialect{}
+}
+
+// Return the SQL dialect spoken by the given database driver.
+//
+// Driver names are those accepted by `database.Open`.
+func SQLDialectForDriver(driver string) (SQLDialect, error) {
+	switch driver {
+	case "mysql":
+		return mysqlDialect{}, nil
+
+	case "postgres", "pgx", "pgx/v5":
+		return postgresDialect{}, nil
+	}
+
+	return nil, errors.WithMessagef(ErrUnknownDialect, "driver %q", driver)
+}
+
+// * sql_dialect.go ends here.
@@ -0,0 +1,342 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// sql_test.go --- SQL WHERE clause translator tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Asmodai/gohacks/database"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Utilities:
+
+// Columns for the test schema.  `Facility' is deliberately unmapped.
+func makeSQLColumns() map[string]string {
+	return map[string]string{
+		"Timestamp": "ts",
+		"Level":     "level",
+		"Percent":   "pct",
+		"Source":    "src",
+		"Message":   "msg",
+	}
+}
+
+// ** Tests:
+
+//nolint:funlen
+func TestSQLTranslator(t *testing.T) {
+	stamp := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query  string
+		clause string
+		args   []any
+		exact  bool
+		diags  int
+	}{
+		{`level:42`, `level = ?`, []any{42.0}, true, 0},
+		{`!level:42`, `NOT COALESCE(level = ?, FALSE)`, []any{42.0}, true, 0},
+		{`level:[1 TO 5}`, `(level >= ? AND level < ?)`, []any{1.0, 5.0}, true, 0},
+		{`percent:[* TO *]`, `pct IS NOT NULL`, nil, true, 0},
+		{`level:1 || level:>=10`, `(level = ? OR level >= ?)`, []any{1.0, 10.0}, true, 0},
+		{
+			`timestamp:{"2025-01-01T00:00:00Z" TO *]`,
+			`ts > ?`,
+			[]any{stamp},
+			true,
+			0,
+		}, {
+			`timestamp:<"2025-01-01T00:00:00Z"`,
+			`ts < ?`,
+			[]any{stamp},
+			true,
+			0,
+		}, {
+			`source:"::ffff:10.0.0.1"`,
+			`INET6_ATON(src) = INET6_ATON(?)`,
+			[]any{"10.0.0.1"},
+			true,
+			0,
+		}, {
+			`source:["10.0.0.1" TO *}`,
+			`INET6_ATON(src) >= INET6_ATON(?)`,
+			[]any{"10.0.0.1"},
+			true,
+			0,
+		},
+		{`message:/^x/i`, `REGEXP_LIKE(msg, ?, 'c')`, []any{"(?i)^x"}, true, 0},
+		{
+			`message:"50%_off!  now"`,
+			`msg LIKE ? ESCAPE '!'`,
+			[]any{"%50!%!_off!!%now%"},
+			false,
+			0,
+		}, {
+			`!message:"a?c*"`,
+			`NOT COALESCE(msg LIKE ? ESCAPE '!', FALSE)`,
+			[]any{"a_c%"},
+			false,
+			0,
+		}, {
+			`!message:"hello world"`,
+			`NOT COALESCE(msg = ?, FALSE)`,
+			[]any{"hello world"},
+			false,
+			0,
+		},
+		{`message:"hello"~1`, `TRUE`, nil, false, 1},
+		{`facility:"kern"`, `TRUE`, nil, false, 1},
+		{`!facility:"kern"`, `TRUE`, nil, false, 1},
+		{`!(level:42 && facility:"kern")`, `TRUE`, nil, false, 1},
+		{
+			`level:42 && !(facility:"kern" || message:"x"~)`,
+			`level = ?`,
+			[]any{42.0},
+			false,
+			2,
+		},
+	}
+
+	trans := NewSQLTranslator(MySQLDialect(), nil, makeSQLColumns())
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			where, diags := trans.Translate(typeQuery(t, test.query))
+
+			if where.Clause != test.clause {
+				t.Errorf("Clause mismatch: %s != %s",
+					where.Clause,
+					test.clause)
+			}
+
+			empty := len(where.Args) == 0 && len(test.args) == 0
+			if !empty && !reflect.DeepEqual(where.Args, test.args) {
+				t.Errorf("Argument mismatch: %#v != %#v",
+					where.Args,
+					test.args)
+			}
+
+			if where.Exact != test.exact {
+				t.Errorf("Exactness mismatch: %v != %v",
+					where.Exact,
+					test.exact)
+			}
+
+			if len(diags) != test.diags {
+				t.Errorf("Diagnostic mismatch: %v", diags)
+			}
+		})
+	}
+}
+
+func TestSQLTranslatorNodes(t *testing.T) {
+	lo := int64(0)
+	trans := NewSQLTranslator(PostgresDialect(), nil, makeSQLColumns())
+
+	tests := []struct {
+		node   IRNode
+		clause string
+	}{
+		{&IRAnd{}, `TRUE`},
+		{&IROr{}, `FALSE`},
+		{&IRAny{Field: "Message"}, `msg IS NOT NULL`},
+		{&IRStringEQ{Field: "Message", Value: "x"}, `msg = ?`},
+		{&IRStringNEQ{Field: "Message", Value: "x"}, `NOT COALESCE(msg = ?, FALSE)`},
+		{&IRPrefix{Field: "Message", Prefix: "a_b"}, `msg LIKE ? ESCAPE '!'`},
+		{&IRGlob{Field: "Message", Glob: "a*"}, `msg LIKE ? ESCAPE '!'`},
+		{&IRRegex{Field: "Message", Pattern: "x"}, `msg ~ ?`},
+		{&IRTimeRange{Field: "Timestamp", Lo: &lo, IncL: true}, `ts >= ?`},
+		{
+			&IRIPRange{
+				Field: "Source",
+				Lo:    netip.MustParseAddr("10.0.0.0"),
+				Hi:    netip.MustParseAddr("10.255.255.255"),
+				IncL:  true,
+				IncH:  true},
+			`(CAST(src AS inet) >= CAST(? AS inet) AND CAST(src AS inet) <= CAST(? AS inet))`,
+		},
+		{&IRIPCmp{Field: "Source", Op: ComparatorEQ}, `TRUE`},
+		{&IRNot{Kid: &IRIPCmp{Field: "Source"}}, `TRUE`},
+	}
+
+	for _, test := range tests {
+		where, _ := trans.Translate(test.node)
+
+		if where.Clause != test.clause {
+			t.Errorf("%T: clause mismatch: %s != %s",
+				test.node,
+				where.Clause,
+				test.clause)
+		}
+	}
+
+	where, _ := trans.Translate(&IRPrefix{Field: "Message", Prefix: "a_b"})
+	if want := []any{"a!_b%"}; !reflect.DeepEqual(where.Args, want) {
+		t.Errorf("Argument mismatch: %#v != %#v", where.Args, want)
+	}
+}
+
+func TestSQLTranslatorAnalysed(t *testing.T) {
+	trans := NewSQLTranslator(MySQLDialect(), nil, makeSQLColumns())
+
+	tests := []struct {
+		analyser string
+		kind     PredicateKind
+		negate   bool
+		str      string
+		clause   string
+		exact    bool
+	}{
+		{AnalyserEnglish, PredicateEQS, false, "Running", `TRUE`, false},
+		{AnalyserEnglish, PredicateEQS, true, "Running", `TRUE`, false},
+		{AnalyserEnglish, PredicateNEQS, false, "Running", `TRUE`, false},
+		{AnalyserEnglish, PredicatePREFIX, false, "RUN", `TRUE`, false},
+		{AnalyserEnglish, PredicateGLOB, false, "R?nning", `TRUE`, false},
+		{AnalyserKeyword, PredicateEQS, false, "Running", `msg = ?`, true},
+		{
+			AnalyserWhitespace,
+			PredicateNEQS,
+			false,
+			"Running",
+			`NOT COALESCE(msg = ?, FALSE)`,
+			true,
+		},
+		{AnalyserKeyword, PredicatePREFIX, false, "RUN", `msg LIKE ? ESCAPE '!'`, true},
+		{AnalyserKeyword, PredicateGLOB, false, "R?nning", `msg LIKE ? ESCAPE '!'`, true},
+	}
+
+	for num, test := range tests {
+		schema := Schema{
+			"message": FieldSpec{
+				Name:     "Message",
+				FType:    FTText,
+				Analyser: test.analyser},
+		}
+
+		node := &ASTPredicate{Kind: test.kind, Field: "message", String: test.str}
+
+		typed, diags := NewTyper(schema).Type(node)
+		if len(diags) > 0 {
+			t.Fatalf("%02d: Typer errors: %v", num, diags)
+		}
+
+		if test.negate {
+			typed = &IRNot{Kid: typed}
+		}
+
+		where, diags := trans.Translate(typed)
+
+		if where.Clause != test.clause {
+			t.Errorf("%02d: clause mismatch: %s != %s",
+				num,
+				where.Clause,
+				test.clause)
+		}
+
+		if where.Exact != test.exact {
+			t.Errorf("%02d: exactness mismatch: %v != %v",
+				num,
+				where.Exact,
+				test.exact)
+		}
+
+		if (len(diags) == 0) != test.exact {
+			t.Errorf("%02d: diagnostic mismatch: %v", num, diags)
+		}
+	}
+}
+
+func TestSQLTranslatorRebind(t *testing.T) {
+	query := `level:[1 TO 5] && source:"10.0.0.1"`
+
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{
+			"mysql",
+			`((level >= ? AND level <= ?) AND ` +
+				`INET6_ATON(src) = INET6_ATON(?))`,
+		}, {
+			"pgx/v5",
+			`((level >= $1 AND level <= $2) AND ` +
+				`CAST(src AS inet) = CAST($3 AS inet))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			dialect, err := SQLDialectForDriver(test.driver)
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			trans := NewSQLTranslator(dialect,
+				database.FromDB(nil, test.driver),
+				makeSQLColumns())
+
+			where, diags := trans.Translate(typeQuery(t, query))
+			if len(diags) > 0 {
+				t.Fatalf("Unexpected diagnostics: %v", diags)
+			}
+
+			if where.Clause != test.want {
+				t.Errorf("Clause mismatch: %s != %s",
+					where.Clause,
+					test.want)
+			}
+
+			if len(where.Args) != 3 {
+				t.Errorf("Argument count mismatch: %d != 3",
+					len(where.Args))
+			}
+		})
+	}
+
+	if _, err := SQLDialectForDriver("sqlite3"); !errors.Is(err, ErrUnknownDialect) {
+		t.Errorf("Error mismatch: %#v", err)
+	}
+}
+
+// * sql_test.go ends here.
@@ -43,12 +43,20 @@ import (
 	"fmt"
 	"net/netip"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Asmodai/gohacks/conversion"
 	"gitlab.com/tozd/go/errors"
 )
 
+// * Constants:
+
+const (
+	// Edit distance used when `~` is not followed by a number.
+	defaultFuzziness float64 = 2
+)
+
 // * Variables:
 
 var (
@@ -71,8 +79,9 @@ type Typer interface {
 // ** Structure:
 
 type typer struct {
-	Sch   Schema
-	Diags []Diagnostic
+	Sch       Schema
+	Analysers *AnalyserRegistry
+	Diags     []Diagnostic
 }
 
 // ** Utility methods:
@@ -86,6 +95,58 @@ func (t *typer) Diagnostics() []Diagnostic {
 	return t.Diags
 }
 
+// Look up the analyser for a text field.
+//
+// Returns nil if the field is not an analysed text field.
+func (t *typer) fieldAnalyser(pred *ASTPredicate, spec FieldSpec) Analyser {
+	if spec.FType != FTText || spec.Analyser == "" {
+		return nil
+	}
+
+	analyser, found := t.Analysers.Lookup(spec.Analyser)
+	if !found {
+		t.addDiag(pred.span,
+			"unknown analyser %q for field %q",
+			spec.Analyser,
+			spec.Name)
+
+		return nil
+	}
+
+	return analyser
+}
+
+// Apply the field's analyser to a text literal.
+//
+// Returns the analysed literal along with the name of the analyser that
+// was applied, which is empty if the literal was left as it is.
+func (t *typer) analyse(pred *ASTPredicate, spec FieldSpec) (string, string) {
+	analyser := t.fieldAnalyser(pred, spec)
+	if analyser == nil {
+		return pred.String, ""
+	}
+
+	// Tokenising a wildcard pattern would destroy the wildcards.
+	if hasWildcard(pred.String) {
+		return analyser.Normalise(pred.String), spec.Analyser
+	}
+
+	return strings.Join(analyser.Analyse(pred.String), " "), spec.Analyser
+}
+
+// Normalise a prefix or glob literal with the field's analyser.
+//
+// Such literals match single terms, so they are normalised term-wise
+// rather than tokenised.
+func (t *typer) normalise(pred *ASTPredicate, spec FieldSpec) (string, string) {
+	analyser := t.fieldAnalyser(pred, spec)
+	if analyser == nil {
+		return pred.String, ""
+	}
+
+	return analyser.Normalise(pred.String), spec.Analyser
+}
+
 // ** IR methods:
 
 // Generate an IR `And' node.
@@ -141,7 +202,8 @@ func (t *typer) typeCompare(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return &IRNumberCmp{
 			Field: spec.Name,
 			Op:    pred.Comparator.Op,
-			Value: val}
+			Value: val,
+			Boost: pred.Boost}
 
 	case FTDateTime:
 		str, _, _ := stringOrNumber(pred.Comparator.Atom)
@@ -156,7 +218,8 @@ func (t *typer) typeCompare(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return &IRTimeCmp{
 			Field: spec.Name,
 			Op:    pred.Comparator.Op,
-			Value: val}
+			Value: val,
+			Boost: pred.Boost}
 
 	case FTIP:
 		str, _, _ := stringOrNumber(pred.Comparator.Atom)
@@ -169,7 +232,8 @@ func (t *typer) typeCompare(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return &IRIPCmp{
 			Field: spec.Name,
 			Op:    pred.Comparator.Op,
-			Value: val}
+			Value: val,
+			Boost: pred.Boost}
 
 	default:
 		t.addDiag(pred.span,
@@ -188,9 +252,12 @@ func (t *typer) typeStringEQ(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return t.typeCompare(pred, spec)
 	}
 
+	value, analyser := t.analyse(pred, spec)
+
 	return &IRStringEQ{
-		Field: spec.Name,
-		Value: pred.String}
+		Field:    spec.Name,
+		Value:    value,
+		Analyser: analyser}
 }
 
 func (t *typer) typeStringNEQ(pred *ASTPredicate, spec FieldSpec) IRNode {
@@ -198,9 +265,12 @@ func (t *typer) typeStringNEQ(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return t.typeCompare(pred, spec)
 	}
 
+	value, analyser := t.analyse(pred, spec)
+
 	return &IRStringNEQ{
-		Field: spec.Name,
-		Value: pred.String}
+		Field:    spec.Name,
+		Value:    value,
+		Analyser: analyser}
 }
 
 func (t *typer) typeAny(_ *ASTPredicate, spec FieldSpec) IRNode {
@@ -215,9 +285,12 @@ func (t *typer) typeGlob(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return &IRAny{Field: spec.Name}
 	}
 
+	glob, analyser := t.normalise(pred, spec)
+
 	return &IRGlob{
-		Field: spec.Name,
-		Glob:  pred.String}
+		Field:    spec.Name,
+		Glob:     glob,
+		Analyser: analyser}
 }
 
 func (t *typer) typePhrase(pred *ASTPredicate, spec FieldSpec) IRNode {
@@ -239,16 +312,20 @@ func (t *typer) typePhrase(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return &IRIPCmp{
 			Field: spec.Name,
 			Op:    ComparatorEQ,
-			Value: ipaddr}
+			Value: ipaddr,
+			Boost: pred.Boost}
 	}
 
 proceedAsString:
+	phrase, analyser := t.analyse(pred, spec)
+
 	return &IRPhrase{
 		Field:     spec.Name,
-		Phrase:    pred.String,
+		Phrase:    phrase,
 		Proximity: pred.Proximity,
-		Fuzz:      pred.Fuzz,
-		Boost:     pred.Boost}
+		Fuzz:      fuzziness(pred),
+		Boost:     pred.Boost,
+		Analyser:  analyser}
 }
 
 func (t *typer) typePrefix(pred *ASTPredicate, spec FieldSpec) IRNode {
@@ -260,9 +337,12 @@ func (t *typer) typePrefix(pred *ASTPredicate, spec FieldSpec) IRNode {
 		return &IRAny{Field: spec.Name}
 	}
 
+	prefix, analyser := t.normalise(pred, spec)
+
 	return &IRPrefix{
-		Field:  spec.Name,
-		Prefix: pred.String}
+		Field:    spec.Name,
+		Prefix:   prefix,
+		Analyser: analyser}
 }
 
 func (t *typer) typeRange(pred *ASTPredicate, spec FieldSpec) IRNode {
@@ -277,7 +357,8 @@ func (t *typer) typeRange(pred *ASTPredicate, spec FieldSpec) IRNode {
 			Lo:    low,
 			Hi:    high,
 			IncL:  pred.Range.IncL,
-			IncH:  pred.Range.IncH}
+			IncH:  pred.Range.IncH,
+			Boost: pred.Boost}
 
 	case FTDateTime:
 		low := pickTimePtr(pred.Range.Lo, t, pred.span, spec.Layouts)
@@ -288,7 +369,8 @@ func (t *typer) typeRange(pred *ASTPredicate, spec FieldSpec) IRNode {
 			Lo:    low,
 			Hi:    high,
 			IncL:  pred.Range.IncL,
-			IncH:  pred.Range.IncH}
+			IncH:  pred.Range.IncH,
+			Boost: pred.Boost}
 
 	case FTIP:
 		low := pickIPPtr(pred.Range.Lo, t, pred.span)
@@ -299,7 +381,8 @@ func (t *typer) typeRange(pred *ASTPredicate, spec FieldSpec) IRNode {
 			Lo:    low,
 			Hi:    high,
 			IncL:  pred.Range.IncL,
-			IncH:  pred.Range.IncH}
+			IncH:  pred.Range.IncH,
+			Boost: pred.Boost}
 
 	default:
 		t.addDiag(pred.span,
@@ -322,7 +405,8 @@ func (t *typer) typeRegex(pred *ASTPredicate, spec FieldSpec) IRNode {
 	return &IRRegex{
 		Field:    spec.Name,
 		Pattern:  pred.Regex,
-		Compiled: pred.compiled}
+		Compiled: pred.compiled,
+		Boost:    pred.Boost}
 }
 
 func (t *typer) typeLeaf(pred *ASTPredicate, spec FieldSpec) IRNode {
@@ -396,6 +480,17 @@ func (t *typer) Type(node ASTNode) (IRNode, []Diagnostic) {
 
 // ** Functions:
 
+// Return the edit distance for a predicate, if it is fuzzy.
+func fuzziness(pred *ASTPredicate) *float64 {
+	if pred.Fuzz != nil || !pred.Fuzzy {
+		return pred.Fuzz
+	}
+
+	dist := defaultFuzziness
+
+	return &dist
+}
+
 // Convert a string to a Unix epoch.
 func toEpoch(val string, layouts []string) (int64, error) {
 	for _, layout := range layouts {
@@ -521,8 +616,17 @@ func pickIPPtr(lit *ASTLiteral, inst *typer, span *Span) netip.Addr {
 	return val
 }
 
+// Create a new typer for the given schema.
+//
+// Analysers named by the schema are looked up in the default analyser
+// registry.
 func NewTyper(sch Schema) Typer {
-	return &typer{Sch: sch}
+	return NewTyperWithAnalysers(sch, DefaultAnalyserRegistry())
+}
+
+// Create a new typer that looks up analysers in the given registry.
+func NewTyperWithAnalysers(sch Schema, analysers *AnalyserRegistry) Typer {
+	return &typer{Sch: sch, Analysers: analysers}
 }
 
 // * typer.go ends here.
@@ -0,0 +1,862 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// vm.go --- Bytecode virtual machine.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// The virtual machine is a simple accumulator machine with the following
+// registers:
+//
+//   PC     Program counter.
+//   ACC    Accumulator.  Zero is false, anything else is true.
+//   FIELD  Index of the current field in the field constant pool.
+//   BOOST  Boost value for the next predicate.
+//   FUZZY  Fuzziness value for the next predicate.
+//   SCORE  Relevance score accumulated so far.
+//
+// Predicates operate on the values of the current field and store their
+// result in the accumulator.
+//
+// A field that is missing from the document matches nothing, with the
+// exception of the inequality opcodes, which match because a missing value
+// is certainly not equal to the constant.  This keeps `NOT field:x` in
+// agreement with the inequality that the NNF pass rewrites it into.
+//
+// Scoring:
+//
+// Every predicate that matches adds `BOOST * similarity` to the score,
+// where similarity is 1 for an exact match.  A fuzzy match at edit
+// distance `d` with fuzziness `f` has a similarity of `1 - d/(f+1)`, so
+// closer matches rank higher.  `BOOST` and `FUZZY` are reset once a
+// predicate has consumed them.
+//
+// As the code generator short-circuits, only the predicates that were
+// actually evaluated can contribute, so for `a OR b` only the first
+// matching alternative is scored.  Predicates under a negation are given a
+// boost of zero by the code generator.
+//
+// Analysis:
+//
+// Phrases on text fields that name an analyser have already been analysed
+// by the typer.  The VM applies the same analyser to document values before
+// matching, so the comparison is between two lists of terms rather than two
+// raw strings.
+//
+// The code generator only ever emits forward jumps, so a valid program can
+// never execute more instructions than it contains.  The VM uses this to
+// detect runaway programs rather than spin forever.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"cmp"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	// Number of operands taken by range instructions.
+	rangeOperandCount = 4
+
+	// Index of the `FIELD` register value when no field is loaded.
+	noField = -1
+
+	// Default value of the `BOOST` register.
+	defaultBoost = 1.0
+
+	// Similarity of an exact match.
+	exactMatch = 1.0
+)
+
+// * Variables:
+
+var (
+	// Map of `OpCode -> ComparatorKind` for comparison opcodes.
+	//
+	//nolint:gochecknoglobals
+	opToCmp = map[OpCode]ComparatorKind{
+		OpNumberEQ:  ComparatorEQ,
+		OpNumberNEQ: ComparatorNEQ,
+		OpNumberLT:  ComparatorLT,
+		OpNumberLTE: ComparatorLTE,
+		OpNumberGT:  ComparatorGT,
+		OpNumberGTE: ComparatorGTE,
+		OpTimeEQ:    ComparatorEQ,
+		OpTimeNEQ:   ComparatorNEQ,
+		OpTimeLT:    ComparatorLT,
+		OpTimeLTE:   ComparatorLTE,
+		OpTimeGT:    ComparatorGT,
+		OpTimeGTE:   ComparatorGTE,
+		OpIPEQ:      ComparatorEQ,
+		OpIPNEQ:     ComparatorNEQ,
+		OpIPLT:      ComparatorLT,
+		OpIPLTE:     ComparatorLTE,
+		OpIPGT:      ComparatorGT,
+		OpIPGTE:     ComparatorGTE,
+	}
+)
+
+// * Code:
+
+// ** Structure:
+
+// Result of evaluating a program against a document.
+type Result struct {
+	Match bool    // Did the document match?
+	Score float64 // Relevance score.  Zero if there was no match.
+}
+
+// A ranked search hit.
+type Hit struct {
+	Index int     // Index of the document in the searched list.
+	Score float64 // Relevance score.
+}
+
+// Lucette virtual machine.
+//
+// A VM executes a compiled program against documents.  It is not safe for
+// concurrent use, but is cheap to create, so use one per goroutine.
+type VM struct {
+	program *Program // Program to execute.
+	doc     Document // Document being evaluated.
+	pc      int      // Program counter.
+	acc     int      // Accumulator.
+	field   int      // Field register.
+	boost   float64  // Boost register.
+	fuzzy   float64  // Fuzzy register.
+	score   float64  // Score register.
+	sim     float64  // Similarity of the last predicate's match.
+
+	registry  *AnalyserRegistry // Registry used to resolve analysers.
+	analysers []Analyser        // Analysers, indexed by field.
+	resolved  bool              // Have the analysers been resolved?
+}
+
+// ** Methods:
+
+// Reset the VM's registers ready for a run against the given document.
+func (vm *VM) reset(doc Document) {
+	vm.doc = doc
+	vm.pc = 0
+	vm.acc = 0
+	vm.field = noField
+	vm.score = 0
+	vm.resetModifiers()
+}
+
+// Reset the per-predicate registers.
+func (vm *VM) resetModifiers() {
+	vm.boost = defaultBoost
+	vm.fuzzy = 0
+	vm.sim = exactMatch
+}
+
+// Account for the result of a predicate.
+//
+// If the predicate matched, then its boosted similarity is added to the
+// score.  The per-predicate registers are then reset.
+func (vm *VM) settle() {
+	if vm.acc != 0 {
+		vm.score += vm.boost * vm.sim
+	}
+
+	vm.resetModifiers()
+}
+
+// Set the accumulator from a boolean.
+func (vm *VM) setAcc(val bool) {
+	if val {
+		vm.acc = 1
+
+		return
+	}
+
+	vm.acc = 0
+}
+
+// Resolve the analysers named by the program.
+//
+// This only happens once per VM.
+func (vm *VM) resolveAnalysers() error {
+	if vm.resolved {
+		return nil
+	}
+
+	analysers := make([]Analyser, len(vm.program.Fields))
+
+	for idx, field := range vm.program.Fields {
+		name, found := vm.program.Analysers[field]
+		if !found || name == "" {
+			continue
+		}
+
+		analyser, found := vm.registry.Lookup(name)
+		if !found {
+			return errors.WithMessagef(ErrUnknownAnalyser,
+				"%q for field %q",
+				name,
+				field)
+		}
+
+		analysers[idx] = analyser
+	}
+
+	vm.analysers = analysers
+	vm.resolved = true
+
+	return nil
+}
+
+// Return the analyser for the current field, or nil if there is none.
+func (vm *VM) analyser() Analyser {
+	if vm.field < 0 || vm.field >= len(vm.analysers) {
+		return nil
+	}
+
+	return vm.analysers[vm.field]
+}
+
+// Return the values of the current field.
+//
+// If the field is not present in the document, then `nil` is returned.
+func (vm *VM) values() ([]any, error) {
+	if vm.field == noField {
+		return nil, errors.WithMessagef(ErrNoFieldLoaded, "at %d", vm.pc-1)
+	}
+
+	if vm.doc == nil {
+		return nil, nil
+	}
+
+	raw, found := vm.doc.Get(vm.program.Fields[vm.field])
+	if !found {
+		return nil, nil
+	}
+
+	return fieldValues(raw), nil
+}
+
+// Return the result of a finished run.
+func (vm *VM) result() Result {
+	if vm.acc == 0 {
+		return Result{}
+	}
+
+	return Result{Match: true, Score: vm.score}
+}
+
+// Execute a jump instruction.
+func (vm *VM) jump(isn Instr, taken bool) error {
+	target, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		vm.pc = target
+	}
+
+	return nil
+}
+
+// Execute the `LDFLD` instruction.
+func (vm *VM) loadField(isn Instr) error {
+	fid, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	if _, err := constantAt(vm.program.Fields, fid, vm.pc-1); err != nil {
+		return err
+	}
+
+	vm.field = fid
+
+	return nil
+}
+
+// Execute the string instructions.
+//
+// If the field has an analyser, then values are analysed before matching,
+// so that they agree with the analysed literal.  Equality then holds when
+// the literal's terms appear in order among the value's terms, and
+// prefixes and globs are matched against each of the value's terms.
+//
+//nolint:exhaustive
+func (vm *VM) execString(isn Instr) error {
+	sidx, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	str, err := constantAt(vm.program.Strings, sidx, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	var match func(string) bool
+
+	switch isn.Op {
+	case OpStringEQ, OpStringNEQ:
+		match = func(val string) bool { return val == str }
+
+	case OpPrefix:
+		match = func(val string) bool { return strings.HasPrefix(val, str) }
+
+	case OpGlob:
+		match = func(val string) bool { return matchGlob(str, val) }
+
+	default:
+		return nil
+	}
+
+	if analyser := vm.analyser(); analyser != nil {
+		match = analysedMatch(isn.Op, analyser, str, match)
+	}
+
+	found := anyValue(vals, valueString, match)
+
+	if isn.Op == OpStringNEQ {
+		found = !found
+	}
+
+	vm.setAcc(found)
+
+	return nil
+}
+
+// Execute the `REX.S` instruction.
+func (vm *VM) execRegex(isn Instr) error {
+	ridx, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	rex, err := constantAt(vm.program.Patterns, ridx, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	if rex == nil {
+		return errors.WithMessagef(ErrBadConstant, "nil regex at %d", vm.pc-1)
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	vm.setAcc(anyValue(vals, valueString, rex.MatchString))
+
+	return nil
+}
+
+// Execute the `PHR.S` instruction.
+//
+// The maximum edit distance is the larger of the instruction's proximity
+// operand and the `FUZZY` register.
+//
+// If the field has an analyser, then values are analysed before matching.
+func (vm *VM) execPhrase(isn Instr) error {
+	sidx, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	dist, err := operandInt(isn, 1, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	phrase, err := constantAt(vm.program.Strings, sidx, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	if fuzz := int(vm.fuzzy); fuzz > dist {
+		dist = fuzz
+	}
+
+	best, found := 0, false
+	analyser := vm.analyser()
+
+	for _, val := range vals {
+		str, ok := valueString(val)
+		if !ok {
+			continue
+		}
+
+		if analyser != nil {
+			str = strings.Join(analyser.Analyse(str), " ")
+		}
+
+		edits, ok := phraseDistance(str, phrase, dist)
+		if ok && (!found || edits < best) {
+			best, found = edits, true
+		}
+	}
+
+	vm.setAcc(found)
+
+	if found {
+		vm.sim = exactMatch - float64(best)/float64(dist+1)
+	}
+
+	return nil
+}
+
+// Execute the `ANY` instruction.
+func (vm *VM) execAny() error {
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	for _, val := range vals {
+		if val != nil {
+			vm.setAcc(true)
+
+			return nil
+		}
+	}
+
+	vm.setAcc(false)
+
+	return nil
+}
+
+// Execute the numeric comparison instructions.
+func (vm *VM) execNumberCmp(isn Instr) error {
+	nidx, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	num, err := constantAt(vm.program.Numbers, nidx, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	vm.setAcc(compareValues(vals, valueNumber, opToCmp[isn.Op],
+		func(val float64) int {
+			return cmp.Compare(val, num)
+		}))
+
+	return nil
+}
+
+// Execute the date/time comparison instructions.
+func (vm *VM) execTimeCmp(isn Instr) error {
+	tidx, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	epoch, err := constantAt(vm.program.Times, tidx, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	vm.setAcc(compareValues(vals, valueTime, opToCmp[isn.Op],
+		func(val int64) int {
+			return cmp.Compare(val, epoch)
+		}))
+
+	return nil
+}
+
+// Execute the IP address comparison instructions.
+func (vm *VM) execIPCmp(isn Instr) error {
+	iidx, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	addr, err := constantAt(vm.program.IPs, iidx, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	addr = addr.Unmap()
+
+	vm.setAcc(compareValues(vals, valueIP, opToCmp[isn.Op],
+		func(val netip.Addr) int {
+			return val.Compare(addr)
+		}))
+
+	return nil
+}
+
+// Execute the `RNG.N` instruction.
+func (vm *VM) execNumberRange(isn Instr) error {
+	low, high, err := rangeBounds(isn, vm.program.Numbers, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	vm.setAcc(anyValue(vals, valueNumber, func(val float64) bool {
+		return low.admits(val, cmp.Compare[float64]) &&
+			high.admits(val, cmp.Compare[float64])
+	}))
+
+	return nil
+}
+
+// Execute the `RNG.T` instruction.
+func (vm *VM) execTimeRange(isn Instr) error {
+	low, high, err := rangeBounds(isn, vm.program.Times, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	vm.setAcc(anyValue(vals, valueTime, func(val int64) bool {
+		return low.admits(val, cmp.Compare[int64]) &&
+			high.admits(val, cmp.Compare[int64])
+	}))
+
+	return nil
+}
+
+// Execute the `RNG.IP` instruction.
+//
+// The zero address is used by the typer for an unbounded end, so it is
+// treated as such here.
+func (vm *VM) execIPRange(isn Instr) error {
+	low, high, err := rangeBounds(isn, vm.program.IPs, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	low.bounded = low.bounded && low.value.IsValid()
+	high.bounded = high.bounded && high.value.IsValid()
+	low.value = low.value.Unmap()
+	high.value = high.value.Unmap()
+
+	compare := func(lhs, rhs netip.Addr) int {
+		return lhs.Compare(rhs)
+	}
+
+	vm.setAcc(anyValue(vals, valueIP, func(val netip.Addr) bool {
+		return low.admits(val, compare) && high.admits(val, compare)
+	}))
+
+	return nil
+}
+
+// Execute the `IN.CIDR` instruction.
+func (vm *VM) execInCIDR(isn Instr) error {
+	iidx, err := operandInt(isn, 0, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	bits, err := operandInt(isn, 1, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	addr, err := constantAt(vm.program.IPs, iidx, vm.pc-1)
+	if err != nil {
+		return err
+	}
+
+	prefix, err := addr.Unmap().Prefix(bits)
+	if err != nil {
+		return errors.WithMessagef(ErrBadOperand,
+			"bad prefix length %d at %d",
+			bits,
+			vm.pc-1)
+	}
+
+	vals, err := vm.values()
+	if err != nil {
+		return err
+	}
+
+	vm.setAcc(anyValue(vals, valueIP, prefix.Contains))
+
+	return nil
+}
+
+// Execute a single instruction.
+//
+// Returns `true` if the program has finished.
+//
+//nolint:cyclop,funlen
+func (vm *VM) step(isn Instr) (bool, error) {
+	var err error
+
+	switch isn.Op {
+	case OpNoOp, OpLabel:
+		// Nothing to do.
+
+	case OpReturn:
+		return true, nil
+
+	case OpJump:
+		err = vm.jump(isn, true)
+
+	case OpJumpZ:
+		err = vm.jump(isn, vm.acc == 0)
+
+	case OpJumpNZ:
+		err = vm.jump(isn, vm.acc != 0)
+
+	case OpNot:
+		vm.setAcc(vm.acc == 0)
+
+	case OpLoadA:
+		vm.acc, err = operandInt(isn, 0, vm.pc-1)
+
+	case OpLoadField:
+		err = vm.loadField(isn)
+
+	case OpLoadBoost:
+		vm.boost, err = operandFloat(isn, 0, vm.pc-1)
+
+	case OpLoadFuzzy:
+		vm.fuzzy, err = operandFloat(isn, 0, vm.pc-1)
+
+	case OpStringEQ, OpStringNEQ, OpPrefix, OpGlob:
+		err = vm.execString(isn)
+
+	case OpRegex:
+		err = vm.execRegex(isn)
+
+	case OpPhrase:
+		err = vm.execPhrase(isn)
+
+	case OpAny:
+		err = vm.execAny()
+
+	case OpNumberEQ, OpNumberNEQ, OpNumberLT, OpNumberLTE, OpNumberGT, OpNumberGTE:
+		err = vm.execNumberCmp(isn)
+
+	case OpNumberRange:
+		err = vm.execNumberRange(isn)
+
+	case OpTimeEQ, OpTimeNEQ, OpTimeLT, OpTimeLTE, OpTimeGT, OpTimeGTE:
+		err = vm.execTimeCmp(isn)
+
+	case OpTimeRange:
+		err = vm.execTimeRange(isn)
+
+	case OpIPEQ, OpIPNEQ, OpIPLT, OpIPLTE, OpIPGT, OpIPGTE:
+		err = vm.execIPCmp(isn)
+
+	case OpIPRange:
+		err = vm.execIPRange(isn)
+
+	case OpInCIDR:
+		err = vm.execInCIDR(isn)
+
+	default:
+		err = errors.WithMessagef(ErrBadOpcode,
+			"%d at %d",
+			isn.Op,
+			vm.pc-1)
+	}
+
+	if err == nil && isn.IsPredicate() {
+		vm.settle()
+	}
+
+	return false, err
+}
+
+// Run the program against the given document.
+//
+// Returns `true` if the document matches the query.
+func (vm *VM) Run(doc Document) (bool, error) {
+	res, err := vm.Evaluate(doc)
+
+	return res.Match, err
+}
+
+// Evaluate the program against the given document.
+//
+// Returns whether the document matches the query along with its relevance
+// score.
+func (vm *VM) Evaluate(doc Document) (Result, error) {
+	if vm.program == nil {
+		return Result{}, errors.WithStack(ErrNoProgram)
+	}
+
+	if err := vm.resolveAnalysers(); err != nil {
+		return Result{}, err
+	}
+
+	code := vm.program.Code
+	vm.reset(doc)
+
+	for steps := 0; ; steps++ {
+		if vm.pc < 0 || vm.pc >= len(code) {
+			return Result{}, errors.WithMessagef(ErrPCOutOfRange,
+				"%d",
+				vm.pc)
+		}
+
+		if steps >= len(code) {
+			return Result{}, errors.WithMessagef(ErrRunaway,
+				"after %d steps",
+				steps)
+		}
+
+		isn := code[vm.pc]
+		vm.pc++
+
+		done, err := vm.step(isn)
+		if err != nil {
+			return Result{}, err
+		}
+
+		if done {
+			return vm.result(), nil
+		}
+	}
+}
+
+// ** Program methods:
+
+// Run the program against the given document.
+//
+// This is a convenience wrapper that creates a new VM for each call.  For
+// hot paths, create a VM with `NewVM` and reuse it.
+func (p *Program) Run(doc Document) (bool, error) {
+	return NewVM(p).Run(doc)
+}
+
+// Evaluate the program against the given document, returning both the
+// match and its relevance score.
+//
+// This is a convenience wrapper that creates a new VM for each call.
+func (p *Program) Evaluate(doc Document) (Result, error) {
+	return NewVM(p).Evaluate(doc)
+}
+
+// Evaluate the program against each of the given documents and return the
+// matching documents ranked by descending score.
+//
+// Documents with equal scores retain their relative order.
+func (p *Program) Rank(docs []Document) ([]Hit, error) {
+	vm := NewVM(p)
+	hits := make([]Hit, 0, len(docs))
+
+	for idx := range docs {
+		res, err := vm.Evaluate(docs[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		if res.Match {
+			hits = append(hits, Hit{Index: idx, Score: res.Score})
+		}
+	}
+
+	sort.SliceStable(hits, func(lhs, rhs int) bool {
+		return hits[lhs].Score > hits[rhs].Score
+	})
+
+	return hits, nil
+}
+
+// ** Functions:
+
+// Create a new virtual machine for the given program.
+//
+// Analysers named by the program are looked up in the default analyser
+// registry.
+func NewVM(program *Program) *VM {
+	return NewVMWithAnalysers(program, DefaultAnalyserRegistry())
+}
+
+// Create a new virtual machine for the given program that looks up
+// analysers in the given registry.
+func NewVMWithAnalysers(program *Program, analysers *AnalyserRegistry) *VM {
+	return &VM{
+		program:  program,
+		field:    noField,
+		boost:    defaultBoost,
+		sim:      exactMatch,
+		registry: analysers}
+}
+
+// * vm.go ends here.
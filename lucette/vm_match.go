@@ -0,0 +1,170 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// vm_match.go --- String matchers used by the virtual machine.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"strings"
+
+	"github.com/Asmodai/gohacks/stringy"
+)
+
+// * Constants:
+
+const (
+	globAnyRune  = '?' // Glob wildcard matching exactly one rune.
+	globAnyRunes = '*' // Glob wildcard matching zero or more runes.
+)
+
+// * Code:
+
+// ** Functions:
+
+// Does the given string match the given glob pattern?
+//
+// `*` matches any run of runes, including the empty run, and `?` matches
+// exactly one rune.  All other runes match themselves.
+func matchGlob(pattern, str string) bool {
+	pat := []rune(pattern)
+	src := []rune(str)
+	pIdx, sIdx := 0, 0
+	starP, starS := -1, -1
+
+	for sIdx < len(src) {
+		switch {
+		case pIdx < len(pat) && pat[pIdx] == globAnyRunes:
+			// Remember where the star was so we can backtrack.
+			starP, starS = pIdx, sIdx
+			pIdx++
+
+		case pIdx < len(pat) && (pat[pIdx] == globAnyRune || pat[pIdx] == src[sIdx]):
+			pIdx++
+			sIdx++
+
+		case starP >= 0:
+			// Let the last star swallow one more rune.
+			starS++
+			pIdx, sIdx = starP+1, starS
+
+		default:
+			return false
+		}
+	}
+
+	// Trailing stars match the empty run.
+	for pIdx < len(pat) && pat[pIdx] == globAnyRunes {
+		pIdx++
+	}
+
+	return pIdx == len(pat)
+}
+
+// Does the given phrase pattern contain glob wildcards?
+func hasWildcard(pattern string) bool {
+	return strings.ContainsRune(pattern, globAnyRune) ||
+		strings.ContainsRune(pattern, globAnyRunes)
+}
+
+// Return the edit distance between the candidate and the phrase.
+//
+// Wildcard phrases are matched as globs and are either an exact match or
+// no match at all.  Otherwise, if `dist` is non-zero then the candidate may
+// be up to `dist` edits away from the phrase.
+//
+// Returns `false` if the candidate does not match.
+func candidateDistance(candidate, phrase string, wildcard bool, dist int) (int, bool) {
+	switch {
+	case wildcard:
+		return 0, matchGlob(phrase, candidate)
+
+	case dist > 0:
+		edits := stringy.Levenshtein(candidate, phrase)
+
+		return edits, edits <= dist
+
+	default:
+		return 0, candidate == phrase
+	}
+}
+
+// Return the smallest edit distance at which the value contains the
+// phrase.
+//
+// The phrase matches if it matches the whole value, or if it matches any
+// run of consecutive whitespace-separated words in the value that has the
+// same number of words as the phrase.
+//
+// Returns `false` if the value does not contain the phrase.
+func phraseDistance(value, phrase string, dist int) (int, bool) {
+	wildcard := hasWildcard(phrase)
+
+	best, found := candidateDistance(value, phrase, wildcard, dist)
+	if found && best == 0 {
+		return 0, true
+	}
+
+	want := strings.Fields(phrase)
+	words := strings.Fields(value)
+	count := len(want)
+
+	if count == 0 || count > len(words) {
+		return best, found
+	}
+
+	// Normalise the phrase's whitespace so it compares against joined
+	// windows.
+	phrase = strings.Join(want, " ")
+
+	for idx := 0; idx+count <= len(words); idx++ {
+		window := strings.Join(words[idx:idx+count], " ")
+
+		edits, ok := candidateDistance(window, phrase, wildcard, dist)
+		if !ok || (found && edits >= best) {
+			continue
+		}
+
+		best, found = edits, true
+
+		if best == 0 {
+			break
+		}
+	}
+
+	return best, found
+}
+
+// * vm_match.go ends here.
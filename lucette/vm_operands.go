@@ -0,0 +1,196 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// vm_operands.go --- Instruction operand decoding.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import "gitlab.com/tozd/go/errors"
+
+// * Code:
+
+// ** Types:
+
+// One end of a range.
+type rangeBound[T any] struct {
+	value   T    // Bound value.
+	bounded bool // Is there a bound at all?
+	lower   bool // Is this the lower bound?
+	incl    bool // Is the bound inclusive?
+}
+
+// ** Methods:
+
+// Does the bound admit the given value?
+func (b rangeBound[T]) admits(val T, compare func(T, T) int) bool {
+	if !b.bounded {
+		return true
+	}
+
+	res := compare(val, b.value)
+
+	switch {
+	case res == 0:
+		return b.incl
+
+	case b.lower:
+		return res > 0
+
+	default:
+		return res < 0
+	}
+}
+
+// ** Functions:
+
+// Return the integer operand at the given index.
+func operandInt(isn Instr, idx, pc int) (int, error) {
+	if idx < len(isn.Args) {
+		if val, ok := isn.Args[idx].(int); ok {
+			return val, nil
+		}
+	}
+
+	return 0, errors.WithMessagef(ErrBadOperand,
+		"%s operand %d at %d",
+		opNames[isn.Op],
+		idx,
+		pc)
+}
+
+// Return the floating-point operand at the given index.
+//
+// Integer operands are accepted and converted.
+func operandFloat(isn Instr, idx, pc int) (float64, error) {
+	if idx < len(isn.Args) {
+		switch val := isn.Args[idx].(type) {
+		case float64:
+			return val, nil
+
+		case int:
+			return float64(val), nil
+		}
+	}
+
+	return 0, errors.WithMessagef(ErrBadOperand,
+		"%s operand %d at %d",
+		opNames[isn.Op],
+		idx,
+		pc)
+}
+
+// Return the boolean operand at the given index.
+func operandBool(isn Instr, idx, pc int) (bool, error) {
+	if idx < len(isn.Args) {
+		if val, ok := isn.Args[idx].(bool); ok {
+			return val, nil
+		}
+	}
+
+	return false, errors.WithMessagef(ErrBadOperand,
+		"%s operand %d at %d",
+		opNames[isn.Op],
+		idx,
+		pc)
+}
+
+// Return the constant at the given index in the given pool.
+func constantAt[T any](pool []T, idx, pc int) (T, error) {
+	if idx < 0 || idx >= len(pool) {
+		var zero T
+
+		return zero, errors.WithMessagef(ErrBadConstant,
+			"%d at %d",
+			idx,
+			pc)
+	}
+
+	return pool[idx], nil
+}
+
+// Decode the operands of a range instruction.
+//
+// A negative constant index denotes an unbounded end.
+func rangeBounds[T any](isn Instr, pool []T, pc int) (rangeBound[T], rangeBound[T], error) {
+	var low, high rangeBound[T]
+
+	if len(isn.Args) != rangeOperandCount {
+		return low, high, errors.WithMessagef(ErrBadOperand,
+			"%s expects %d operands at %d",
+			opNames[isn.Op],
+			rangeOperandCount,
+			pc)
+	}
+
+	lidx, err := operandInt(isn, 0, pc)
+	if err != nil {
+		return low, high, err
+	}
+
+	hidx, err := operandInt(isn, 1, pc)
+	if err != nil {
+		return low, high, err
+	}
+
+	if low.incl, err = operandBool(isn, 2, pc); err != nil { //nolint:mnd
+		return low, high, err
+	}
+
+	if high.incl, err = operandBool(isn, 3, pc); err != nil { //nolint:mnd
+		return low, high, err
+	}
+
+	low.lower = true
+
+	if lidx >= 0 {
+		if low.value, err = constantAt(pool, lidx, pc); err != nil {
+			return low, high, err
+		}
+
+		low.bounded = true
+	}
+
+	if hidx >= 0 {
+		if high.value, err = constantAt(pool, hidx, pc); err != nil {
+			return low, high, err
+		}
+
+		high.bounded = true
+	}
+
+	return low, high, nil
+}
+
+// * vm_operands.go ends here.
@@ -0,0 +1,440 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// vm_test.go --- Virtual machine tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"math"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Utilities:
+
+// Compile a query against the test schema.
+func compileQuery(t testing.TB, query string) *Program {
+	t.Helper()
+
+	lexed, err := NewLexer().Lex(strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("Lexer error: %#v", err)
+	}
+
+	parsed, diags := NewParser().Parse(lexed)
+	if len(diags) > 0 {
+		t.Fatalf("Parser errors: %v", diags)
+	}
+
+	typed, diags := NewTyper(MakeStructSchema()).Type(parsed)
+	if len(diags) > 0 {
+		t.Fatalf("Typer errors: %v", diags)
+	}
+
+	simple := NewSimplifier().Simplify(NewNNF().NNF(typed))
+	program := NewProgram()
+	program.Emit(simple)
+
+	return program
+}
+
+// ** Tests:
+
+//nolint:funlen
+func TestVM(t *testing.T) {
+	stamp := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	doc := MapDocument{
+		"Timestamp": stamp,
+		"Facility":  "kern",
+		"Level":     42,
+		"Percent":   12.5,
+		"Source":    "192.168.1.20",
+		"Message":   "hello brave new world",
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`level:42`, true},
+		{`level:41`, false},
+		{`level:>40 && level:<=42`, true},
+		{`level:>42`, false},
+		{`level:[1 TO 42]`, true},
+		{`level:[1 TO 42}`, false},
+		{`level:[* TO *]`, true},
+		{`-level:42`, false},
+		{`!level:41`, true},
+		{`percent:[12 TO 13]`, true},
+		{`message:"hello"`, true},
+		{`message:"brave new"`, true},
+		{`message:"new brave"`, false},
+		{`message:"br*e"`, true},
+		{`message:"w?rld"`, true},
+		{`message:"goodbye"`, false},
+		{`message:/^hel+o/`, true},
+		{`message:/^HELLO/i`, true},
+		{`message:/^bye/`, false},
+		{`!message:"hello"`, false},
+		{`!message:"goodbye"`, true},
+		{`(message:"yes" or message:"hello") and !message:"no"`, true},
+		{`(message:"yes" or message:"maybe") and !message:"no"`, false},
+		{`source:"192.168.1.20"`, true},
+		{`source:"192.168.1.21"`, false},
+		{`source:>="192.168.1.0"`, true},
+		{`source:["192.168.1.1" TO "192.168.1.254"]`, true},
+		{`source:["10.0.0.1" TO "10.0.0.254"]`, false},
+		{`!source:["10.0.0.1" TO "10.0.0.254"]`, true},
+		{`timestamp:>"2025-01-01T00:00:00Z"`, true},
+		{`timestamp:<"2025-01-01T00:00:00Z"`, false},
+		{`timestamp:["2025-06-01T12:00:00Z" TO *]`, true},
+		{`timestamp:{"2025-06-01T12:00:00Z" TO *]`, false},
+		{`level:42 && (message:"nope" || source:"192.168.1.20")`, true},
+		{`(level:42 || message:"nope") && level:43`, false},
+	}
+
+	for idx, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			program := compileQuery(t, test.query)
+
+			got, err := program.Run(doc)
+			if err != nil {
+				t.Fatalf("%02d: Unexpected error: %#v", idx, err)
+			}
+
+			if got != test.want {
+				t.Errorf("%02d: Result mismatch: %v != %v",
+					idx,
+					got,
+					test.want)
+			}
+		})
+	}
+}
+
+func TestVMMultiValued(t *testing.T) {
+	vm := NewVM(compileQuery(t, `message:"beta" && !message:"delta"`))
+
+	t.Run("Match", func(t *testing.T) {
+		doc := MapDocument{"Message": []string{"alpha", "beta"}}
+
+		if got, err := vm.Run(doc); err != nil || !got {
+			t.Errorf("Unexpected result: %v, %v", got, err)
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		doc := MapDocument{"Message": []any{"beta", "delta"}}
+
+		if got, err := vm.Run(doc); err != nil || got {
+			t.Errorf("Unexpected result: %v, %v", got, err)
+		}
+	})
+}
+
+func TestVMMissingField(t *testing.T) {
+	doc := MapDocument{"Message": "hello"}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`level:42`, false},
+		{`level:[1 TO 10]`, false},
+		{`!level:42`, true},
+		{`facility:"kern"`, false},
+		{`!facility:"kern"`, true},
+	}
+
+	for idx, test := range tests {
+		got, err := compileQuery(t, test.query).Run(doc)
+		if err != nil {
+			t.Fatalf("%02d: Unexpected error: %#v", idx, err)
+		}
+
+		if got != test.want {
+			t.Errorf("%02d: %s: %v != %v",
+				idx,
+				test.query,
+				got,
+				test.want)
+		}
+	}
+}
+
+func TestVMScoring(t *testing.T) {
+	doc := MapDocument{
+		"Level":   42,
+		"Message": "hello brave new world",
+	}
+
+	tests := []struct {
+		query string
+		match bool
+		score float64
+	}{
+		{`message:"hello"`, true, 1},
+		{`message:"hello"^3`, true, 3},
+		{`message:"hello"^3 && level:42`, true, 4},
+		{`level:42^2`, true, 2},
+		{`level:[40 TO 50]^0.5`, true, 0.5},
+		{`message:"helo"`, false, 0},
+		{`message:"helo"~1`, true, 0.5},
+		{`message:"brave nwe"~2`, true, 1.0 / 3.0},
+		{`message:"hello"~`, true, 1},
+		{`message:"hallo"~^2`, true, 4.0 / 3.0},
+		{`!message:"goodbye"^5 && level:42`, true, 1},
+		{`message:"goodbye"^5`, false, 0},
+	}
+
+	for idx, test := range tests {
+		res, err := compileQuery(t, test.query).Evaluate(doc)
+		if err != nil {
+			t.Fatalf("%02d: Unexpected error: %#v", idx, err)
+		}
+
+		if res.Match != test.match {
+			t.Errorf("%02d: %s: match %v != %v",
+				idx,
+				test.query,
+				res.Match,
+				test.match)
+		}
+
+		if math.Abs(res.Score-test.score) > 1e-9 {
+			t.Errorf("%02d: %s: score %g != %g",
+				idx,
+				test.query,
+				res.Score,
+				test.score)
+		}
+	}
+}
+
+func TestDefaultFuzziness(t *testing.T) {
+	one := 1.0
+
+	tests := []struct {
+		query string
+		fuzz  *float64
+		typed float64
+	}{
+		{`message:"hello"~`, nil, defaultFuzziness},
+		{`message:"hello"~1`, &one, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			parsed := parseQuery(t, test.query)
+
+			pred, ok := parsed.(*ASTPredicate)
+			if !ok {
+				t.Fatalf("Unexpected AST: %#v", parsed)
+			}
+
+			if !pred.Fuzzy || !reflect.DeepEqual(pred.Fuzz, test.fuzz) {
+				t.Errorf("AST fuzz: %v, %v", pred.Fuzzy, pred.Fuzz)
+			}
+
+			typed, _ := NewTyper(MakeStructSchema()).Type(parsed)
+
+			phrase, ok := typed.(*IRPhrase)
+			if !ok || phrase.Fuzz == nil || *phrase.Fuzz != test.typed {
+				t.Errorf("Unexpected IR: %#v", typed)
+			}
+		})
+	}
+}
+
+func TestProgramRank(t *testing.T) {
+	program := compileQuery(t, `message:"hello"^2 || message:"world"`)
+	docs := []Document{
+		MapDocument{"Message": "world"},
+		MapDocument{"Message": "nothing to see"},
+		MapDocument{"Message": "hello"},
+	}
+
+	hits, err := program.Rank(docs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if len(hits) != 2 {
+		t.Fatalf("Hit count mismatch: %d != 2", len(hits))
+	}
+
+	if hits[0].Index != 2 || hits[1].Index != 0 {
+		t.Errorf("Ranking mismatch: %v", hits)
+	}
+}
+
+//nolint:funlen
+func TestVMBytecode(t *testing.T) {
+	t.Run("CIDR", func(t *testing.T) {
+		program := NewProgram()
+		fidx := program.AddFieldConstant("Source")
+		iidx := program.AddIPConstant(netip.MustParseAddr("10.1.0.0"))
+
+		program.AppendIsn(OpLoadField, fidx)
+		program.AppendIsn(OpInCIDR, iidx, 16)
+		program.AppendIsn(OpReturn)
+
+		for addr, want := range map[string]bool{
+			"10.1.200.3": true,
+			"10.2.0.1":   false,
+		} {
+			got, err := program.Run(MapDocument{"Source": addr})
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			if got != want {
+				t.Errorf("%s: %v != %v", addr, got, want)
+			}
+		}
+	})
+
+	t.Run("NOT", func(t *testing.T) {
+		program := NewProgram()
+
+		program.AppendIsn(OpLoadA, 0)
+		program.AppendIsn(OpNot)
+		program.AppendIsn(OpReturn)
+
+		if got, err := program.Run(nil); err != nil || !got {
+			t.Errorf("Unexpected result: %v, %v", got, err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			code []Instr
+			err  error
+		}{
+			{
+				name: "Runaway",
+				code: []Instr{{Op: OpJump, Args: []any{0}}},
+				err:  ErrRunaway,
+			}, {
+				name: "Bad opcode",
+				code: []Instr{{Op: OpMaximum}},
+				err:  ErrBadOpcode,
+			}, {
+				name: "Bad operand",
+				code: []Instr{{Op: OpLoadA, Args: []any{"one"}}},
+				err:  ErrBadOperand,
+			}, {
+				name: "Bad constant",
+				code: []Instr{{Op: OpLoadField, Args: []any{7}}},
+				err:  ErrBadConstant,
+			}, {
+				name: "No field",
+				code: []Instr{{Op: OpAny}},
+				err:  ErrNoFieldLoaded,
+			}, {
+				name: "Fall off the end",
+				code: []Instr{{Op: OpNoOp}},
+				err:  ErrPCOutOfRange,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				program := NewProgram()
+				program.Code = test.code
+
+				_, err := program.Run(MapDocument{})
+				if !errors.Is(err, test.err) {
+					t.Errorf("Error mismatch: %#v != %#v",
+						err,
+						test.err)
+				}
+			})
+		}
+	})
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    bool
+	}{
+		{"*", "", true},
+		{"a*", "abc", true},
+		{"*c", "abc", true},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"日*語", "日本語", true},
+	}
+
+	for _, test := range tests {
+		if got := matchGlob(test.pattern, test.input); got != test.want {
+			t.Errorf("%q ~ %q: %v != %v",
+				test.input,
+				test.pattern,
+				got,
+				test.want)
+		}
+	}
+}
+
+// ** Benchmarks:
+
+func BenchmarkVM(b *testing.B) {
+	vm := NewVM(compileQuery(b, tests[6].input))
+	doc := MapDocument{"Message": "yes indeed"}
+
+	b.ReportAllocs()
+
+	for range b.N {
+		if _, err := vm.Run(doc); err != nil {
+			b.Fatalf("Unexpected error: %#v", err)
+		}
+	}
+}
+
+// * vm_test.go ends here.
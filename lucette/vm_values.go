@@ -0,0 +1,291 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// vm_values.go --- Document value coercion.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// Document values are whatever the caller hands us, so everything here is
+// about turning `any` into something the opcodes can compare.
+//
+// Fields may be multi-valued.  A slice value is treated as a bag of
+// values, and a predicate matches if any one of the values matches.
+//
+
+// * Package:
+
+package lucette
+
+// * Imports:
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Asmodai/gohacks/conversion"
+)
+
+// * Code:
+
+// ** Interfaces:
+
+// Anything that can provide a `time.Time`, such as `types.RFC3339`.
+type timeProvider interface {
+	Time() time.Time
+}
+
+// ** Functions:
+
+// Expand a document value into a list of values.
+//
+//nolint:cyclop
+func fieldValues(raw any) []any {
+	switch val := raw.(type) {
+	case nil:
+		return nil
+
+	case []any:
+		return val
+
+	case []string:
+		return boxValues(val)
+
+	case []float64:
+		return boxValues(val)
+
+	case []int:
+		return boxValues(val)
+
+	case []int64:
+		return boxValues(val)
+
+	case []time.Time:
+		return boxValues(val)
+
+	case []netip.Addr:
+		return boxValues(val)
+
+	case []net.IP:
+		return boxValues(val)
+
+	default:
+		return []any{raw}
+	}
+}
+
+// Box a typed slice into a slice of `any`.
+func boxValues[T any](vals []T) []any {
+	out := make([]any, len(vals))
+
+	for idx := range vals {
+		out[idx] = vals[idx]
+	}
+
+	return out
+}
+
+// Coerce a value to a string.
+func valueString(val any) (string, bool) {
+	return conversion.ToString(val)
+}
+
+// Coerce a value to a number.
+func valueNumber(val any) (float64, bool) {
+	if str, ok := val.(string); ok {
+		num, err := strconv.ParseFloat(str, 64)
+
+		return num, err == nil
+	}
+
+	return conversion.ToFloat64(val)
+}
+
+// Coerce a value to a Unix epoch in nanoseconds.
+//
+// Strings are parsed as RFC 3339 timestamps, integers are treated as Unix
+// epochs in seconds, in keeping with how the typer treats literals.
+func valueTime(val any) (int64, bool) {
+	switch tval := val.(type) {
+	case time.Time:
+		return tval.UnixNano(), true
+
+	case *time.Time:
+		if tval == nil {
+			return 0, false
+		}
+
+		return tval.UnixNano(), true
+
+	case timeProvider:
+		return tval.Time().UnixNano(), true
+
+	case string:
+		epoch, err := toEpoch(tval, nil)
+
+		return epoch, err == nil
+	}
+
+	if secs, ok := conversion.ToInt64(val); ok {
+		return time.Unix(secs, 0).UnixNano(), true
+	}
+
+	return 0, false
+}
+
+// Coerce a value to an IP address.
+func valueIP(val any) (netip.Addr, bool) {
+	switch addr := val.(type) {
+	case netip.Addr:
+		return addr.Unmap(), addr.IsValid()
+
+	case *netip.Addr:
+		if addr == nil {
+			return zeroIP, false
+		}
+
+		return addr.Unmap(), addr.IsValid()
+
+	case net.IP:
+		parsed, ok := netip.AddrFromSlice(addr)
+
+		return parsed.Unmap(), ok
+
+	case string:
+		parsed, err := netip.ParseAddr(addr)
+
+		return parsed.Unmap(), err == nil
+	}
+
+	return zeroIP, false
+}
+
+// Does any of the given values satisfy the predicate?
+//
+// Values that cannot be coerced to the predicate's type are ignored.
+func anyValue[T any](vals []any, coerce func(any) (T, bool), pred func(T) bool) bool {
+	for _, val := range vals {
+		conv, ok := coerce(val)
+		if !ok {
+			continue
+		}
+
+		if pred(conv) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Wrap a string predicate so that it is applied to analysed values.
+//
+// For equality, the analysed value must either equal the literal or
+// contain the literal's terms in order.  Other predicates must hold for at
+// least one of the value's terms.
+func analysedMatch(op OpCode, analyser Analyser, literal string, pred func(string) bool) func(string) bool {
+	if op == OpStringEQ || op == OpStringNEQ {
+		terms := strings.Fields(literal)
+
+		return func(val string) bool {
+			tokens := analyser.Analyse(val)
+
+			return strings.Join(tokens, " ") == literal ||
+				containsTerms(tokens, terms)
+		}
+	}
+
+	return func(val string) bool {
+		return slices.ContainsFunc(analyser.Analyse(val), pred)
+	}
+}
+
+// Do the given terms appear in order, and next to each other, in the
+// tokens?
+func containsTerms(tokens, terms []string) bool {
+	if len(terms) == 0 || len(terms) > len(tokens) {
+		return false
+	}
+
+	for idx := range len(tokens) - len(terms) + 1 {
+		if slices.Equal(tokens[idx:idx+len(terms)], terms) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Does the result of a three-way comparison satisfy the comparator?
+func testComparator(kind ComparatorKind, res int) bool {
+	switch kind {
+	case ComparatorLT:
+		return res < 0
+
+	case ComparatorLTE:
+		return res <= 0
+
+	case ComparatorGT:
+		return res > 0
+
+	case ComparatorGTE:
+		return res >= 0
+
+	case ComparatorNEQ:
+		return res != 0
+
+	default:
+		return res == 0
+	}
+}
+
+// Compare the given values using the given comparator.
+//
+// `compare` performs a three-way comparison of a value against the
+// constant operand.
+//
+// Inequality holds only when no value is equal, so a missing field is
+// unequal to everything.
+func compareValues[T any](vals []any, coerce func(any) (T, bool), kind ComparatorKind, compare func(T) int) bool {
+	if kind == ComparatorNEQ {
+		return !anyValue(vals, coerce, func(val T) bool {
+			return compare(val) == 0
+		})
+	}
+
+	return anyValue(vals, coerce, func(val T) bool {
+		return testComparator(kind, compare(val))
+	})
+}
+
+// * vm_values.go ends here.
@@ -12,6 +12,7 @@ package expertsys
 import (
 	reflect "reflect"
 
+	expertsys "github.com/Asmodai/gohacks/expertsys"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -39,6 +40,69 @@ func (m *MockWorkingMemory) EXPECT() *MockWorkingMemoryMockRecorder {
 	return m.recorder
 }
 
+// Assert mocks base method.
+func (m *MockWorkingMemory) Assert(arg0 string, arg1 map[string]any) (expertsys.FactID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Assert", arg0, arg1)
+	ret0, _ := ret[0].(expertsys.FactID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Assert indicates an expected call of Assert.
+func (mr *MockWorkingMemoryMockRecorder) Assert(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assert", reflect.TypeOf((*MockWorkingMemory)(nil).Assert), arg0, arg1)
+}
+
+// DefineTemplate mocks base method.
+func (m *MockWorkingMemory) DefineTemplate(arg0 string, arg1 ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DefineTemplate", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DefineTemplate indicates an expected call of DefineTemplate.
+func (mr *MockWorkingMemoryMockRecorder) DefineTemplate(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefineTemplate", reflect.TypeOf((*MockWorkingMemory)(nil).DefineTemplate), varargs...)
+}
+
+// Fact mocks base method.
+func (m *MockWorkingMemory) Fact(arg0 expertsys.FactID) (*expertsys.Fact, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fact", arg0)
+	ret0, _ := ret[0].(*expertsys.Fact)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Fact indicates an expected call of Fact.
+func (mr *MockWorkingMemoryMockRecorder) Fact(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fact", reflect.TypeOf((*MockWorkingMemory)(nil).Fact), arg0)
+}
+
+// Facts mocks base method.
+func (m *MockWorkingMemory) Facts(arg0 string) []*expertsys.Fact {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Facts", arg0)
+	ret0, _ := ret[0].([]*expertsys.Fact)
+	return ret0
+}
+
+// Facts indicates an expected call of Facts.
+func (mr *MockWorkingMemoryMockRecorder) Facts(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Facts", reflect.TypeOf((*MockWorkingMemory)(nil).Facts), arg0)
+}
+
 // Get mocks base method.
 func (m *MockWorkingMemory) Get(arg0 string) (any, bool) {
 	m.ctrl.T.Helper()
@@ -54,6 +118,20 @@ func (mr *MockWorkingMemoryMockRecorder) Get(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWorkingMemory)(nil).Get), arg0)
 }
 
+// HasTemplate mocks base method.
+func (m *MockWorkingMemory) HasTemplate(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasTemplate", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasTemplate indicates an expected call of HasTemplate.
+func (mr *MockWorkingMemoryMockRecorder) HasTemplate(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasTemplate", reflect.TypeOf((*MockWorkingMemory)(nil).HasTemplate), arg0)
+}
+
 // Keys mocks base method.
 func (m *MockWorkingMemory) Keys() []string {
 	m.ctrl.T.Helper()
@@ -68,6 +146,49 @@ func (mr *MockWorkingMemoryMockRecorder) Keys() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockWorkingMemory)(nil).Keys))
 }
 
+// Modify mocks base method.
+func (m *MockWorkingMemory) Modify(arg0 expertsys.FactID, arg1 string, arg2 any) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Modify", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Modify indicates an expected call of Modify.
+func (mr *MockWorkingMemoryMockRecorder) Modify(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Modify", reflect.TypeOf((*MockWorkingMemory)(nil).Modify), arg0, arg1, arg2)
+}
+
+// Retract mocks base method.
+func (m *MockWorkingMemory) Retract(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retract", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Retract indicates an expected call of Retract.
+func (mr *MockWorkingMemoryMockRecorder) Retract(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retract", reflect.TypeOf((*MockWorkingMemory)(nil).Retract), arg0)
+}
+
+// RetractFact mocks base method.
+func (m *MockWorkingMemory) RetractFact(arg0 expertsys.FactID) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetractFact", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// RetractFact indicates an expected call of RetractFact.
+func (mr *MockWorkingMemoryMockRecorder) RetractFact(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetractFact", reflect.TypeOf((*MockWorkingMemory)(nil).RetractFact), arg0)
+}
+
 // Set mocks base method.
 func (m *MockWorkingMemory) Set(arg0 string, arg1 any) bool {
 	m.ctrl.T.Helper()
@@ -109,3 +230,55 @@ func (mr *MockWorkingMemoryMockRecorder) Version() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockWorkingMemory)(nil).Version))
 }
+
+// MockChangeTracker is a mock of ChangeTracker interface.
+type MockChangeTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockChangeTrackerMockRecorder
+	isgomock struct{}
+}
+
+// MockChangeTrackerMockRecorder is the mock recorder for MockChangeTracker.
+type MockChangeTrackerMockRecorder struct {
+	mock *MockChangeTracker
+}
+
+// NewMockChangeTracker creates a new mock instance.
+func NewMockChangeTracker(ctrl *gomock.Controller) *MockChangeTracker {
+	mock := &MockChangeTracker{ctrl: ctrl}
+	mock.recorder = &MockChangeTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChangeTracker) EXPECT() *MockChangeTrackerMockRecorder {
+	return m.recorder
+}
+
+// ChangedAt mocks base method.
+func (m *MockChangeTracker) ChangedAt(arg0 string) uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangedAt", arg0)
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// ChangedAt indicates an expected call of ChangedAt.
+func (mr *MockChangeTrackerMockRecorder) ChangedAt(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangedAt", reflect.TypeOf((*MockChangeTracker)(nil).ChangedAt), arg0)
+}
+
+// ChangedSince mocks base method.
+func (m *MockChangeTracker) ChangedSince(arg0 uint64) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangedSince", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// ChangedSince indicates an expected call of ChangedSince.
+func (mr *MockChangeTrackerMockRecorder) ChangedSince(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangedSince", reflect.TypeOf((*MockChangeTracker)(nil).ChangedSince), arg0)
+}
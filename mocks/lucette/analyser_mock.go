@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./lucette/analyser.go
+//
+// Generated by this command:
+//
+//	mockgen -package=lucette -source=./lucette/analyser.go -destination=mocks/lucette/analyser_mock.go
+//
+
+// Package lucette is a generated GoMock package.
+package lucette
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAnalyser is a mock of Analyser interface.
+type MockAnalyser struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnalyserMockRecorder
+}
+
+// MockAnalyserMockRecorder is the mock recorder for MockAnalyser.
+type MockAnalyserMockRecorder struct {
+	mock *MockAnalyser
+}
+
+// NewMockAnalyser creates a new mock instance.
+func NewMockAnalyser(ctrl *gomock.Controller) *MockAnalyser {
+	mock := &MockAnalyser{ctrl: ctrl}
+	mock.recorder = &MockAnalyserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnalyser) EXPECT() *MockAnalyserMockRecorder {
+	return m.recorder
+}
+
+// Analyse mocks base method.
+func (m *MockAnalyser) Analyse(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Analyse", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Analyse indicates an expected call of Analyse.
+func (mr *MockAnalyserMockRecorder) Analyse(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyse", reflect.TypeOf((*MockAnalyser)(nil).Analyse), arg0)
+}
+
+// Normalise mocks base method.
+func (m *MockAnalyser) Normalise(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Normalise", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Normalise indicates an expected call of Normalise.
+func (mr *MockAnalyserMockRecorder) Normalise(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Normalise", reflect.TypeOf((*MockAnalyser)(nil).Normalise), arg0)
+}
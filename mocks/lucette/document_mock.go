@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./lucette/document.go
+//
+// Generated by this command:
+//
+//	mockgen -package=lucette -source=./lucette/document.go -destination=mocks/lucette/document_mock.go
+//
+
+// Package lucette is a generated GoMock package.
+package lucette
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDocument is a mock of Document interface.
+type MockDocument struct {
+	ctrl     *gomock.Controller
+	recorder *MockDocumentMockRecorder
+}
+
+// MockDocumentMockRecorder is the mock recorder for MockDocument.
+type MockDocumentMockRecorder struct {
+	mock *MockDocument
+}
+
+// NewMockDocument creates a new mock instance.
+func NewMockDocument(ctrl *gomock.Controller) *MockDocument {
+	mock := &MockDocument{ctrl: ctrl}
+	mock.recorder = &MockDocumentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDocument) EXPECT() *MockDocumentMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockDocument) Get(arg0 string) (any, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDocumentMockRecorder) Get(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDocument)(nil).Get), arg0)
+}
@@ -10,6 +10,7 @@
 package wal
 
 import (
+	context "context"
 	reflect "reflect"
 
 	wal "github.com/Asmodai/gohacks/wal"
@@ -54,6 +55,20 @@ func (mr *MockWriteAheadLogMockRecorder) Append(lsn, tstamp, key, value any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockWriteAheadLog)(nil).Append), lsn, tstamp, key, value)
 }
 
+// AppendSync mocks base method.
+func (m *MockWriteAheadLog) AppendSync(lsn uint64, tstamp int64, key, value []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendSync", lsn, tstamp, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendSync indicates an expected call of AppendSync.
+func (mr *MockWriteAheadLogMockRecorder) AppendSync(lsn, tstamp, key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendSync", reflect.TypeOf((*MockWriteAheadLog)(nil).AppendSync), lsn, tstamp, key, value)
+}
+
 // Close mocks base method.
 func (m *MockWriteAheadLog) Close() error {
 	m.ctrl.T.Helper()
@@ -68,6 +83,21 @@ func (mr *MockWriteAheadLogMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockWriteAheadLog)(nil).Close))
 }
 
+// Follow mocks base method.
+func (m *MockWriteAheadLog) Follow(ctx context.Context, baseLSN uint64, applyCb wal.ApplyCallbackFn) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, baseLSN, applyCb)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockWriteAheadLogMockRecorder) Follow(ctx, baseLSN, applyCb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockWriteAheadLog)(nil).Follow), ctx, baseLSN, applyCb)
+}
+
 // Replay mocks base method.
 func (m *MockWriteAheadLog) Replay(baseLSN uint64, applyCb wal.ApplyCallbackFn) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -122,3 +152,195 @@ func (mr *MockWriteAheadLogMockRecorder) Sync() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockWriteAheadLog)(nil).Sync))
 }
+
+// MockSegmentedWriteAheadLog is a mock of SegmentedWriteAheadLog interface.
+type MockSegmentedWriteAheadLog struct {
+	ctrl     *gomock.Controller
+	recorder *MockSegmentedWriteAheadLogMockRecorder
+	isgomock struct{}
+}
+
+// MockSegmentedWriteAheadLogMockRecorder is the mock recorder for MockSegmentedWriteAheadLog.
+type MockSegmentedWriteAheadLogMockRecorder struct {
+	mock *MockSegmentedWriteAheadLog
+}
+
+// NewMockSegmentedWriteAheadLog creates a new mock instance.
+func NewMockSegmentedWriteAheadLog(ctrl *gomock.Controller) *MockSegmentedWriteAheadLog {
+	mock := &MockSegmentedWriteAheadLog{ctrl: ctrl}
+	mock.recorder = &MockSegmentedWriteAheadLogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSegmentedWriteAheadLog) EXPECT() *MockSegmentedWriteAheadLogMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockSegmentedWriteAheadLog) Append(lsn uint64, tstamp int64, key, value []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", lsn, tstamp, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Append(lsn, tstamp, key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Append), lsn, tstamp, key, value)
+}
+
+// AppendSync mocks base method.
+func (m *MockSegmentedWriteAheadLog) AppendSync(lsn uint64, tstamp int64, key, value []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendSync", lsn, tstamp, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendSync indicates an expected call of AppendSync.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) AppendSync(lsn, tstamp, key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendSync", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).AppendSync), lsn, tstamp, key, value)
+}
+
+// Checkpoint mocks base method.
+func (m *MockSegmentedWriteAheadLog) Checkpoint(lsn uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkpoint", lsn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Checkpoint indicates an expected call of Checkpoint.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Checkpoint(lsn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkpoint", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Checkpoint), lsn)
+}
+
+// CheckpointLSN mocks base method.
+func (m *MockSegmentedWriteAheadLog) CheckpointLSN() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckpointLSN")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// CheckpointLSN indicates an expected call of CheckpointLSN.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) CheckpointLSN() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckpointLSN", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).CheckpointLSN))
+}
+
+// Close mocks base method.
+func (m *MockSegmentedWriteAheadLog) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Close))
+}
+
+// Follow mocks base method.
+func (m *MockSegmentedWriteAheadLog) Follow(ctx context.Context, baseLSN uint64, applyCb wal.ApplyCallbackFn) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, baseLSN, applyCb)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Follow(ctx, baseLSN, applyCb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Follow), ctx, baseLSN, applyCb)
+}
+
+// Replay mocks base method.
+func (m *MockSegmentedWriteAheadLog) Replay(baseLSN uint64, applyCb wal.ApplyCallbackFn) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", baseLSN, applyCb)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Replay(baseLSN, applyCb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Replay), baseLSN, applyCb)
+}
+
+// Reset mocks base method.
+func (m *MockSegmentedWriteAheadLog) Reset() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Reset() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Reset))
+}
+
+// Rotate mocks base method.
+func (m *MockSegmentedWriteAheadLog) Rotate() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Rotate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Rotate))
+}
+
+// Segments mocks base method.
+func (m *MockSegmentedWriteAheadLog) Segments() []wal.SegmentInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Segments")
+	ret0, _ := ret[0].([]wal.SegmentInfo)
+	return ret0
+}
+
+// Segments indicates an expected call of Segments.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Segments() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Segments", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Segments))
+}
+
+// SetPolicy mocks base method.
+func (m *MockSegmentedWriteAheadLog) SetPolicy(arg0 wal.Policy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPolicy", arg0)
+}
+
+// SetPolicy indicates an expected call of SetPolicy.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) SetPolicy(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPolicy", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).SetPolicy), arg0)
+}
+
+// Sync mocks base method.
+func (m *MockSegmentedWriteAheadLog) Sync() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sync")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Sync indicates an expected call of Sync.
+func (mr *MockSegmentedWriteAheadLogMockRecorder) Sync() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockSegmentedWriteAheadLog)(nil).Sync))
+}
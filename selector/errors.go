@@ -44,15 +44,17 @@ import "gitlab.com/tozd/go/errors"
 // * Variables:
 
 var (
-	ErrForwardLoop       = errors.Base("selector forward loop detected")
-	ErrHasNoSelector     = errors.Base("has no selector")
-	ErrNoMethodExists    = errors.Base("no method by this name exists")
-	ErrNoMethodSpecified = errors.Base("no method specified")
-	ErrNoMethodToWrap    = errors.Base("no method to wrap")
-	ErrReferenceParse    = errors.Base("reference parse failure")
-	ErrSelectorNotFound  = errors.Base("no method for selector")
-	ErrSelectorPanic     = errors.Base("panic during selector method")
-	ErrUnresolved        = errors.Base("unresolved selector")
+	ErrForwardLoop        = errors.Base("selector forward loop detected")
+	ErrHasNoSelector      = errors.Base("has no selector")
+	ErrNoApplicableMethod = errors.Base("no applicable method")
+	ErrNoNextMethod       = errors.Base("no next method")
+	ErrNoMethodExists     = errors.Base("no method by this name exists")
+	ErrNoMethodSpecified  = errors.Base("no method specified")
+	ErrNoMethodToWrap     = errors.Base("no method to wrap")
+	ErrReferenceParse     = errors.Base("reference parse failure")
+	ErrSelectorNotFound   = errors.Base("no method for selector")
+	ErrSelectorPanic      = errors.Base("panic during selector method")
+	ErrUnresolved         = errors.Base("unresolved selector")
 )
 
 // * Code:
@@ -0,0 +1,561 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// generic.go --- Multiple-dispatch generic functions.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+//
+// Generic functions dispatch on the concrete types of both the receiver
+// and the event, much like CLOS generic functions.
+//
+// Each method is specialised on a receiver type and an event type.  A
+// specialiser may be a concrete type, which matches only that type; an
+// interface type, which matches any type implementing it; or nothing,
+// which matches anything.  Methods specialised on `responder.Respondable`
+// or `events.Event` are unspecialised.
+//
+// Go has no classes, so class precedence is taken to be: the concrete
+// type, then the interfaces it implements, then anything.  Interfaces with
+// more methods are more specific, so an interface is always more specific
+// than the interfaces it embeds.  Methods are ordered
+// by the specificity of their receiver specialiser, then by that of their
+// event specialiser, and then by the order in which they were added.
+//
+// Methods are combined using standard method combination:
+//
+//   - `:around` methods run first, most specific first.  Each may call
+//     `Call.CallNextMethod` to run the next one, the last one running the
+//     rest of the methods.
+//
+//   - `:before` methods run next, most specific first.  As with `Table`,
+//     they act as gatekeepers: a nil or `*SelectorError` result stops the
+//     call and becomes its result.  Other results are ignored.
+//
+//   - The most specific primary method runs next, and its result becomes
+//     the result of the call.  It may call `Call.CallNextMethod` to run
+//     the next most specific primary method.
+//
+//   - `:after` methods run last, least specific first.  They are given the
+//     original event, as they were chosen for it, and their results are
+//     ignored.
+//
+// If no primary method is applicable then a `*SelectorError` wrapping
+// `ErrNoApplicableMethod` is returned.
+
+// * Package:
+
+package selector
+
+// * Imports:
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/Asmodai/gohacks/events"
+	"github.com/Asmodai/gohacks/responder"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	QualifierPrimary Qualifier = iota
+	QualifierBefore
+	QualifierAfter
+	QualifierAround
+)
+
+// * Variables:
+
+var (
+	//nolint:gochecknoglobals
+	qualifierNames = map[Qualifier]string{
+		QualifierPrimary: "primary",
+		QualifierBefore:  ":before",
+		QualifierAfter:   ":after",
+		QualifierAround:  ":around",
+	}
+
+	//nolint:gochecknoglobals
+	respondableType = reflect.TypeFor[responder.Respondable]()
+
+	//nolint:gochecknoglobals
+	eventType = reflect.TypeFor[events.Event]()
+)
+
+// * Code:
+
+// ** Types:
+
+// Method qualifier.
+type Qualifier int
+
+// Generic method function signature type.
+//
+// The call gives access to the next method.
+type GenericMethodFn func(*Call, responder.Respondable, events.Event) events.Event
+
+// A method of a generic function.
+type GenericMethod struct {
+	qualifier Qualifier
+	receiver  reflect.Type // nil if unspecialised.
+	event     reflect.Type // nil if unspecialised.
+	fn        GenericMethodFn
+}
+
+// Methods applicable to a receiver and event type, in the order in which
+// they are run.
+type effectiveMethod struct {
+	around  []*GenericMethod
+	before  []*GenericMethod
+	primary []*GenericMethod
+	after   []*GenericMethod
+}
+
+// Types an effective method is computed for.
+type dispatchKey struct {
+	receiver reflect.Type
+	event    reflect.Type
+}
+
+// A generic function.
+//
+// The effective method for each combination of receiver and event types
+// is cached until a method is added.
+type GenericFunction struct {
+	mu      sync.RWMutex
+	name    string
+	methods []*GenericMethod
+	cache   map[dispatchKey]*effectiveMethod
+}
+
+// An invocation of a generic method.
+type Call struct {
+	name     string
+	receiver responder.Respondable
+	event    events.Event
+	rest     []*GenericMethod                // Next methods.
+	core     func(events.Event) events.Event // Run once `rest` is empty.
+}
+
+// ** Methods:
+
+// *** Qualifier:
+
+// Return the name of the qualifier.
+func (q Qualifier) String() string {
+	if name, ok := qualifierNames[q]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Qualifier(%d)", int(q))
+}
+
+// *** Generic method:
+
+// Return the method's qualifier.
+func (gm *GenericMethod) Qualifier() Qualifier {
+	return gm.qualifier
+}
+
+// Return the method's receiver specialiser, or nil if it has none.
+func (gm *GenericMethod) Receiver() reflect.Type {
+	return gm.receiver
+}
+
+// Return the method's event specialiser, or nil if it has none.
+func (gm *GenericMethod) Event() reflect.Type {
+	return gm.event
+}
+
+// Is the method applicable to the given receiver and event types?
+func (gm *GenericMethod) applicable(key dispatchKey) bool {
+	return specialiserMatches(gm.receiver, key.receiver) &&
+		specialiserMatches(gm.event, key.event)
+}
+
+// Does the method have the same qualifier and specialisers as another?
+func (gm *GenericMethod) sameAs(other *GenericMethod) bool {
+	return gm.qualifier == other.qualifier &&
+		gm.receiver == other.receiver &&
+		gm.event == other.event
+}
+
+// *** Generic function:
+
+// Return the name of the generic function.
+func (gf *GenericFunction) Name() string {
+	return gf.name
+}
+
+// Add a method to the generic function.
+//
+// A method with the same qualifier and specialisers as an existing one
+// replaces it.
+func (gf *GenericFunction) AddMethod(method *GenericMethod) error {
+	if method == nil || method.fn == nil {
+		return errors.WithStack(ErrNoMethodSpecified)
+	}
+
+	gf.mu.Lock()
+	defer gf.mu.Unlock()
+
+	idx := slices.IndexFunc(gf.methods, method.sameAs)
+
+	// Copy to preserve old slice.
+	methods := slices.Clone(gf.methods)
+
+	if idx < 0 {
+		methods = append(methods, method)
+	} else {
+		methods[idx] = method
+	}
+
+	gf.methods = methods
+	clear(gf.cache)
+
+	return nil
+}
+
+// Return the methods of the generic function in the order they were
+// added.
+func (gf *GenericFunction) Methods() []*GenericMethod {
+	gf.mu.RLock()
+	defer gf.mu.RUnlock()
+
+	return slices.Clone(gf.methods)
+}
+
+// Invoke the generic function.
+//
+// Conforms to `Method`, so a generic function can be registered as the
+// primary method of a selector.
+func (gf *GenericFunction) Invoke(receiver responder.Respondable, event events.Event) events.Event {
+	emeth := gf.effective(dispatchKey{
+		receiver: reflect.TypeOf(receiver),
+		event:    reflect.TypeOf(event),
+	})
+
+	if len(emeth.primary) == 0 {
+		return NewSelectorError(
+			gf.name,
+			errors.WithMessagef(
+				ErrNoApplicableMethod,
+				"%q receiver %T event %T",
+				gf.name,
+				receiver,
+				event))
+	}
+
+	Trace("Invoking generic function %q on %T with %T",
+		gf.name,
+		receiver,
+		event)
+
+	call := &Call{
+		name:     gf.name,
+		receiver: receiver,
+		event:    event,
+		rest:     emeth.around,
+		core: func(evt events.Event) events.Event {
+			return emeth.run(gf.name, receiver, evt)
+		},
+	}
+
+	return call.CallNextMethodWith(event)
+}
+
+// Return the effective method for the given types.
+func (gf *GenericFunction) effective(key dispatchKey) *effectiveMethod {
+	gf.mu.RLock()
+	emeth, found := gf.cache[key]
+	gf.mu.RUnlock()
+
+	if found {
+		return emeth
+	}
+
+	gf.mu.Lock()
+	defer gf.mu.Unlock()
+
+	if emeth, found = gf.cache[key]; found {
+		return emeth
+	}
+
+	applicable := make([]*GenericMethod, 0, len(gf.methods))
+
+	for _, method := range gf.methods {
+		if method.applicable(key) {
+			applicable = append(applicable, method)
+		}
+	}
+
+	slices.SortStableFunc(applicable, func(a, b *GenericMethod) int {
+		return cmp.Or(
+			compareSpecialisers(a.receiver, b.receiver),
+			compareSpecialisers(a.event, b.event))
+	})
+
+	emeth = &effectiveMethod{}
+
+	for _, method := range applicable {
+		switch method.qualifier {
+		case QualifierAround:
+			emeth.around = append(emeth.around, method)
+
+		case QualifierBefore:
+			emeth.before = append(emeth.before, method)
+
+		case QualifierAfter:
+			emeth.after = append(emeth.after, method)
+
+		default:
+			emeth.primary = append(emeth.primary, method)
+		}
+	}
+
+	// `:after` methods run least specific first.
+	slices.Reverse(emeth.after)
+
+	gf.cache[key] = emeth
+
+	return emeth
+}
+
+// *** Effective method:
+
+// Run the `:before`, primary and `:after` methods.
+func (em *effectiveMethod) run(
+	name string,
+	receiver responder.Respondable,
+	event events.Event,
+) events.Event {
+	for idx, method := range em.before {
+		Trace("Executing :before[%d] for generic %q", idx, name)
+
+		out := method.fn(&Call{name: name, receiver: receiver, event: event}, receiver, event)
+
+		if _, isErr := out.(*SelectorError); isErr || out == nil {
+			Trace(":before[%d] for generic %q short-circuited", idx, name)
+
+			return out
+		}
+	}
+
+	call := &Call{
+		name:     name,
+		receiver: receiver,
+		event:    event,
+		rest:     em.primary,
+	}
+
+	result := call.CallNextMethodWith(event)
+
+	for idx, method := range em.after {
+		Trace("Executing :after[%d] for generic %q", idx, name)
+
+		_ = method.fn(&Call{name: name, receiver: receiver, event: event}, receiver, event)
+	}
+
+	return result
+}
+
+// *** Call:
+
+// Return the name of the generic function being called.
+func (c *Call) Name() string {
+	return c.name
+}
+
+// Is there a next method?
+func (c *Call) NextMethodP() bool {
+	return len(c.rest) > 0 || c.core != nil
+}
+
+// Call the next method with the event given to this method.
+func (c *Call) CallNextMethod() events.Event {
+	return c.CallNextMethodWith(c.event)
+}
+
+// Call the next method with the given event.
+//
+// As in CLOS, the event must be one for which the same methods are
+// applicable; the remaining methods were chosen for the original event.
+//
+// If there is no next method then a `*SelectorError` wrapping
+// `ErrNoNextMethod` is returned.
+func (c *Call) CallNextMethodWith(event events.Event) events.Event {
+	if len(c.rest) == 0 {
+		if c.core != nil {
+			return c.core(event)
+		}
+
+		return NewSelectorError(
+			c.name,
+			errors.WithMessagef(ErrNoNextMethod, "%q", c.name))
+	}
+
+	next := &Call{
+		name:     c.name,
+		receiver: c.receiver,
+		event:    event,
+		rest:     c.rest[1:],
+		core:     c.core,
+	}
+
+	return c.rest[0].fn(next, c.receiver, event)
+}
+
+// ** Functions:
+
+// Create a new generic function.
+func NewGenericFunction(name string) *GenericFunction {
+	return &GenericFunction{
+		name:    name,
+		methods: []*GenericMethod{},
+		cache:   make(map[dispatchKey]*effectiveMethod),
+	}
+}
+
+// Create a new generic method specialised on the types `R` and `E`.
+//
+// The method is only invoked with receivers and events of those types, so
+// the function need not check their types itself.
+//
+// Returns nil if `fn` is nil.
+func NewMethod[R responder.Respondable, E events.Event](
+	qualifier Qualifier,
+	fn func(*Call, R, E) events.Event,
+) *GenericMethod {
+	if fn == nil {
+		return nil
+	}
+
+	wrapper := func(call *Call, receiver responder.Respondable, event events.Event) events.Event {
+		recv, recvOk := receiver.(R)
+		evt, evtOk := event.(E)
+
+		// Only possible when a next method is called with an event
+		// of another type.
+		if (!recvOk && receiver != nil) || (!evtOk && event != nil) {
+			return NewSelectorError(
+				call.name,
+				errors.WithMessagef(
+					ErrNoApplicableMethod,
+					"%q receiver %T event %T",
+					call.name,
+					receiver,
+					event))
+		}
+
+		return fn(call, recv, evt)
+	}
+
+	return &GenericMethod{
+		qualifier: qualifier,
+		receiver:  specialiser(reflect.TypeFor[R](), respondableType),
+		event:     specialiser(reflect.TypeFor[E](), eventType),
+		fn:        wrapper,
+	}
+}
+
+// Create an unspecialised primary method from a selector method.
+func methodFromPrimary(method Method) *GenericMethod {
+	return &GenericMethod{
+		qualifier: QualifierPrimary,
+		fn: func(_ *Call, receiver responder.Respondable, event events.Event) events.Event {
+			return method(receiver, event)
+		},
+	}
+}
+
+// Return the specialiser for a type, or nil if the type is the given base
+// type that every argument has.
+func specialiser(typ, base reflect.Type) reflect.Type {
+	if typ == base {
+		return nil
+	}
+
+	return typ
+}
+
+// Does the specialiser match the given type?
+func specialiserMatches(spec, typ reflect.Type) bool {
+	switch {
+	case spec == nil:
+		return true
+
+	case typ == nil:
+		return false
+
+	case spec.Kind() == reflect.Interface:
+		return typ.Implements(spec)
+
+	default:
+		return spec == typ
+	}
+}
+
+// Return the precedence rank of a specialiser.
+//
+// Lower ranks are more specific.
+func specialiserRank(spec reflect.Type) int {
+	switch {
+	case spec == nil:
+		return 2 //nolint:mnd
+
+	case spec.Kind() == reflect.Interface:
+		return 1
+
+	default:
+		return 0
+	}
+}
+
+// Compare the specificity of two specialisers matching the same type.
+//
+// Returns a negative number if `a` is more specific than `b`.
+func compareSpecialisers(a, b reflect.Type) int {
+	if rank := cmp.Compare(specialiserRank(a), specialiserRank(b)); rank != 0 {
+		return rank
+	}
+
+	if specialiserRank(a) != 1 {
+		return 0
+	}
+
+	// Both are interfaces.
+	return cmp.Compare(b.NumMethod(), a.NumMethod())
+}
+
+// * generic.go ends here.
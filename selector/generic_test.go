@@ -0,0 +1,290 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// generic_test.go --- Generic function tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package selector
+
+// * Imports:
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Asmodai/gohacks/events"
+	"github.com/Asmodai/gohacks/responder"
+)
+
+// * Code:
+
+// ** Types:
+
+// *** Event without a selector:
+
+type plainEvent struct{}
+
+func (e *plainEvent) String() string  { return "plain" }
+func (e *plainEvent) When() time.Time { return time.Time{} }
+
+// ** Functions:
+
+// Return a method appending `name` to the log, then calling the next
+// method if there is one.
+func logMethod[R responder.Respondable, E events.Event](
+	qualifier Qualifier,
+	log *[]string,
+	name string,
+) *GenericMethod {
+	return NewMethod(qualifier, func(call *Call, _ R, evt E) events.Event {
+		*log = append(*log, name)
+
+		if qualifier == QualifierBefore || qualifier == QualifierAfter {
+			return evt
+		}
+
+		if call.NextMethodP() {
+			return call.CallNextMethod()
+		}
+
+		return evt
+	})
+}
+
+// ** Tests:
+
+func TestGenericDispatch(t *testing.T) {
+	var log []string
+
+	gf := NewGenericFunction("describe")
+	methods := []*GenericMethod{
+		logMethod[responder.Respondable, events.Event](QualifierPrimary, &log, "any"),
+		logMethod[responder.Respondable, SelectorEvent](QualifierPrimary, &log, "selector-event"),
+		logMethod[responder.Respondable, *testEvent](QualifierPrimary, &log, "test-event"),
+		logMethod[*testResponder, events.Event](QualifierPrimary, &log, "test-responder"),
+	}
+
+	for _, method := range methods {
+		if err := gf.AddMethod(method); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		receiver responder.Respondable
+		event    events.Event
+		want     string
+	}{
+		{"receiver first", newTestResponder("r"), &testEvent{}, "test-responder test-event selector-event any"},
+		{"concrete event", NewRespondable("r", "t"), &testEvent{}, "test-event selector-event any"},
+		{"interface event", NewRespondable("r", "t"), &nsTestEvent{}, "selector-event any"},
+		{"unspecialised", NewRespondable("r", "t"), &plainEvent{}, "any"},
+		{"nil event", NewRespondable("r", "t"), nil, "any"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log = nil
+
+			gf.Invoke(test.receiver, test.event)
+
+			if got := strings.Join(log, " "); got != test.want {
+				t.Errorf("Order mismatch: %q != %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenericCombination(t *testing.T) {
+	var log []string
+
+	gf := NewGenericFunction("combine")
+
+	_ = gf.AddMethod(logMethod[responder.Respondable, events.Event](QualifierAround, &log, "around-any"))
+	_ = gf.AddMethod(logMethod[responder.Respondable, *testEvent](QualifierAround, &log, "around-test"))
+	_ = gf.AddMethod(logMethod[responder.Respondable, events.Event](QualifierBefore, &log, "before-any"))
+	_ = gf.AddMethod(logMethod[responder.Respondable, *testEvent](QualifierBefore, &log, "before-test"))
+	_ = gf.AddMethod(logMethod[responder.Respondable, events.Event](QualifierAfter, &log, "after-any"))
+	_ = gf.AddMethod(logMethod[responder.Respondable, *testEvent](QualifierAfter, &log, "after-test"))
+	_ = gf.AddMethod(logMethod[responder.Respondable, events.Event](QualifierPrimary, &log, "primary-any"))
+	_ = gf.AddMethod(logMethod[responder.Respondable, *testEvent](QualifierPrimary, &log, "primary-test"))
+
+	gf.Invoke(NewRespondable("r", "t"), &testEvent{})
+
+	want := "around-test around-any before-test before-any " +
+		"primary-test primary-any after-any after-test"
+
+	if got := strings.Join(log, " "); got != want {
+		t.Errorf("Order mismatch: %q != %q", got, want)
+	}
+
+	t.Run("Around result", func(t *testing.T) {
+		_ = gf.AddMethod(NewMethod(QualifierAround,
+			func(call *Call, _ responder.Respondable, _ *testEvent) events.Event {
+				out := call.CallNextMethodWith(&testEvent{payload: "changed"})
+
+				return &testEvent{payload: "around " + out.String()}
+			}))
+
+		out := gf.Invoke(NewRespondable("r", "t"), &testEvent{payload: "original"})
+		if out.String() != "around changed" {
+			t.Errorf("Unexpected result: %#v", out)
+		}
+
+		if len(gf.Methods()) != 8 {
+			t.Errorf("Method was not replaced: %d", len(gf.Methods()))
+		}
+	})
+
+	t.Run("Before short-circuit", func(t *testing.T) {
+		log = nil
+
+		gf := NewGenericFunction("gate")
+
+		_ = gf.AddMethod(logMethod[responder.Respondable, events.Event](QualifierPrimary, &log, "primary"))
+		_ = gf.AddMethod(logMethod[responder.Respondable, events.Event](QualifierAfter, &log, "after"))
+		_ = gf.AddMethod(NewMethod(QualifierBefore,
+			func(call *Call, _ responder.Respondable, _ *testEvent) events.Event {
+				return NewSelectorError(call.Name(), errors.New("denied"))
+			}))
+
+		out := gf.Invoke(NewRespondable("r", "t"), &testEvent{})
+
+		if err, ok := out.(*SelectorError); !ok || err.Error().Error() != "denied" {
+			t.Errorf("Expected short-circuit error, got: %#v", out)
+		}
+
+		if len(log) > 0 {
+			t.Errorf("Methods invoked after short-circuit: %v", log)
+		}
+	})
+}
+
+func TestGenericErrors(t *testing.T) {
+	gf := NewGenericFunction("fail")
+
+	if err := gf.AddMethod(nil); !errors.Is(err, ErrNoMethodSpecified) {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+
+	var none func(*Call, responder.Respondable, events.Event) events.Event
+
+	if err := gf.AddMethod(NewMethod(QualifierPrimary, none)); !errors.Is(err, ErrNoMethodSpecified) {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+
+	_ = gf.AddMethod(NewMethod(QualifierPrimary,
+		func(call *Call, _ responder.Respondable, _ *testEvent) events.Event {
+			return call.CallNextMethod()
+		}))
+
+	out := gf.Invoke(NewRespondable("r", "t"), &testEvent{})
+	if err, ok := out.(*SelectorError); !ok || !errors.Is(err.Error(), ErrNoNextMethod) {
+		t.Errorf("Expected ErrNoNextMethod, got: %#v", out)
+	}
+
+	out = gf.Invoke(NewRespondable("r", "t"), &plainEvent{})
+	if err, ok := out.(*SelectorError); !ok || !errors.Is(err.Error(), ErrNoApplicableMethod) {
+		t.Errorf("Expected ErrNoApplicableMethod, got: %#v", out)
+	}
+}
+
+func TestTableGeneric(t *testing.T) {
+	res := newTestResponder("generic")
+	tbl := res.Respondable.Methods()
+
+	tbl.Register("greet", func(_ responder.Respondable, e events.Event) events.Event {
+		return &testEvent{selector: "greet", payload: "hello"}
+	})
+
+	_ = tbl.AddAfter("greet", func(_ responder.Respondable, e events.Event) events.Event {
+		res.log.WriteString("after " + e.String())
+
+		return e
+	})
+
+	// `Respondable.Invoke` passes itself as the receiver.
+	err := tbl.AddMethod("greet", NewMethod(QualifierPrimary,
+		func(call *Call, _ *Respondable, e *testEvent) events.Event {
+			if e.payload != "world" {
+				return call.CallNextMethod()
+			}
+
+			return &testEvent{selector: "greet", payload: "hello world"}
+		}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if _, found := tbl.GenericFunction("greet"); !found {
+		t.Fatal("Generic function not found")
+	}
+
+	if out := res.Invoke(&testEvent{selector: "greet", payload: "world"}); out.String() != "hello world" {
+		t.Errorf("Unexpected result: %#v", out)
+	}
+
+	if out := res.Invoke(&testEvent{selector: "greet", payload: "you"}); out.String() != "hello" {
+		t.Errorf("Unexpected result: %#v", out)
+	}
+
+	if got := res.log.String(); got != "after hello worldafter hello" {
+		t.Errorf("Selector :after not invoked: %q", got)
+	}
+
+	tbl.Register("greet", func(_ responder.Respondable, e events.Event) events.Event {
+		return &testEvent{selector: "greet", payload: "hi"}
+	})
+
+	if out := res.Invoke(&testEvent{selector: "greet", payload: "you"}); out.String() != "hi" {
+		t.Errorf("Unspecialised primary not replaced: %#v", out)
+	}
+
+	if out := res.Invoke(&testEvent{selector: "greet", payload: "world"}); out.String() != "hello world" {
+		t.Errorf("Specialised primary replaced: %#v", out)
+	}
+
+	tbl.Register("greet", nil)
+
+	if _, found := tbl.GenericFunction("greet"); found {
+		t.Error("Generic function not removed")
+	}
+
+	if out, ok := tbl.InvokeSelector("greet", res, &testEvent{selector: "greet", payload: "world"}); ok {
+		t.Errorf("Removed primary still invoked: %#v", out)
+	}
+}
+
+// * generic_test.go ends here.
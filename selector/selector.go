@@ -69,6 +69,7 @@ type AuxiliaryMethod struct {
 // Selector table entry.
 type Entry struct {
 	primary Method            // Primary method.
+	generic *GenericFunction  // Generic function, if any.
 	before  []AuxiliaryMethod // Methods to invoke before primary.
 	after   []AuxiliaryMethod // Methods to invoke after primary.
 	mdata   metadata.Metadata // Metadata.
@@ -85,6 +86,10 @@ type Table struct {
 // ** Methods:
 
 // Register a method for a selector.
+//
+// If the selector has a generic function then the method becomes its
+// unspecialised primary method.  Registering a nil method removes the
+// primary method, along with any generic function and all of its methods.
 func (st *Table) Register(selector string, method Method) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
@@ -95,9 +100,68 @@ func (st *Table) Register(selector string, method Method) {
 		st.selectors[selector] = entry
 	}
 
+	switch {
+	case method == nil:
+		entry.generic = nil
+
+	case entry.generic != nil:
+		_ = entry.generic.AddMethod(methodFromPrimary(method))
+
+		return
+	}
+
 	entry.primary = method
 }
 
+// Add a method to the generic function for a selector.
+//
+// The generic function is created if the selector does not have one, and
+// becomes the selector's primary method.  Any existing primary method
+// becomes its unspecialised primary method.
+//
+// `:before` and `:after` methods added via `AddBefore` and `AddAfter`
+// continue to wrap the whole generic function.
+func (st *Table) AddMethod(selector string, method *GenericMethod) error {
+	if method == nil {
+		return errors.WithStack(ErrNoMethodSpecified)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entry, exists := st.selectors[selector]
+	if !exists {
+		entry = newEntry()
+		st.selectors[selector] = entry
+	}
+
+	if entry.generic == nil {
+		generic := NewGenericFunction(selector)
+
+		if entry.primary != nil {
+			_ = generic.AddMethod(methodFromPrimary(entry.primary))
+		}
+
+		entry.generic = generic
+		entry.primary = generic.Invoke
+	}
+
+	return entry.generic.AddMethod(method)
+}
+
+// Return the generic function for a selector.
+func (st *Table) GenericFunction(selector string) (*GenericFunction, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	entry, found := st.selectors[selector]
+	if !found || entry.generic == nil {
+		return nil, false
+	}
+
+	return entry.generic, true
+}
+
 func (st *Table) Unregister(selector string) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
@@ -121,6 +185,10 @@ func (st *Table) SetPrimary(selector string, method Method) (Method, error) {
 			selector)
 	}
 
+	if entry.generic != nil {
+		return method, entry.generic.AddMethod(methodFromPrimary(method))
+	}
+
 	entry.primary = method
 
 	return method, nil
@@ -300,7 +368,8 @@ func (st *Table) invoke(
 	}()
 
 	// NOTE: We diverge from CLOS here slightly --  we do not have the
-	// `:around` auxiliary method.
+	// `:around` auxiliary method.  Generic functions, which are invoked
+	// as the primary, do; see generic.go.
 	//
 	// Here, `:before` acts as a gatekeeper to the primary method.
 	// This means that if any method in the `:before` chain returns nil
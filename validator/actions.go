@@ -47,6 +47,17 @@ import (
 	"gitlab.com/tozd/go/errors"
 )
 
+// * Constants:
+
+const (
+	// Parameters added to failure specifications by `Validator.Compile`.
+	paramRuleName     = "rule-name"
+	paramRuleReason   = "rule-reason"
+	paramRuleField    = "rule-field"
+	paramRuleOperator = "rule-operator"
+	paramRuleExpected = "rule-expected"
+)
+
 // * Variables:
 
 var (
@@ -60,9 +71,10 @@ var (
 // Implementation of validator actions.
 //
 // Conforms to `dag.Actions`.
-type actions struct {
-	errors []error
-}
+//
+// Failure actions record their failures in the `Result` carried by the
+// `BoundObject` being evaluated, so they hold no state of their own.
+type actions struct{}
 
 // ** Methods:
 
@@ -80,6 +92,9 @@ func (act *actions) Builder(funame string, params dag.ActionParams) (dag.ActionF
 	case "error":
 		return act.errorAction(params)
 
+	case "field-error":
+		return act.fieldErrorAction(params)
+
 	default:
 		return nil, errors.WithMessagef(
 			dag.ErrUnknownBuiltin,
@@ -103,6 +118,13 @@ func (act *actions) noneAction(_ dag.ActionParams) (dag.ActionFn, error) {
 //
 //	`message`: The message to print in the log.
 //
+// Optional parameters are:
+//
+//	`code`:     The message code used in reports.
+//	`severity`: The severity of the failure, defaults to `error`.
+//
+// The message is used as the report's message template; see report.go.
+//
 // If no valid parameters are passed then `ErrExpectedParams` is returned.
 //
 // If no `message` parameter is provided then `ErrMissingParam` is returned.
@@ -127,13 +149,93 @@ func (act *actions) errorAction(params dag.ActionParams) (dag.ActionFn, error) {
 		return nil, errors.WithStack(dag.ErrExpectedString)
 	}
 
-	afn := func(_ context.Context, _ dag.Filterable) {
-		if act.errors == nil {
-			act.errors = []error{}
-		}
+	rule, err := ruleParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.template = smsg
+
+	afn := func(_ context.Context, input dag.Filterable) {
+		failure := *rule
 
 		//nolint:err113
-		act.errors = append(act.errors, fmt.Errorf("%s", smsg))
+		failure.Err = fmt.Errorf("%s", smsg)
+
+		recordFailure(input, &failure)
+	}
+
+	return afn, nil
+}
+
+// Compile a `field-error` action.
+//
+// Required parameters are:
+//
+//	`field`: The path of the field that failed validation.
+//	`rule`:  The name of the rule that failed.
+//
+// Optional parameters are:
+//
+//	`param`:    The parameter given to the rule.
+//	`message`:  The message to use instead of the default one.
+//	`code`:     The message code used in reports.
+//	`severity`: The severity of the failure, defaults to `error`.
+//
+// If no valid parameters are passed then `ErrExpectedParams` is returned.
+//
+// If a required parameter is missing then `ErrMissingParam` is returned.
+//
+// If any parameter is not a string then `ErrExpectedString` is returned.
+//
+// Upon success a function object generating a `*FieldError` is returned.
+func (act *actions) fieldErrorAction(params dag.ActionParams) (dag.ActionFn, error) {
+	if params == nil {
+		return nil, errors.WithStack(dag.ErrExpectedParams)
+	}
+
+	strs := make(map[string]string, len(params))
+
+	for _, name := range []string{"field", "rule", "param", "message"} {
+		val, okay := params[name]
+		if !okay {
+			if name == "field" || name == "rule" {
+				return nil, errors.WithMessagef(dag.ErrMissingParam,
+					"Parameter %q",
+					name)
+			}
+
+			continue
+		}
+
+		sval, okay := val.(string)
+		if !okay {
+			return nil, errors.WithMessagef(dag.ErrExpectedString,
+				"Parameter %q",
+				name)
+		}
+
+		strs[name] = sval
+	}
+
+	rule, err := ruleParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Field = strs["field"]
+	rule.template = strs["message"]
+
+	afn := func(_ context.Context, input dag.Filterable) {
+		failure := *rule
+		failure.Err = &FieldError{
+			Field:   strs["field"],
+			Rule:    strs["rule"],
+			Param:   strs["param"],
+			Message: strs["message"],
+		}
+
+		recordFailure(input, &failure)
 	}
 
 	return afn, nil
@@ -181,4 +283,100 @@ func (act *actions) logAction(params dag.ActionParams) (dag.ActionFn, error) {
 	return afn, nil
 }
 
+// ** Functions:
+
+// Return a failure template holding the rule parameters added by
+// `Validator.Compile`, along with the `code` and `severity` parameters.
+//
+// If any of them, other than the expected value, is not a string then
+// `ErrExpectedString` is returned.  If the severity is not known then
+// `ErrInvalidSeverity` is returned.
+func ruleParams(params dag.ActionParams) (*Failure, error) {
+	names := []string{
+		paramRuleName,
+		paramRuleReason,
+		paramRuleField,
+		paramRuleOperator,
+		"code",
+		"severity",
+	}
+	strs := make(map[string]string, len(names))
+
+	for _, name := range names {
+		val, okay := params[name]
+		if !okay {
+			continue
+		}
+
+		sval, okay := val.(string)
+		if !okay {
+			return nil, errors.WithMessagef(dag.ErrExpectedString,
+				"Parameter %q",
+				name)
+		}
+
+		strs[name] = sval
+	}
+
+	severity := SeverityError
+
+	if name, ok := strs["severity"]; ok {
+		parsed, err := ParseSeverity(name)
+		if err != nil {
+			return nil, err
+		}
+
+		severity = parsed
+	}
+
+	failure := &Failure{
+		Expected: params[paramRuleExpected],
+		Field:    strs[paramRuleField],
+		Rule:     strs[paramRuleName],
+		Reason:   strs[paramRuleReason],
+		Operator: strs[paramRuleOperator],
+		Code:     strs["code"],
+		Severity: severity,
+	}
+
+	return failure, nil
+}
+
+// Record a failure in the result of the evaluation the input belongs to.
+//
+// Inputs that are not bound objects have no result, so the failure is
+// dropped.
+func recordFailure(input dag.Filterable, failure *Failure) {
+	if bound, ok := input.(*BoundObject); ok && bound.result != nil {
+		failure.Actual = actualValue(bound, failure.Field)
+		bound.result.add(failure)
+	}
+}
+
+// Return the value of the given field in the bound object.
+//
+// If the field path has wildcards then the values of every element it
+// expands to are returned.
+func actualValue(bound *BoundObject, field string) any {
+	if len(field) == 0 {
+		return nil
+	}
+
+	path, err := parsePath(field)
+	if err != nil {
+		return nil
+	}
+
+	if path.wildcard() >= 0 {
+		pred := &quantifiedPredicate{key: field, path: path}
+		values, _ := pred.Actual(bound)
+
+		return values
+	}
+
+	val, _ := bound.GetValue(field)
+
+	return val
+}
+
 // * actions.go ends here.
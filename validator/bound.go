@@ -35,6 +35,10 @@
 
 package validator
 
+// * Imports:
+
+import "reflect"
+
 // * Code:
 
 // ** Types:
@@ -42,33 +46,58 @@ package validator
 // Structure binding object.
 //
 // Implements `dag.Filterable`.
+//
+// Keys are field names or field paths; see path.go.
 type BoundObject struct {
 	Descriptor *StructDescriptor
 	Binding    any
+
+	key    string    // Key bound to `path`, if any.
+	path   fieldPath // Concrete path substituted for `key`.
+	result *Result   // Result of the evaluation in progress.
 }
 
 // Get the value for the given key from the bound object.
 //
-// This works by using the accessor obtained via reflection during the
-// predicate building phase.
+// Plain field names are looked up via the accessor obtained via reflection
+// during the predicate building phase.  Field paths are walked.
 func (bo *BoundObject) GetValue(key string) (any, bool) {
-	field, ok := bo.Descriptor.Get(key)
-	if !ok {
-		return nil, false
+	if bo.path == nil && isPlainField(key) {
+		field, ok := bo.Descriptor.Get(key)
+		if !ok {
+			return nil, false
+		}
+
+		finfo, finfoOk := field.(*FieldInfo)
+		if !finfoOk {
+			return nil, false
+		}
+
+		val := finfo.Accessor(bo.Binding)
+
+		return val, true
 	}
 
-	finfo, finfoOk := field.(*FieldInfo)
-	if !finfoOk {
+	val, _, ok := bo.resolve(key)
+	if !ok {
 		return nil, false
 	}
 
-	val := finfo.Accessor(bo.Binding)
-
-	return val, true
+	return val.Interface(), true
 }
 
+// Get the field information for the given key.
 func (bo *BoundObject) Get(key string) (any, bool) {
-	return bo.Descriptor.Get(key)
+	if bo.path == nil && isPlainField(key) {
+		return bo.Descriptor.Get(key)
+	}
+
+	_, finfo, ok := bo.resolve(key)
+	if !ok {
+		return nil, false
+	}
+
+	return finfo, true
 }
 
 func (bo *BoundObject) Set(_ string, _ any) bool {
@@ -83,4 +112,46 @@ func (bo *BoundObject) String() string {
 	return bo.Descriptor.String()
 }
 
+// Walk the path named by the given key.
+func (bo *BoundObject) resolve(key string) (reflect.Value, *FieldInfo, bool) {
+	path := bo.path
+
+	if key != bo.key || path == nil {
+		parsed, err := parsePath(key)
+		if err != nil {
+			return reflect.Value{}, nil, false
+		}
+
+		path = parsed
+	}
+
+	return bo.walk(path)
+}
+
+// Walk the given path.
+func (bo *BoundObject) walk(path fieldPath) (reflect.Value, *FieldInfo, bool) {
+	return walkPath(bo.Descriptor, reflect.ValueOf(bo.Binding), path)
+}
+
+// Return a copy of the bound object in which the given key names the
+// given concrete path.
+func (bo *BoundObject) withPath(key string, path fieldPath) *BoundObject {
+	return &BoundObject{
+		Descriptor: bo.Descriptor,
+		Binding:    bo.Binding,
+		key:        key,
+		path:       path,
+		result:     bo.result,
+	}
+}
+
+// Return a copy of the bound object that records failures in the given
+// result.
+func (bo *BoundObject) withResult(result *Result) *BoundObject {
+	dup := *bo
+	dup.result = result
+
+	return &dup
+}
+
 // * bound.go ends here.
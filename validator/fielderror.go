@@ -0,0 +1,71 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// fielderror.go --- Field validation errors.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import "fmt"
+
+// * Code:
+
+// ** Types:
+
+// A validation failure for a single field.
+//
+// Generated by the `field-error` failure action.
+type FieldError struct {
+	Field   string // Field path.
+	Rule    string // Validation rule, e.g. `min`.
+	Param   string // Parameter given to the rule, if any.
+	Message string // Human-readable message.
+}
+
+// ** Methods:
+
+// Return the error message.
+func (fe *FieldError) Error() string {
+	if len(fe.Message) > 0 {
+		return fe.Message
+	}
+
+	if len(fe.Param) > 0 {
+		return fmt.Sprintf("%s failed %s=%s", fe.Field, fe.Rule, fe.Param)
+	}
+
+	return fmt.Sprintf("%s failed %s", fe.Field, fe.Rule)
+}
+
+// * fielderror.go ends here.
@@ -54,12 +54,15 @@ type FieldInfo struct {
 	Type        reflect.Type
 	ElementType reflect.Type
 	Accessor    FieldAccessorFn
+	Descriptor  *StructDescriptor // Nested structure, if `KindStruct`.
+	Element     *FieldInfo        // Elements, if `KindSlice` or `KindMap`.
 	Name        string
 	TypeName    string
 	Tags        reflect.StructTag
 	TypeKind    reflect.Kind
 	Kind        FieldKind
 	ElementKind FieldKind
+	Index       int // Index within the enclosing structure.
 }
 
 // ** Methods:
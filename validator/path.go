@@ -0,0 +1,467 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// path.go --- Field paths.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A field path names a value within a bound structure.  It is a field
+// name, optionally followed by any number of selectors and further field
+// names:
+//
+//	address.postcode      Field of a nested structure.
+//	items[0].price        Element of a slice or array.
+//	labels["env"]         Element of a map; the quotes are optional.
+//	items[*].price        Every element.
+//	items[?].price        Any element.
+//
+// Field names are matched without regard to case, as with plain field
+// names.  Pointers and interfaces are followed as the path is walked.
+//
+// A predicate on a path containing `[*]` holds if it holds for every
+// element, and so holds for an empty slice or map.  One on a path
+// containing `[?]` holds if it holds for at least one element.  Nested
+// wildcards are applied from left to right.
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	stepField stepKind = iota // Structure field.
+	stepIndex                 // Slice, array or map element by number.
+	stepKey                   // Map element by key.
+	stepAll                   // Every element.
+	stepAny                   // Any element.
+)
+
+// * Variables:
+
+var (
+	ErrInvalidPath = errors.Base("invalid field path")
+
+	// Parsed field paths.
+	//
+	//nolint:gochecknoglobals
+	pathCache sync.Map
+)
+
+// * Code:
+
+// ** Types:
+
+type stepKind int
+
+// A single step in a field path.
+type pathStep struct {
+	name  string   // Field name, map key, or index as written.
+	index int      // Element index.
+	kind  stepKind // Kind of step.
+}
+
+// A parsed field path.
+type fieldPath []pathStep
+
+// ** Methods:
+
+// Return the string representation of the path.
+func (path fieldPath) String() string {
+	var sbld strings.Builder
+
+	for idx, step := range path {
+		switch step.kind {
+		case stepField:
+			if idx > 0 {
+				sbld.WriteRune('.')
+			}
+
+			sbld.WriteString(step.name)
+
+		case stepIndex:
+			sbld.WriteString("[" + step.name + "]")
+
+		case stepKey:
+			sbld.WriteString("[" + strconv.Quote(step.name) + "]")
+
+		case stepAll:
+			sbld.WriteString("[*]")
+
+		case stepAny:
+			sbld.WriteString("[?]")
+		}
+	}
+
+	return sbld.String()
+}
+
+// Return the position of the first wildcard in the path, or -1.
+func (path fieldPath) wildcard() int {
+	return slices.IndexFunc(path, func(step pathStep) bool {
+		return step.kind == stepAll || step.kind == stepAny
+	})
+}
+
+// ** Functions:
+
+// *** Parsing:
+
+// Is the key a plain field name rather than a path?
+func isPlainField(key string) bool {
+	return !strings.ContainsAny(key, ".[")
+}
+
+// Parse a field path.
+func parsePath(path string) (fieldPath, error) {
+	if cached, found := pathCache.Load(path); found {
+		parsed, _ := cached.(fieldPath)
+
+		return parsed, nil
+	}
+
+	parsed := make(fieldPath, 0)
+	rest := path
+
+	for {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+
+		if end == 0 {
+			return nil, errors.WithMessagef(
+				ErrInvalidPath,
+				"%q: expected field name",
+				path)
+		}
+
+		parsed = append(parsed, pathStep{name: rest[:end], kind: stepField})
+		rest = rest[end:]
+
+		for strings.HasPrefix(rest, "[") {
+			step, remain, err := parseSelector(rest)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "%q", path)
+			}
+
+			parsed = append(parsed, step)
+			rest = remain
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+
+		if rest[0] != '.' {
+			return nil, errors.WithMessagef(
+				ErrInvalidPath,
+				"%q: unexpected %q",
+				path,
+				rest)
+		}
+
+		rest = rest[1:]
+	}
+
+	pathCache.Store(path, parsed)
+
+	return parsed, nil
+}
+
+// Parse a bracketed selector at the start of the string.
+//
+// Returns the step and the remainder of the string.
+func parseSelector(str string) (pathStep, string, error) {
+	body := str[1:]
+
+	if strings.HasPrefix(body, `"`) {
+		quoted, err := strconv.QuotedPrefix(body)
+		if err != nil || !strings.HasPrefix(body[len(quoted):], "]") {
+			return pathStep{}, "", errors.WithMessage(
+				ErrInvalidPath,
+				"malformed quoted key")
+		}
+
+		key, _ := strconv.Unquote(quoted)
+
+		return pathStep{name: key, kind: stepKey}, body[len(quoted)+1:], nil
+	}
+
+	end := strings.IndexRune(body, ']')
+	if end <= 0 {
+		return pathStep{}, "", errors.WithMessage(
+			ErrInvalidPath,
+			"empty or unterminated selector")
+	}
+
+	sel := body[:end]
+	rest := body[end+1:]
+
+	switch sel {
+	case "*":
+		return pathStep{name: sel, kind: stepAll}, rest, nil
+
+	case "?":
+		return pathStep{name: sel, kind: stepAny}, rest, nil
+	}
+
+	if index, err := strconv.Atoi(sel); err == nil && index >= 0 {
+		return pathStep{name: sel, index: index, kind: stepIndex}, rest, nil
+	}
+
+	return pathStep{name: sel, kind: stepKey}, rest, nil
+}
+
+// *** Walking:
+
+// Follow pointers and interfaces.
+//
+// Returns an invalid value if a nil pointer or interface is found.
+func indirect(val reflect.Value) reflect.Value {
+	for val.IsValid() &&
+		(val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface) {
+		if val.IsNil() {
+			return reflect.Value{}
+		}
+
+		val = val.Elem()
+	}
+
+	return val
+}
+
+// Walk a path from the given structure value.
+//
+// Returns the value at the end of the path along with its field
+// information.  The path must not contain wildcards.
+func walkPath(desc *StructDescriptor, val reflect.Value, path fieldPath) (reflect.Value, *FieldInfo, bool) {
+	var finfo *FieldInfo
+
+	for _, step := range path {
+		val = indirect(val)
+		if !val.IsValid() {
+			return reflect.Value{}, nil, false
+		}
+
+		switch step.kind {
+		case stepField:
+			if val.Kind() != reflect.Struct {
+				return reflect.Value{}, nil, false
+			}
+
+			if finfo != nil {
+				desc = finfo.Descriptor
+			}
+
+			// Interfaces can hold any structure.
+			if desc == nil || desc.Type != val.Type() {
+				desc = sharedDescriptor(val.Type())
+			}
+
+			field, found := desc.Find(step.name)
+			if !found {
+				return reflect.Value{}, nil, false
+			}
+
+			finfo, _ = field.(*FieldInfo)
+			val = val.Field(finfo.Index)
+
+		case stepIndex, stepKey:
+			elem, found := selectElement(val, step)
+			if !found {
+				return reflect.Value{}, nil, false
+			}
+
+			finfo = elementInfo(finfo, val.Type())
+			val = elem
+
+		case stepAll, stepAny:
+			return reflect.Value{}, nil, false
+		}
+	}
+
+	return val, finfo, finfo != nil && val.CanInterface()
+}
+
+// Return the field information for elements of the given container.
+func elementInfo(container *FieldInfo, typ reflect.Type) *FieldInfo {
+	if container != nil &&
+		container.Element != nil &&
+		container.Element.Type == typ.Elem() {
+		return container.Element
+	}
+
+	descriptorMu.Lock()
+	defer descriptorMu.Unlock()
+
+	return describeType("[]", typ.Elem(), map[reflect.Type]*FieldInfo{})
+}
+
+// Select an element of a slice, array or map.
+//
+//nolint:exhaustive
+func selectElement(val reflect.Value, step pathStep) (reflect.Value, bool) {
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		if step.kind != stepIndex || step.index >= val.Len() {
+			return reflect.Value{}, false
+		}
+
+		return val.Index(step.index), true
+
+	case reflect.Map:
+		key, ok := mapKey(val.Type().Key(), step.name)
+		if !ok {
+			return reflect.Value{}, false
+		}
+
+		elem := val.MapIndex(key)
+
+		return elem, elem.IsValid()
+
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// Convert a map key as written in a path to the map's key type.
+//
+//nolint:exhaustive
+func mapKey(typ reflect.Type, name string) (reflect.Value, bool) {
+	key := reflect.New(typ).Elem()
+
+	switch typ.Kind() {
+	case reflect.String:
+		key.SetString(name)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, err := strconv.ParseInt(name, 10, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, false
+		}
+
+		key.SetInt(num)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, err := strconv.ParseUint(name, 10, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, false
+		}
+
+		key.SetUint(num)
+
+	default:
+		return reflect.Value{}, false
+	}
+
+	return key, true
+}
+
+// Return a step selecting each element of a slice, array or map.
+//
+// Map elements are returned ordered by their keys as written in a path.
+//
+//nolint:exhaustive
+func elementSteps(val reflect.Value) ([]pathStep, bool) {
+	val = indirect(val)
+
+	switch {
+	case !val.IsValid():
+		return nil, false
+
+	case val.Kind() == reflect.Slice || val.Kind() == reflect.Array:
+		steps := make([]pathStep, 0, val.Len())
+
+		for idx := range val.Len() {
+			steps = append(steps, pathStep{
+				name:  strconv.Itoa(idx),
+				index: idx,
+				kind:  stepIndex,
+			})
+		}
+
+		return steps, true
+
+	case val.Kind() == reflect.Map:
+		steps := make([]pathStep, 0, val.Len())
+
+		for _, key := range val.MapKeys() {
+			name, ok := keyName(key)
+			if !ok {
+				return nil, false
+			}
+
+			steps = append(steps, pathStep{name: name, kind: stepKey})
+		}
+
+		slices.SortFunc(steps, func(lhs, rhs pathStep) int {
+			return strings.Compare(lhs.name, rhs.name)
+		})
+
+		return steps, true
+
+	default:
+		return nil, false
+	}
+}
+
+// Return a map key as it would be written in a path.
+//
+//nolint:exhaustive
+func keyName(key reflect.Value) (string, bool) {
+	switch key.Kind() {
+	case reflect.String:
+		return key.String(), true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(key.Uint(), 10), true
+
+	default:
+		return "", false
+	}
+}
+
+// * path.go ends here.
@@ -0,0 +1,301 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// path_test.go --- Field path tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Asmodai/gohacks/dag"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Types:
+
+type testPathAddress struct {
+	Postcode string
+	Lines    []string
+}
+
+type testPathItem struct {
+	Name  string
+	Price float64
+}
+
+type testPathStruct struct {
+	Address *testPathAddress
+	Items   []testPathItem
+	Labels  map[string]string
+	Counts  map[int]int
+	Extra   any
+	Next    *testPathStruct
+	Name    string
+}
+
+type testPathTree map[string]testPathTree
+
+type testPathList []testPathList
+
+type testPathRecursive struct {
+	Tree testPathTree
+	List testPathList
+}
+
+// ** Tests:
+
+func TestParsePath(t *testing.T) {
+	valid := []string{
+		"name",
+		"address.postcode",
+		"items[0].price",
+		`labels["env"]`,
+		"labels[env]",
+		`labels["a.b]"]`,
+		"items[*].price",
+		"items[?].price",
+		"a[*][?].b",
+	}
+
+	for _, path := range valid {
+		t.Run(path, func(t *testing.T) {
+			parsed, err := parsePath(path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			// Formatting and parsing again gives the same path.
+			again, err := parsePath(parsed.String())
+			if err != nil || !reflect.DeepEqual(parsed, again) {
+				t.Errorf("Round trip mismatch: %v != %v", parsed, again)
+			}
+		})
+	}
+
+	invalid := []string{"", ".name", "name.", "items[", "items[]", "items[0]x", "[0]", `labels["env]`}
+
+	for _, path := range invalid {
+		t.Run(path, func(t *testing.T) {
+			if _, err := parsePath(path); !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("Unexpected error: %#v", err)
+			}
+		})
+	}
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	desc := BuildDescriptor(reflect.TypeOf(testPathRecursive{}))
+
+	for _, name := range []string{"Tree", "List"} {
+		finfo := desc.Fields[name]
+		if finfo == nil || finfo.Element != finfo {
+			t.Errorf("%s: element was not shared: %v", name, finfo)
+		}
+	}
+
+	input := &testPathRecursive{
+		Tree: testPathTree{"a": {"b": {"c": nil}}},
+		List: testPathList{nil, {nil, nil}},
+	}
+
+	bindings := NewBindings()
+	bindings.BuildWithReflection(input)
+	obj, _ := bindings.BindWithReflection(input)
+
+	if val, found := obj.GetValue(`tree["a"]["b"]`); !found || len(val.(testPathTree)) != 1 {
+		t.Errorf("Unexpected tree value: %v, %v", val, found)
+	}
+
+	if val, found := obj.GetValue("list[1][1]"); !found || val.(testPathList) != nil {
+		t.Errorf("Unexpected list value: %v, %v", val, found)
+	}
+}
+
+func TestBoundObjectPaths(t *testing.T) {
+	input := &testPathStruct{
+		Address: &testPathAddress{Postcode: "SW1A 1AA", Lines: []string{"10 Downing St"}},
+		Items:   []testPathItem{{"tea", 2.5}, {"coffee", 3}},
+		Labels:  map[string]string{"env": "prod"},
+		Counts:  map[int]int{7: 49},
+		Extra:   &testPathAddress{Postcode: "EC1A 1BB"},
+		Next:    &testPathStruct{Name: "next"},
+	}
+
+	bindings := NewBindings()
+	bindings.BuildWithReflection(input)
+	obj, _ := bindings.BindWithReflection(input)
+
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{"address.postcode", "SW1A 1AA", true},
+		{"Address.Lines[0]", "10 Downing St", true},
+		{"items[1].name", "coffee", true},
+		{"items[2].name", nil, false},
+		{`labels["env"]`, "prod", true},
+		{"labels[env]", "prod", true},
+		{"labels[missing]", nil, false},
+		{"counts[7]", 49, true},
+		{"extra.postcode", "EC1A 1BB", true},
+		{"next.name", "next", true},
+		{"next.next.name", nil, false},
+		{"name.first", nil, false},
+		{"items[*].name", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			val, found := obj.GetValue(test.path)
+			if found != test.found || (found && val != test.want) {
+				t.Errorf("GetValue mismatch: %v, %v", val, found)
+			}
+
+			if _, found := obj.Get(test.path); found != test.found {
+				t.Errorf("Get mismatch: %v", found)
+			}
+		})
+	}
+
+	t.Run("Field information", func(t *testing.T) {
+		data, _ := obj.Get("items[0].price")
+		finfo, _ := data.(*FieldInfo)
+
+		if finfo == nil || finfo.TypeKind != reflect.Float64 || finfo.Name != "Price" {
+			t.Errorf("Unexpected field information: %v", finfo)
+		}
+
+		desc := BuildDescriptor(reflect.TypeOf(input))
+		if desc.Fields["Next"].Descriptor != desc {
+			t.Error("Self-referential descriptor was not shared")
+		}
+	})
+
+	t.Run("Copies", func(t *testing.T) {
+		desc := BuildDescriptor(reflect.TypeOf(input))
+		desc.Fields["Address"].Descriptor.Fields["Postcode"].Name = "Changed"
+		delete(desc.Fields, "Name")
+
+		other := BuildDescriptor(reflect.TypeOf(input))
+		if _, found := other.Fields["Name"]; !found {
+			t.Error("Field removal was shared")
+		}
+
+		if name := other.Fields["Address"].Descriptor.Fields["Postcode"].Name; name != "Postcode" {
+			t.Errorf("Nested modification was shared: %q", name)
+		}
+
+		if val, found := obj.GetValue("address.postcode"); !found || val != "SW1A 1AA" {
+			t.Errorf("Bound object affected: %v, %v", val, found)
+		}
+	})
+}
+
+func TestQuantifiedPaths(t *testing.T) {
+	input := &testPathStruct{
+		Items:  []testPathItem{{"tea", 2.5}, {"coffee", 3}},
+		Labels: map[string]string{"env": "prod", "tier": "web"},
+	}
+
+	bindings := NewBindings()
+	bindings.BuildWithReflection(input)
+	obj, _ := bindings.BindWithReflection(input)
+	empty, _ := bindings.BindWithReflection(&testPathStruct{})
+	dict := BuildPredicateDict()
+
+	tests := []struct {
+		token string
+		path  string
+		val   any
+		want  bool
+		empty bool
+	}{
+		{fvgtToken, "items[*].price", 2, true, true},
+		{fvgtToken, "items[*].price", 2.75, false, true},
+		{fvgtToken, "items[?].price", 2.75, true, false},
+		{fvgtToken, "items[?].price", 5, false, false},
+		{fveqToken, "labels[?]", "web", true, false},
+		{fveqToken, "labels[*]", "web", false, true},
+		{fteqToken, "items[*].name", "string", true, true},
+	}
+
+	for idx, test := range tests {
+		name := fmt.Sprintf("%02d %s %s %v", idx, test.path, test.token, test.val)
+
+		t.Run(name, func(t *testing.T) {
+			pred, err := dict[test.token].Build(test.path, test.val, nil, false)
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			if got := pred.Eval(context.TODO(), obj); got != test.want {
+				t.Errorf("Result mismatch: %v != %v", got, test.want)
+			}
+
+			if got := pred.Eval(context.TODO(), empty); got != test.empty {
+				t.Errorf("Empty result mismatch: %v != %v", got, test.empty)
+			}
+		})
+	}
+
+	t.Run("Actual", func(t *testing.T) {
+		pred, _ := dict[fvgtToken].Build("items[?].price", 0, nil, false)
+
+		traceable, ok := pred.(dag.TraceablePredicate)
+		if !ok {
+			t.Fatal("Predicate is not traceable")
+		}
+
+		got, _ := traceable.Actual(obj)
+		if !reflect.DeepEqual(got, []any{2.5, float64(3)}) {
+			t.Errorf("Actual mismatch: %v", got)
+		}
+	})
+
+	t.Run("Invalid path", func(t *testing.T) {
+		if _, err := dict[fvgtToken].Build("items[", 0, nil, false); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Unexpected error: %#v", err)
+		}
+	})
+}
+
+// * path_test.go ends here.
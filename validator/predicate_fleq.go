@@ -0,0 +1,128 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_fleq.go --- FLEQ - Field Length Equals.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package validator
+
+// * Imports:
+
+import (
+	"context"
+
+	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	fleqIsn   = "FLEQ"
+	fleqToken = "field-length-equal" //nolint:gosec
+)
+
+// * Variables:
+
+var (
+	ErrInvalidLength = errors.Base("invalid length")
+)
+
+// * Code:
+
+// ** Predicate:
+
+// Field Length Equals.
+//
+// This predicate returns true if the length of the value in the structure
+// is equal to the value in the predicate.
+//
+// See `MetaPredicate.GetKeyAsLength` for how length is measured.
+type FLEQPredicate struct {
+	length int64
+
+	MetaPredicate
+}
+
+func (pred *FLEQPredicate) Instruction() string {
+	return fleqIsn
+}
+
+func (pred *FLEQPredicate) Token() string {
+	return fleqToken
+}
+
+func (pred *FLEQPredicate) String() string {
+	return pred.MetaPredicate.String(fleqToken)
+}
+
+func (pred *FLEQPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(fleqIsn, fleqToken)
+}
+
+func (pred *FLEQPredicate) Eval(_ context.Context, input dag.Filterable) bool {
+	length, ok := pred.MetaPredicate.GetKeyAsLength(input)
+
+	return ok && int64(length) == pred.length
+}
+
+// ** Builder:
+
+type FLEQBuilder struct{}
+
+func (bld *FLEQBuilder) Token() string {
+	return fleqToken
+}
+
+func (bld *FLEQBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (dag.Predicate, error) {
+	meta := MetaPredicate{
+		key:    key,
+		val:    val,
+		logger: lgr,
+		debug:  dbg,
+	}
+
+	length, ok := meta.GetValueAsInt64()
+	if !ok || length < 0 {
+		return nil, errors.WithMessagef(
+			ErrInvalidLength,
+			"%s: value %v",
+			fleqToken,
+			val)
+	}
+
+	return &FLEQPredicate{MetaPredicate: meta, length: length}, nil
+}
+
+// * predicate_fleq.go ends here.
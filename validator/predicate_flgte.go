@@ -0,0 +1,122 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_flgte.go --- FLGTE - Field Length is Greater or Equal To.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+//nolint:dupl
+package validator
+
+// * Imports:
+
+import (
+	"context"
+
+	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	flgteIsn   = "FLGTE"
+	flgteToken = "field-length->=" //nolint:gosec
+)
+
+// * Code:
+
+// ** Predicate:
+
+// Field Length is Greater or Equal To.
+//
+// This predicate returns true if the length of the value in the structure
+// is greater than or equal to the value in the predicate.
+//
+// See `MetaPredicate.GetKeyAsLength` for how length is measured.
+type FLGTEPredicate struct {
+	length int64
+
+	MetaPredicate
+}
+
+func (pred *FLGTEPredicate) Instruction() string {
+	return flgteIsn
+}
+
+func (pred *FLGTEPredicate) Token() string {
+	return flgteToken
+}
+
+func (pred *FLGTEPredicate) String() string {
+	return pred.MetaPredicate.String(flgteToken)
+}
+
+func (pred *FLGTEPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(flgteIsn, flgteToken)
+}
+
+func (pred *FLGTEPredicate) Eval(_ context.Context, input dag.Filterable) bool {
+	length, ok := pred.MetaPredicate.GetKeyAsLength(input)
+
+	return ok && int64(length) >= pred.length
+}
+
+// ** Builder:
+
+type FLGTEBuilder struct{}
+
+func (bld *FLGTEBuilder) Token() string {
+	return flgteToken
+}
+
+func (bld *FLGTEBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (dag.Predicate, error) {
+	meta := MetaPredicate{
+		key:    key,
+		val:    val,
+		logger: lgr,
+		debug:  dbg,
+	}
+
+	length, ok := meta.GetValueAsInt64()
+	if !ok || length < 0 {
+		return nil, errors.WithMessagef(
+			ErrInvalidLength,
+			"%s: value %v",
+			flgteToken,
+			val)
+	}
+
+	return &FLGTEPredicate{MetaPredicate: meta, length: length}, nil
+}
+
+// * predicate_flgte.go ends here.
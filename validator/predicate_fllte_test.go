@@ -0,0 +1,101 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_fllte_test.go --- FLLTE tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Tests:
+
+func TestFLLTEPredicate(t *testing.T) {
+	input := &testLengthStruct{
+		String:  "tea",
+		Unicode: "café",
+		Slice:   []int{1, 2},
+		Map:     map[string]int{"one": 1},
+		Int:     42,
+	}
+
+	tests := []struct {
+		field  string
+		length int
+		want   bool
+	}{
+		{"String", 3, true},
+		{"String", 2, false},
+		{"Unicode", 4, true},
+		{"Slice", 1, false},
+		{"Map", 1, true},
+		{"Nil", 5, false},
+		{"Int", 5, false},
+	}
+
+	bindings := NewBindings()
+	bindings.BuildWithReflection(input)
+	obj, _ := bindings.BindWithReflection(input)
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("%02d FLLTE(%s)", idx, tt.field), func(t *testing.T) {
+			pred, _ := (&FLLTEBuilder{}).Build(tt.field, tt.length, nil, false)
+			result := pred.Eval(context.TODO(), obj)
+
+			if result != tt.want {
+				t.Errorf("FLLTE(%s, %v) = %v, want %v",
+					tt.field,
+					tt.length,
+					result,
+					tt.want)
+			}
+		})
+	}
+
+	for _, val := range []any{nil, -1, "many"} {
+		if _, err := (&FLLTEBuilder{}).Build("String", val, nil, false); !errors.Is(err, ErrInvalidLength) {
+			t.Errorf("%v: unexpected error: %#v", val, err)
+		}
+	}
+}
+
+// * predicate_fllte_test.go ends here.
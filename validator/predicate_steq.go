@@ -0,0 +1,128 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_steq.go --- STEQ - Structure Type Equals.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"context"
+
+	"github.com/Asmodai/gohacks/conversion"
+	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	steqIsn   = "STEQ"
+	steqToken = "struct-type-equal"
+)
+
+// * Code:
+
+// ** Predicate:
+
+// Structure Type Equality.
+//
+// This predicate returns true if the bound structure's type, as given by
+// `reflect.Type.String`, is equal to the value in the predicate.
+//
+// It does not look at a field, so the attribute is ignored.  It allows
+// rules for different structures to share a validator by guarding each
+// rule with the type it applies to.
+type STEQPredicate struct {
+	MetaPredicate
+}
+
+func (pred *STEQPredicate) Instruction() string {
+	return steqIsn
+}
+
+func (pred *STEQPredicate) Token() string {
+	return steqToken
+}
+
+func (pred *STEQPredicate) String() string {
+	return pred.MetaPredicate.String(steqToken)
+}
+
+func (pred *STEQPredicate) Debug() string {
+	return pred.MetaPredicate.Debug(steqIsn, steqToken)
+}
+
+func (pred *STEQPredicate) Eval(_ context.Context, input dag.Filterable) bool {
+	bound, boundOk := input.(*BoundObject)
+	want, wantOk := pred.MetaPredicate.GetValueAsString()
+
+	if !(boundOk && wantOk) || bound.Descriptor == nil {
+		return false
+	}
+
+	return bound.Descriptor.Type.String() == want
+}
+
+// ** Builder:
+
+type STEQBuilder struct{}
+
+func (bld *STEQBuilder) Token() string {
+	return steqToken
+}
+
+func (bld *STEQBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (dag.Predicate, error) {
+	if _, ok := conversion.ToString(val); !ok {
+		return nil, errors.WithMessagef(
+			ErrValueNotString,
+			"%s: value %q",
+			steqToken,
+			val)
+	}
+
+	pred := &STEQPredicate{
+		MetaPredicate: MetaPredicate{
+			key:    key,
+			val:    val,
+			logger: lgr,
+			debug:  dbg,
+		},
+	}
+
+	return pred, nil
+}
+
+// * predicate_steq.go ends here.
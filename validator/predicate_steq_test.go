@@ -0,0 +1,77 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// predicate_steq_test.go --- STEQ tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Tests:
+
+func TestSTEQPredicate(t *testing.T) {
+	bindings := NewBindings()
+	bindings.BuildWithReflection(&testLengthStruct{})
+	bindings.BuildWithReflection(&testPathItem{})
+
+	length, _ := bindings.BindWithReflection(&testLengthStruct{})
+	item, _ := bindings.BindWithReflection(&testPathItem{})
+
+	pred, err := (&STEQBuilder{}).Build("", "validator.testLengthStruct", nil, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if !pred.Eval(context.TODO(), length) {
+		t.Error("Structure type did not match")
+	}
+
+	if pred.Eval(context.TODO(), item) {
+		t.Error("Unexpected match for other structure")
+	}
+
+	if _, err := (&STEQBuilder{}).Build("", nil, nil, false); !errors.Is(err, ErrValueNotString) {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
+
+// * predicate_steq_test.go ends here.
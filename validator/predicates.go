@@ -39,6 +39,8 @@ package validator
 
 import (
 	"fmt"
+	"reflect"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/Asmodai/gohacks/conversion"
@@ -89,6 +91,21 @@ func (meta *MetaPredicate) String(token string) string {
 	return fmt.Sprintf("%q %s%s", meta.key, token, match)
 }
 
+// Return the field on which the predicate operates.
+func (meta *MetaPredicate) Attribute() string {
+	return meta.key
+}
+
+// Return the condition value.
+func (meta *MetaPredicate) Expected() any {
+	return meta.val
+}
+
+// Return the field's value from the `Filterable`.
+func (meta *MetaPredicate) Actual(input dag.Filterable) (any, bool) {
+	return meta.GetKeyAsValue(input)
+}
+
 func (meta *MetaPredicate) GetValueAsAny() (any, bool) {
 	return meta.val, true
 }
@@ -158,6 +175,39 @@ func (meta *MetaPredicate) GetKeyAsFloat64(input dag.Filterable) (float64, bool)
 	return flt, dataOk && fltOk
 }
 
+// Return the length of the field's value.
+//
+// The length of a string is the number of runes in it.  Slices, arrays,
+// maps and channels have the length of their contents.  Pointers are
+// followed; other values have no length.
+//
+//nolint:exhaustive
+func (meta *MetaPredicate) GetKeyAsLength(input dag.Filterable) (int, bool) {
+	data, dataOk := meta.GetKeyAsValue(input)
+	if !dataOk {
+		return 0, false
+	}
+
+	val := indirect(reflect.ValueOf(data))
+
+	switch {
+	case !val.IsValid():
+		return 0, false
+
+	case val.Kind() == reflect.String:
+		return utf8.RuneCountInString(val.String()), true
+
+	case val.Kind() == reflect.Slice,
+		val.Kind() == reflect.Array,
+		val.Kind() == reflect.Map,
+		val.Kind() == reflect.Chan:
+		return val.Len(), true
+
+	default:
+		return 0, false
+	}
+}
+
 // ** Functions:
 
 func BuildPredicateDict() dag.PredicateDict {
@@ -181,14 +231,23 @@ func BuildPredicateDict() dag.PredicateDict {
 		&FVNEQBuilder{},   // Field Value Not Equals.
 		&FVINBuilder{},    // Field Value In.
 		&FVREMBuilder{},   // Field Value Regex Match.
+
+		//
+		// Field length predicates.
+		&FLEQBuilder{},  // Field Length Equals.
+		&FLGTEBuilder{}, // Field Length is Greater or Equal To.
+		&FLLTEBuilder{}, // Field Length is Lesser or Equal To.
 	}
 
 	for idx := range preds {
 		pred := preds[idx]
 
-		result[pred.Token()] = pred
+		result[pred.Token()] = &pathBuilder{PredicateBuilder: pred}
 	}
 
+	// Structure predicates do not take a field path.
+	result[steqToken] = &STEQBuilder{} // Structure Type Equals.
+
 	return result
 }
 
@@ -0,0 +1,183 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// quantifier.go --- Wildcard path predicates.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// Predicates are written against a single value.  A predicate whose field
+// path contains wildcards is wrapped so that it is evaluated once for each
+// concrete path the wildcards expand to, with the results combined as
+// described in path.go.
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Types:
+
+// Predicate builder that validates field paths.
+//
+// Implements `dag.PredicateBuilder`.
+type pathBuilder struct {
+	dag.PredicateBuilder
+}
+
+// Predicate over a field path containing wildcards.
+//
+// Implements `dag.Predicate`.
+type quantifiedPredicate struct {
+	dag.Predicate
+
+	key  string
+	path fieldPath
+}
+
+// ** Methods:
+
+// *** Path builder:
+
+func (bld *pathBuilder) Build(key string, val any, lgr logger.Logger, dbg bool) (dag.Predicate, error) {
+	path, err := parsePath(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	pred, err := bld.PredicateBuilder.Build(key, val, lgr, dbg)
+	if err != nil || path.wildcard() < 0 {
+		return pred, errors.WithStack(err)
+	}
+
+	return &quantifiedPredicate{Predicate: pred, key: key, path: path}, nil
+}
+
+// *** Quantified predicate:
+
+func (pred *quantifiedPredicate) Eval(ctx context.Context, input dag.Filterable) bool {
+	bound, ok := input.(*BoundObject)
+	if !ok {
+		return false
+	}
+
+	return pred.expand(bound, nil, pred.path, false, func(obj *BoundObject) bool {
+		return pred.Predicate.Eval(ctx, obj)
+	})
+}
+
+// Return the field on which the predicate operates.
+func (pred *quantifiedPredicate) Attribute() string {
+	return pred.key
+}
+
+// Return the condition value.
+func (pred *quantifiedPredicate) Expected() any {
+	if traceable, ok := pred.Predicate.(dag.TraceablePredicate); ok {
+		return traceable.Expected()
+	}
+
+	return nil
+}
+
+// Return the values of every element the path expands to.
+func (pred *quantifiedPredicate) Actual(input dag.Filterable) (any, bool) {
+	bound, ok := input.(*BoundObject)
+	if !ok {
+		return nil, false
+	}
+
+	values := make([]any, 0)
+
+	pred.expand(bound, nil, pred.path, true, func(obj *BoundObject) bool {
+		if val, found := obj.GetValue(pred.key); found {
+			values = append(values, val)
+		}
+
+		return true
+	})
+
+	return values, true
+}
+
+// Expand the first wildcard in `rest`, calling `eval` with a bound object
+// for each concrete path once no wildcards remain.
+//
+// `done` is the part of the path that has already been expanded.  Unless
+// `exhaustive` is set, expansion stops as soon as the result is known.
+func (pred *quantifiedPredicate) expand(
+	bound *BoundObject,
+	done, rest fieldPath,
+	exhaustive bool,
+	eval func(*BoundObject) bool,
+) bool {
+	idx := rest.wildcard()
+	if idx < 0 {
+		return eval(bound.withPath(pred.key, slices.Concat(done, rest)))
+	}
+
+	prefix := slices.Concat(done, rest[:idx])
+
+	container, _, ok := bound.walk(prefix)
+	if !ok {
+		return false
+	}
+
+	steps, ok := elementSteps(container)
+	if !ok {
+		return false
+	}
+
+	every := rest[idx].kind == stepAll
+
+	for _, step := range steps {
+		path := append(slices.Clip(prefix), step)
+
+		result := pred.expand(bound, path, rest[idx+1:], exhaustive, eval)
+		if result != every && !exhaustive {
+			return !every
+		}
+	}
+
+	return every
+}
+
+// * quantifier.go ends here.
@@ -39,6 +39,7 @@ package validator
 
 import (
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -68,6 +69,14 @@ var (
 
 	//nolint:gochecknoglobals
 	baseTypeTimeType = reflect.TypeOf(time.Time{})
+
+	// Structure descriptors, by type.
+	//
+	//nolint:gochecknoglobals
+	descriptorCache = map[reflect.Type]*StructDescriptor{}
+
+	//nolint:gochecknoglobals
+	descriptorMu sync.Mutex
 )
 
 // * Code:
@@ -76,6 +85,61 @@ var (
 
 type FieldKind int
 
+// Deep copier for descriptors.
+//
+// Copies are remembered, so shared and self-referential descriptors and
+// field information are copied once and stay shared in the copy.
+type descriptorCopier struct {
+	descs  map[*StructDescriptor]*StructDescriptor
+	fields map[*FieldInfo]*FieldInfo
+}
+
+// ** Methods:
+
+// Copy a descriptor.
+func (dc *descriptorCopier) descriptor(desc *StructDescriptor) *StructDescriptor {
+	if desc == nil {
+		return nil
+	}
+
+	if dup, found := dc.descs[desc]; found {
+		return dup
+	}
+
+	dup := &StructDescriptor{
+		Type:     desc.Type,
+		TypeName: desc.TypeName,
+		Fields:   make(map[string]*FieldInfo, len(desc.Fields)),
+	}
+
+	dc.descs[desc] = dup
+
+	for name, finfo := range desc.Fields {
+		dup.Fields[name] = dc.field(finfo)
+	}
+
+	return dup
+}
+
+// Copy field information.
+func (dc *descriptorCopier) field(finfo *FieldInfo) *FieldInfo {
+	if finfo == nil {
+		return nil
+	}
+
+	if dup, found := dc.fields[finfo]; found {
+		return dup
+	}
+
+	dup := *finfo
+	dc.fields[finfo] = &dup
+
+	dup.Descriptor = dc.descriptor(finfo.Descriptor)
+	dup.Element = dc.field(finfo.Element)
+
+	return &dup
+}
+
 // ** Functions:
 
 func KindToString(kind FieldKind) string {
@@ -123,20 +187,71 @@ func classifyField(typ reflect.Type) FieldKind {
 	}
 }
 
-func reflectField(index int, field reflect.StructField) *FieldInfo {
-	if len(field.PkgPath) > 0 {
-		return nil
+// Return the structure type a value of the given type leads to, if any.
+//
+// Pointers are followed.  `time.Time` and types derived from it are not
+// treated as structures.
+func structType(typ reflect.Type) (reflect.Type, bool) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct || isDerivedFrom(typ, baseTypeTimeType) {
+		return nil, false
+	}
+
+	return typ, true
+}
+
+// Describe a value of the given type.
+//
+// Nested structures, and the elements of slices and maps, are described
+// recursively.  The caller must hold `descriptorMu`.
+//
+// `visiting` holds the slice and map types being described.  A type such
+// as `type Tree map[string]Tree` is its own element, so its element is
+// given the information being built for it rather than being described
+// again.
+func describeType(name string, typ reflect.Type, visiting map[reflect.Type]*FieldInfo) *FieldInfo {
+	if finfo, found := visiting[typ]; found {
+		return finfo
+	}
+
+	finfo := &FieldInfo{
+		Name:     name,
+		Type:     typ,
+		TypeName: typ.String(),
+		TypeKind: typ.Kind(),
+		Kind:     classifyField(typ),
+	}
+
+	switch finfo.Kind {
+	case KindStruct:
+		if styp, ok := structType(typ); ok {
+			finfo.Descriptor = buildDescriptorLocked(styp)
+		}
+
+	case KindSlice, KindMap:
+		elem := typ
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+
+		visiting[typ] = finfo
+		finfo.Element = describeType(name+"[]", elem.Elem(), visiting)
+		finfo.ElementType = finfo.Element.Type
+		finfo.ElementKind = finfo.Element.Kind
+
+	default:
 	}
 
-	var (
-		elemType reflect.Type
-		elemKind FieldKind
-	)
+	return finfo
+}
 
-	kind := classifyField(field.Type)
-	if kind == KindSlice || kind == KindMap {
-		elemType = field.Type.Elem()
-		elemKind = classifyField(elemType)
+// The caller must hold `descriptorMu`.
+func reflectField(index int, field reflect.StructField) *FieldInfo {
+	if len(field.PkgPath) > 0 {
+		return nil
 	}
 
 	accessor := func(instance any) any {
@@ -146,28 +261,29 @@ func reflectField(index int, field reflect.StructField) *FieldInfo {
 			Interface()
 	}
 
-	return &FieldInfo{
-		Name:        field.Name,
-		Type:        field.Type,
-		TypeName:    field.Type.String(),
-		TypeKind:    field.Type.Kind(),
-		Accessor:    accessor,
-		Tags:        field.Tag,
-		Kind:        kind,
-		ElementType: elemType,
-		ElementKind: elemKind,
-	}
+	finfo := describeType(field.Name, field.Type, map[reflect.Type]*FieldInfo{})
+	finfo.Accessor = accessor
+	finfo.Tags = field.Tag
+	finfo.Index = index
+
+	return finfo
 }
 
-func BuildDescriptor(typ reflect.Type) *StructDescriptor {
-	if typ.Kind() == reflect.Pointer {
-		typ = typ.Elem()
+// Build the descriptor for a structure type.
+//
+// The caller must hold `descriptorMu`.  The descriptor is cached before
+// its fields are described, so self-referential types terminate.
+func buildDescriptorLocked(typ reflect.Type) *StructDescriptor {
+	if desc, found := descriptorCache[typ]; found {
+		return desc
 	}
 
 	desc := NewStructDescriptor()
 	desc.Type = typ
 	desc.TypeName = typ.Name()
 
+	descriptorCache[typ] = desc
+
 	for idx := range typ.NumField() {
 		field := typ.Field(idx)
 
@@ -182,4 +298,35 @@ func BuildDescriptor(typ reflect.Type) *StructDescriptor {
 	return desc
 }
 
+// Return the cached descriptor for the given structure type.
+//
+// The descriptor is shared, and is never exposed outside the package.
+func sharedDescriptor(typ reflect.Type) *StructDescriptor {
+	descriptorMu.Lock()
+	defer descriptorMu.Unlock()
+
+	return buildDescriptorLocked(typ)
+}
+
+// Return the descriptor for the given structure type.
+//
+// Descriptors are built once per type, along with the descriptors of any
+// structures nested within it, and cached.  Each call returns a deep copy
+// of the cached descriptor, which the caller may modify freely.
+func BuildDescriptor(typ reflect.Type) *StructDescriptor {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	descriptorMu.Lock()
+	defer descriptorMu.Unlock()
+
+	copier := &descriptorCopier{
+		descs:  make(map[*StructDescriptor]*StructDescriptor),
+		fields: make(map[*FieldInfo]*FieldInfo),
+	}
+
+	return copier.descriptor(buildDescriptorLocked(typ))
+}
+
 // * reflect.go ends here.
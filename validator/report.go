@@ -0,0 +1,294 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// report.go --- Validation reports.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// A report is the machine-readable form of an evaluation's failures.  It
+// is an error whose details hold one entry per failure, so it can be
+// passed straight to `envelope.NewError`:
+//
+//	if err := result.Err(); err != nil {
+//	    return envelope.NewError(http.StatusUnprocessableEntity, err)
+//	}
+//
+// Each entry carries a message code.  The message is rendered from the
+// template registered for the code in the `golang.org/x/text/message`
+// catalog, falling back to the rule's message or a built-in template.
+// Templates are given the field path, the expected value and the actual
+// value, in that order, so a translation can refer to them as `%[1]s`,
+// `%[2]v` and `%[3]v`.  To localise a report, register translations with
+// `message.SetString` and call `Report.Localise` with a printer for the
+// language wanted.
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gitlab.com/tozd/go/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// * Constants:
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+const (
+	// Message code for failures that do not give one.
+	CodeFailed = "validation.failed"
+
+	// Prefix of the message codes for struct tag directives.
+	codePrefix = "validation."
+
+	// Key under which report entries appear in the error details.
+	reportDetailsKey = "failures"
+)
+
+// * Variables:
+
+var (
+	ErrValidationFailed = errors.Base("validation failed")
+	ErrInvalidSeverity  = errors.Base("invalid severity")
+
+	//nolint:gochecknoglobals
+	severityNames = map[Severity]string{
+		SeverityError:   "error",
+		SeverityWarning: "warning",
+		SeverityInfo:    "info",
+	}
+
+	// Built-in message templates, by message code.
+	//
+	//nolint:gochecknoglobals
+	defaultTemplates = map[string]string{
+		CodeFailed:              "%[1]s is not valid",
+		codePrefix + "required": "%[1]s is required",
+		codePrefix + "min":      "%[1]s must be at least %[2]v",
+		codePrefix + "max":      "%[1]s must be at most %[2]v",
+		codePrefix + "len":      "%[1]s must have a length of %[2]v",
+		codePrefix + "regex":    "%[1]s must match %[2]v",
+		codePrefix + "oneof":    "%[1]s must be one of %[2]v",
+	}
+)
+
+// * Code:
+
+// ** Types:
+
+// Severity of a failure.
+type Severity int
+
+// A single failure in a report.
+type ReportEntry struct {
+	Expected any      `json:"expected,omitempty"`
+	Actual   any      `json:"actual,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Operator string   `json:"operator,omitempty"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+
+	template string // Fallback message template.
+}
+
+// Validation report.
+//
+// Implements `error`.
+type Report struct {
+	Entries []*ReportEntry
+}
+
+// ** Methods:
+
+// *** Severity:
+
+// Return the name of the severity.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Marshal the severity as its name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	result, err := json.Marshal(s.String())
+
+	return result, errors.WithStack(err)
+}
+
+// Unmarshal the severity from its name.
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	parsed, err := ParseSeverity(name)
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+
+	return nil
+}
+
+// *** Report entry:
+
+// Render the entry's message with the given printer.
+func (re *ReportEntry) render(printer *message.Printer) {
+	template := re.template
+	if len(template) == 0 {
+		template = defaultTemplates[re.Code]
+	}
+
+	if len(template) == 0 {
+		template = defaultTemplates[CodeFailed]
+	}
+
+	re.Message = printer.Sprintf(
+		message.Key(re.Code, template),
+		re.Field,
+		re.Expected,
+		re.Actual)
+}
+
+// *** Report:
+
+// Return the error message.
+func (r *Report) Error() string {
+	messages := make([]string, 0, len(r.Entries))
+
+	for _, entry := range r.Entries {
+		messages = append(messages, entry.Message)
+	}
+
+	return ErrValidationFailed.Error() + ": " + strings.Join(messages, "; ")
+}
+
+// Return the cause of the error.
+func (r *Report) Cause() error {
+	return ErrValidationFailed
+}
+
+// Allow `errors.Is` to match `ErrValidationFailed`.
+func (r *Report) Unwrap() error {
+	return ErrValidationFailed
+}
+
+// Return the error's details.
+//
+// The entries are given under the `failures` key.
+func (r *Report) Details() map[string]any {
+	return map[string]any{
+		reportDetailsKey: r.Entries,
+	}
+}
+
+// Does the report contain any failures of error severity?
+func (r *Report) HasErrors() bool {
+	for _, entry := range r.Entries {
+		if entry.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Render every message with the given printer.
+func (r *Report) Localise(printer *message.Printer) {
+	for _, entry := range r.Entries {
+		entry.render(printer)
+	}
+}
+
+// ** Functions:
+
+// Parse the name of a severity.
+func ParseSeverity(name string) (Severity, error) {
+	for severity, sname := range severityNames {
+		if strings.EqualFold(name, sname) {
+			return severity, nil
+		}
+	}
+
+	return SeverityError, errors.WithMessagef(ErrInvalidSeverity, "%q", name)
+}
+
+// Create a report from the given failures, with messages in English.
+func NewReport(failures []*Failure) *Report {
+	report := &Report{
+		Entries: make([]*ReportEntry, 0, len(failures)),
+	}
+
+	printer := message.NewPrinter(language.English)
+
+	for _, failure := range failures {
+		entry := &ReportEntry{
+			Expected: failure.Expected,
+			Actual:   failure.Actual,
+			Field:    failure.Field,
+			Rule:     failure.Rule,
+			Operator: failure.Operator,
+			Code:     failure.Code,
+			Severity: failure.Severity,
+			template: failure.template,
+		}
+
+		if len(entry.Code) == 0 {
+			entry.Code = CodeFailed
+		}
+
+		entry.render(printer)
+		report.Entries = append(report.Entries, entry)
+	}
+
+	return report
+}
+
+// * report.go ends here.
@@ -39,16 +39,25 @@ package validator
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"os"
 	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/Asmodai/gohacks/contextdi"
 	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/envelope"
 	"github.com/Asmodai/gohacks/logger"
 	mlogger "github.com/Asmodai/gohacks/mocks/logger"
+	"gitlab.com/tozd/go/errors"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
 )
 
 // * Constants:
@@ -115,6 +124,21 @@ const (
     params:
       message: "'Five' is not valid"
 `
+
+	rulesReport string = `
+- name: "Name should not be shouted"
+  conditions:
+    - not:
+        attribute: Name
+        operator: field-value-equal
+        value: BOB
+  failure:
+    perform: error
+    params:
+      message: "%[1]s should not be shouted"
+      code: report.shouted
+      severity: warning
+`
 )
 
 // * Variables:
@@ -165,6 +189,14 @@ var (
 	}
 )
 
+// ** Report structure:
+
+type testReportStruct struct {
+	Name string   `validate:"required,min=3"`
+	Tags []string `validate:"max=2"`
+	Age  int      `validate:"min=18"`
+}
+
 // ** Tests:
 
 func TestValidator(t *testing.T) {
@@ -237,22 +269,251 @@ func TestValidator(t *testing.T) {
 
 	t.Run("valid data", func(t *testing.T) {
 		obj, _ := bindings.Bind(testData)
-		compiler.Evaluate(obj)
+
+		if result := compiler.Evaluate(obj); !result.Valid() {
+			t.Fatalf("Unexpected failures: %v", result.Errors())
+		}
 	})
 
 	t.Run("invalid data", func(t *testing.T) {
 		obj, _ := bindings.Bind(testInvalid)
-		compiler.Evaluate(obj)
+		result := compiler.Evaluate(obj)
 
-		if len(compiler.Failures()) == 0 {
+		if result.Valid() {
 			t.Fatal("No errors generated")
 		}
 
-		for idx, val := range compiler.Failures() {
-			t.Logf("%02d - %q", idx, val)
+		for idx, val := range result.Failures {
+			t.Logf("%02d - %s: %q (%s)", idx, val.Field, val.Err, val.Rule)
+		}
+
+		want := []string{"five", "four", "one", "three", "two"}
+		if got := result.Fields(); !slices.Equal(got, want) {
+			t.Errorf("Failed fields mismatch: %v != %v", got, want)
+		}
+	})
+
+	t.Run("trace", func(t *testing.T) {
+		obj, _ := bindings.Bind(testInvalid)
+		result, trace := compiler.EvaluateWithTrace(obj)
+
+		if len(trace.Failures) == 0 {
+			t.Fatal("No failures traced")
+		}
+
+		want := []string{"five", "four", "one", "three", "two"}
+		if got := result.Fields(); !slices.Equal(got, want) {
+			t.Errorf("Traced failures not recorded: %v != %v", got, want)
+		}
+
+		found := false
+
+		for _, step := range trace.Steps {
+			if step.Attribute == "four" && step.Token == fteqToken {
+				found = step.Actual == "FINE" && step.Result
+			}
+		}
+
+		if !found {
+			t.Error("Field value not traced")
+		}
+	})
+}
+
+func TestReport(t *testing.T) {
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("Could not set logger DI: %#v", err)
+	}
+
+	rules, err := dag.ParseFromYAML(rulesReport)
+	if err != nil {
+		t.Fatalf("YAML parse error: %#v", err)
+	}
+
+	vdr := NewValidator(ctx)
+
+	if issues := vdr.CompileTags(&testReportStruct{}); len(issues) > 0 {
+		t.Fatalf("Unexpected issues: %v", issues)
+	}
+
+	if issues := vdr.Compile(rules); len(issues) > 0 {
+		t.Fatalf("Unexpected issues: %v", issues)
+	}
+
+	t.Run("Entries", func(t *testing.T) {
+		result, err := vdr.Validate(&testReportStruct{
+			Name: "Al",
+			Tags: []string{"a", "b", "c"},
+			Age:  18,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		entries := result.Report().Entries
+		if len(entries) != 2 {
+			t.Fatalf("Unexpected entries: %d", len(entries))
+		}
+
+		slices.SortFunc(entries, func(a, b *ReportEntry) int {
+			return strings.Compare(a.Field, b.Field)
+		})
+
+		want := []ReportEntry{
+			{
+				Field:    "Name",
+				Rule:     "validator.testReportStruct.Name min=3",
+				Operator: flgteToken,
+				Code:     "validation.min",
+				Message:  "Name must be at least 3",
+				Expected: 3,
+				Actual:   "Al",
+			},
+			{
+				Field:    "Tags",
+				Rule:     "validator.testReportStruct.Tags max=2",
+				Operator: fllteToken,
+				Code:     "validation.max",
+				Message:  "Tags must be at most 2",
+				Expected: 2,
+				Actual:   []string{"a", "b", "c"},
+			},
+		}
+
+		for idx, entry := range entries {
+			wanted := want[idx]
+			wanted.template = entry.template
+
+			if !reflect.DeepEqual(*entry, wanted) {
+				t.Errorf("Entry mismatch: %#v != %#v", *entry, wanted)
+			}
+		}
+	})
+
+	t.Run("Severity", func(t *testing.T) {
+		result, err := vdr.Validate(&testReportStruct{Name: "BOB", Age: 18})
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		if !result.Valid() {
+			t.Fatalf("Warnings should not invalidate: %v", result.Errors())
+		}
+
+		report := result.Report()
+		if report.HasErrors() || len(report.Entries) != 1 {
+			t.Fatalf("Unexpected entries: %#v", report.Entries)
+		}
+
+		entry := report.Entries[0]
+		if entry.Severity != SeverityWarning ||
+			entry.Code != "report.shouted" ||
+			entry.Message != "Name should not be shouted" ||
+			entry.Operator != "not field-value-equal" ||
+			entry.Expected != "BOB" {
+			t.Errorf("Unexpected entry: %#v", entry)
 		}
 	})
 
+	t.Run("Envelope", func(t *testing.T) {
+		result, _ := vdr.Validate(&testReportStruct{Name: "Alice"})
+
+		err := result.Err()
+		if !errors.Is(err, ErrValidationFailed) {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		data, err := json.Marshal(envelope.NewError(http.StatusUnprocessableEntity, err))
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		var decoded struct {
+			Error struct {
+				Error   string `json:"error"`
+				Cause   string `json:"cause"`
+				Details struct {
+					Failures []struct {
+						Field    string   `json:"field"`
+						Code     string   `json:"code"`
+						Message  string   `json:"message"`
+						Severity Severity `json:"severity"`
+						Expected int      `json:"expected"`
+						Actual   int      `json:"actual"`
+					} `json:"failures"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		failures := decoded.Error.Details.Failures
+		if decoded.Error.Cause != ErrValidationFailed.Error() || len(failures) != 1 {
+			t.Fatalf("Unexpected JSON: %s", data)
+		}
+
+		if failures[0].Field != "Age" ||
+			failures[0].Code != "validation.min" ||
+			failures[0].Message != "Age must be at least 18" ||
+			failures[0].Severity != SeverityError ||
+			failures[0].Expected != 18 ||
+			failures[0].Actual != 0 {
+			t.Errorf("Unexpected JSON: %s", data)
+		}
+
+		valid, _ := vdr.Validate(&testReportStruct{Name: "Alice", Age: 18})
+		if valid.Err() != nil {
+			t.Errorf("Unexpected error: %#v", valid.Err())
+		}
+	})
+
+	t.Run("Localise", func(t *testing.T) {
+		cat := catalog.NewBuilder()
+
+		err := cat.SetString(language.French, "validation.min", "%[1]s doit valoir au moins %[2]v")
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		result, _ := vdr.Validate(&testReportStruct{Name: "Alice", Age: 7})
+		report := result.Report()
+
+		report.Localise(message.NewPrinter(language.French, message.Catalog(cat)))
+
+		if got := report.Entries[0].Message; got != "Age doit valoir au moins 18" {
+			t.Errorf("Unexpected message: %q", got)
+		}
+
+		report.Localise(message.NewPrinter(language.English, message.Catalog(cat)))
+
+		if got := report.Entries[0].Message; got != "Age must be at least 18" {
+			t.Errorf("Unexpected message: %q", got)
+		}
+	})
+}
+
+func TestSeverity(t *testing.T) {
+	for _, severity := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		data, err := json.Marshal(severity)
+		if err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+
+		var decoded Severity
+
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != severity {
+			t.Errorf("%s: round trip failed: %v %#v", data, decoded, err)
+		}
+	}
+
+	var decoded Severity
+
+	if err := json.Unmarshal([]byte(`"fatal"`), &decoded); !errors.Is(err, ErrInvalidSeverity) {
+		t.Errorf("Unexpected error: %#v", err)
+	}
 }
 
 // ** Benchmarks:
@@ -0,0 +1,140 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// result.go --- Evaluation results.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"slices"
+)
+
+// * Code:
+
+// ** Types:
+
+// A failure recorded while evaluating an object.
+type Failure struct {
+	Err      error    // Error generated by the failure action.
+	Expected any      // Value the rule's last condition expected.
+	Actual   any      // Value of the field when the rule failed.
+	Field    string   // Path of the field that failed, if known.
+	Rule     string   // Name of the rule that failed.
+	Reason   string   // Reason given in the rule's failure specification.
+	Operator string   // Operator of the rule's last condition.
+	Code     string   // Message code.
+	Severity Severity // Severity of the failure.
+
+	template string // Message template.
+}
+
+// Result of evaluating an object.
+//
+// Each evaluation has a result of its own, so a compiled validator can be
+// shared between goroutines.
+type Result struct {
+	Failures []*Failure // Failures, in the order they were recorded.
+}
+
+// ** Methods:
+
+// Did the object pass validation?
+//
+// Failures of warning or info severity do not make an object invalid.
+func (r *Result) Valid() bool {
+	for _, failure := range r.Failures {
+		if failure.Severity == SeverityError {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Return a report of the failures.
+func (r *Result) Report() *Report {
+	return NewReport(r.Failures)
+}
+
+// Return a report of the failures as an error, or nil if there were none.
+func (r *Result) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+
+	return r.Report()
+}
+
+// Return the errors generated by the failures.
+func (r *Result) Errors() []error {
+	errs := make([]error, 0, len(r.Failures))
+
+	for _, failure := range r.Failures {
+		errs = append(errs, failure.Err)
+	}
+
+	return errs
+}
+
+// Return a sorted list of the paths of the fields that failed.
+func (r *Result) Fields() []string {
+	fields := make([]string, 0, len(r.Failures))
+
+	for _, failure := range r.Failures {
+		if len(failure.Field) > 0 {
+			fields = append(fields, failure.Field)
+		}
+	}
+
+	slices.Sort(fields)
+
+	return slices.Compact(fields)
+}
+
+// Record a failure.
+func (r *Result) add(failure *Failure) {
+	r.Failures = append(r.Failures, failure)
+}
+
+// ** Functions:
+
+// Create a new empty result.
+func NewResult() *Result {
+	return &Result{
+		Failures: make([]*Failure, 0),
+	}
+}
+
+// * result.go ends here.
@@ -0,0 +1,123 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// result_test.go --- Evaluation result tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/logger"
+)
+
+// * Code:
+
+// ** Tests:
+
+func TestResultConcurrency(t *testing.T) {
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("Could not set logger DI: %#v", err)
+	}
+
+	rules, err := dag.ParseFromYAML(rulesFTEQ)
+	if err != nil {
+		t.Fatalf("YAML parse error: %#v", err)
+	}
+
+	rules[3].Failure.Reason = "Four is an enumeration."
+
+	vdr := NewValidator(ctx)
+	if issues := vdr.Compile(rules); len(issues) > 0 {
+		t.Fatalf("Compiler issues: %v", issues)
+	}
+
+	bindings := NewBindings()
+	bindings.Build(&DummyStructure{})
+
+	valid, _ := bindings.Bind(testData)
+	invalid, _ := bindings.Bind(testInvalid)
+
+	var wg sync.WaitGroup
+
+	for idx := range 16 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 100 {
+				if idx%2 == 0 {
+					if result := vdr.Evaluate(valid); !result.Valid() {
+						t.Errorf("Unexpected failures: %v", result.Errors())
+
+						return
+					}
+
+					continue
+				}
+
+				if result := vdr.Evaluate(invalid); len(result.Failures) != len(rules) {
+					t.Errorf("Unexpected failures: %v", result.Errors())
+
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	result := vdr.Evaluate(invalid)
+
+	idx := slices.IndexFunc(result.Failures, func(failure *Failure) bool {
+		return failure.Field == "four"
+	})
+	if idx < 0 {
+		t.Fatalf("No failure for field: %v", result.Failures)
+	}
+
+	failure := result.Failures[idx]
+	if failure.Rule != rules[3].Name || failure.Reason != rules[3].Failure.Reason {
+		t.Errorf("Unexpected failure: %#v", failure)
+	}
+}
+
+// * result_test.go ends here.
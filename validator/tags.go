@@ -0,0 +1,492 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// tags.go --- Struct tag validation rules.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// Rules can be derived from `validate` struct tags rather than written by
+// hand.  A tag is a comma-separated list of directives:
+//
+//	required    The field must be set: non-zero, non-empty and non-nil.
+//	omitempty   Skip the field's other directives if it is not set.
+//	min=N       Numbers must be at least N; strings, slices, arrays and
+//	            maps must have at least N elements.
+//	max=N       As `min`, but at most N.
+//	len=N       Strings, slices, arrays and maps must have exactly N
+//	            elements.
+//	regex=RE    Strings must match the regular expression.  As the
+//	            expression may contain commas, this must come last.
+//	oneof=A B   The value must be one of the space-separated values.
+//
+// A tag of `-` skips the field entirely.
+//
+// Each directive becomes a rule of its own, whose failure action is
+// `field-error`.  Every rule starts with a `struct-type-equal` condition,
+// so rules for different structures can be compiled into one validator
+// along with hand-written rules.
+//
+// Nested structures, and structures held in slices and maps, are walked
+// and their fields' rules are given field paths.  Rules for a structure
+// behind a nil pointer are skipped, except beneath a slice or map where
+// the path's wildcard cannot tell a nil element from a failing one.  For
+// the same reason, `omitempty` cannot be used beneath a slice or map.
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Asmodai/gohacks/dag"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	// Struct tag holding validation directives.
+	TagName = "validate"
+
+	// Tag value that skips a field.
+	tagSkip = "-"
+
+	// Failure action used by derived rules.
+	fieldErrorAction = "field-error"
+)
+
+// * Variables:
+
+var (
+	ErrInvalidTag = errors.Base("invalid validation tag")
+)
+
+// * Code:
+
+// ** Types:
+
+// A single directive in a validation tag.
+type tagDirective struct {
+	name  string
+	param string
+}
+
+// Walks a structure descriptor, deriving rules.
+type tagWalker struct {
+	name   string            // Structure type name.
+	guard  dag.ConditionSpec // Structure type condition.
+	rules  []dag.RuleSpec    // Derived rules.
+	issues []error           // Invalid tags.
+	seen   []reflect.Type    // Structures being walked.
+}
+
+// ** Methods:
+
+// *** Directive:
+
+// Return the directive as written in the tag.
+func (td tagDirective) String() string {
+	if len(td.param) == 0 {
+		return td.name
+	}
+
+	return td.name + "=" + td.param
+}
+
+// *** Walker:
+
+// Derive the rules for each field of the structure.
+//
+// `prefix` is the path to the structure, `guards` are the conditions
+// under which it exists, and `wild` is set if the path has a wildcard.
+func (tw *tagWalker) walkStruct(desc *StructDescriptor, prefix string, guards []dag.ConditionSpec, wild bool) {
+	if slices.Contains(tw.seen, desc.Type) {
+		return
+	}
+
+	tw.seen = append(tw.seen, desc.Type)
+	defer func() { tw.seen = tw.seen[:len(tw.seen)-1] }()
+
+	fields := make([]*FieldInfo, 0, len(desc.Fields))
+	for _, finfo := range desc.Fields {
+		fields = append(fields, finfo)
+	}
+
+	slices.SortFunc(fields, func(lhs, rhs *FieldInfo) int {
+		return lhs.Index - rhs.Index
+	})
+
+	for _, finfo := range fields {
+		tag, found := finfo.Tags.Lookup(TagName)
+		if tag == tagSkip {
+			continue
+		}
+
+		path := finfo.Name
+		if len(prefix) > 0 {
+			path = prefix + "." + finfo.Name
+		}
+
+		if found && len(tag) > 0 {
+			tw.fieldRules(finfo, path, tag, guards, wild)
+		}
+
+		tw.walkNested(finfo, path, guards, wild)
+	}
+}
+
+// Walk the structures nested within a field.
+//
+//nolint:exhaustive
+func (tw *tagWalker) walkNested(finfo *FieldInfo, path string, guards []dag.ConditionSpec, wild bool) {
+	switch finfo.Kind {
+	case KindStruct:
+		if finfo.Descriptor == nil {
+			return
+		}
+
+		if present, ok := presentCondition(finfo, path); ok && !wild {
+			guards = append(slices.Clip(guards), present)
+		}
+
+		tw.walkStruct(finfo.Descriptor, path, guards, wild)
+
+	case KindSlice, KindMap:
+		if finfo.Element.Kind != KindStruct || finfo.Element.Descriptor == nil {
+			return
+		}
+
+		tw.walkStruct(finfo.Element.Descriptor, path+"[*]", guards, true)
+	}
+}
+
+// Derive the rules for a field from its tag.
+func (tw *tagWalker) fieldRules(finfo *FieldInfo, path, tag string, guards []dag.ConditionSpec, wild bool) {
+	directives := parseTag(tag)
+	conds := append([]dag.ConditionSpec{tw.guard}, guards...)
+
+	if slices.ContainsFunc(directives, func(td tagDirective) bool { return td.name == "omitempty" }) {
+		if wild {
+			tw.issues = append(tw.issues, errors.WithMessagef(
+				ErrInvalidTag,
+				"%s: omitempty beneath a slice or map",
+				path))
+
+			return
+		}
+
+		if present, ok := presentCondition(finfo, path); ok {
+			conds = append(conds, present)
+		}
+	}
+
+	for _, directive := range directives {
+		if directive.name == "omitempty" {
+			continue
+		}
+
+		cond, err := directiveCondition(finfo, path, directive)
+		if err != nil {
+			tw.issues = append(tw.issues, err)
+
+			continue
+		}
+
+		if cond == nil {
+			continue
+		}
+
+		_, operator, expected := describeCondition(*cond)
+
+		tw.rules = append(tw.rules, dag.RuleSpec{
+			Name:       fmt.Sprintf("%s.%s %s", tw.name, path, directive),
+			Conditions: append(slices.Clip(conds), tw.leaf(*cond)),
+			Failure: dag.FailureSpec{
+				Perform: fieldErrorAction,
+				Params: dag.ActionParams{
+					"field":           path,
+					"rule":            directive.name,
+					"param":           directive.param,
+					"code":            codePrefix + directive.name,
+					paramRuleOperator: operator,
+					paramRuleExpected: expected,
+				},
+			},
+		})
+	}
+}
+
+// Return the final condition of a rule.
+//
+// The compiler shares nodes between rules with identical conditions, and a
+// node's failure actions run whenever it is reached and fails.  Qualifying
+// the directive with the structure type keeps the final node, to which the
+// failure is attached, unique to the structure.  The structure type is
+// already known to match when the node is reached this way.
+func (tw *tagWalker) leaf(cond dag.ConditionSpec) dag.ConditionSpec {
+	guard := tw.guard
+
+	return dag.ConditionSpec{
+		Any: []dag.ConditionSpec{{Not: &guard}, cond},
+	}
+}
+
+// ** Functions:
+
+// Derive validation rules from the `validate` tags of a structure and the
+// structures nested within it.
+//
+// Returns the rules along with an error for each invalid directive.
+func TagRules(desc *StructDescriptor) ([]dag.RuleSpec, []error) {
+	walker := &tagWalker{
+		name: desc.Type.String(),
+		guard: dag.ConditionSpec{
+			Operator: steqToken,
+			Value:    desc.Type.String(),
+		},
+		rules:  make([]dag.RuleSpec, 0),
+		issues: make([]error, 0),
+	}
+
+	walker.walkStruct(desc, "", nil, false)
+
+	return walker.rules, walker.issues
+}
+
+// Split a tag into directives.
+func parseTag(tag string) []tagDirective {
+	directives := make([]tagDirective, 0)
+
+	for len(tag) > 0 {
+		var item string
+
+		if strings.HasPrefix(tag, "regex=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(item), "=")
+		if len(name) == 0 {
+			continue
+		}
+
+		directives = append(directives, tagDirective{name: name, param: param})
+	}
+
+	return directives
+}
+
+// Return the condition that holds if the field is set.
+//
+// Returns `false` for structures, which are always set.
+func presentCondition(finfo *FieldInfo, path string) (dag.ConditionSpec, bool) {
+	switch {
+	case finfo.TypeKind == reflect.Struct:
+		return dag.ConditionSpec{}, false
+
+	case finfo.TypeKind == reflect.Pointer && finfo.Kind != KindPrimitive:
+		return dag.ConditionSpec{
+			Not: &dag.ConditionSpec{Attribute: path, Operator: fvnilToken},
+		}, true
+
+	case finfo.TypeKind == reflect.Pointer || finfo.TypeKind == reflect.Interface:
+		return dag.ConditionSpec{
+			Not: &dag.ConditionSpec{Attribute: path, Operator: fvfalseToken},
+		}, true
+
+	default:
+		return dag.ConditionSpec{Attribute: path, Operator: fvtrueToken}, true
+	}
+}
+
+// Return the condition for a directive.
+//
+// Returns nil if the directive always holds.
+//
+//nolint:cyclop
+func directiveCondition(finfo *FieldInfo, path string, directive tagDirective) (*dag.ConditionSpec, error) {
+	fail := func(why string) (*dag.ConditionSpec, error) {
+		return nil, errors.WithMessagef(
+			ErrInvalidTag,
+			"%s: %s: %s",
+			path,
+			directive,
+			why)
+	}
+
+	switch directive.name {
+	case "required":
+		cond, ok := presentCondition(finfo, path)
+		if !ok {
+			return nil, nil //nolint:nilnil
+		}
+
+		return &cond, nil
+
+	case "min", "max", "len":
+		return sizeCondition(finfo, path, directive, fail)
+
+	case "regex":
+		if finfo.TypeKind != reflect.String {
+			return fail("field is not a string")
+		}
+
+		if _, err := regexp.Compile(directive.param); err != nil {
+			return fail(err.Error())
+		}
+
+		return &dag.ConditionSpec{
+			Attribute: path,
+			Operator:  fvremToken,
+			Value:     directive.param,
+		}, nil
+
+	case "oneof":
+		values, ok := oneOfValues(finfo.Type, strings.Fields(directive.param))
+		if !ok {
+			return fail("unsupported field type or value")
+		}
+
+		return &dag.ConditionSpec{
+			Attribute: path,
+			Operator:  fvinToken,
+			Value:     values,
+		}, nil
+
+	default:
+		return fail("unknown directive")
+	}
+}
+
+// Return the condition for a `min`, `max` or `len` directive.
+//
+//nolint:exhaustive
+func sizeCondition(
+	finfo *FieldInfo,
+	path string,
+	directive tagDirective,
+	fail func(string) (*dag.ConditionSpec, error),
+) (*dag.ConditionSpec, error) {
+	switch finfo.TypeKind {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		size, err := strconv.Atoi(directive.param)
+		if err != nil || size < 0 {
+			return fail("expected a length")
+		}
+
+		tokens := map[string]string{"min": flgteToken, "max": fllteToken, "len": fleqToken}
+
+		return &dag.ConditionSpec{
+			Attribute: path,
+			Operator:  tokens[directive.name],
+			Value:     size,
+		}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if directive.name == "len" {
+			return fail("numbers have no length")
+		}
+
+		bound, err := strconv.ParseFloat(directive.param, 64)
+		if err != nil {
+			return fail("expected a number")
+		}
+
+		tokens := map[string]string{"min": fvgteToken, "max": fvlteToken}
+
+		return &dag.ConditionSpec{
+			Attribute: path,
+			Operator:  tokens[directive.name],
+			Value:     bound,
+		}, nil
+
+	default:
+		return fail("unsupported field type")
+	}
+}
+
+// Convert the values of a `oneof` directive to the field's type.
+//
+// Only unnamed strings and numbers are supported, as `FVIN` compares
+// canonical values.
+//
+//nolint:exhaustive
+func oneOfValues(typ reflect.Type, words []string) ([]any, bool) {
+	if typ.Name() != typ.Kind().String() || len(words) == 0 {
+		return nil, false
+	}
+
+	values := make([]any, 0, len(words))
+
+	for _, word := range words {
+		var (
+			val any
+			err error
+		)
+
+		switch typ.Kind() {
+		case reflect.String:
+			val = word
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			val, err = strconv.ParseInt(word, 10, typ.Bits())
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			val, err = strconv.ParseUint(word, 10, typ.Bits())
+
+		case reflect.Float32, reflect.Float64:
+			val, err = strconv.ParseFloat(word, typ.Bits())
+
+		default:
+			return nil, false
+		}
+
+		if err != nil {
+			return nil, false
+		}
+
+		values = append(values, val)
+	}
+
+	return values, true
+}
+
+// * tags.go ends here.
@@ -0,0 +1,277 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// tags_test.go --- Struct tag tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package validator
+
+// * Imports:
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/Asmodai/gohacks/dag"
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Types:
+
+type testTagAddress struct {
+	Postcode string `validate:"required,len=7"`
+}
+
+type testTagItem struct {
+	Name  string  `validate:"min=1"`
+	Price float64 `validate:"min=0.01,max=1000"`
+}
+
+type testTagRequest struct {
+	Name     string          `validate:"required,min=1,max=8,regex=^[a-z]{1,8}$"`
+	Level    string          `validate:"oneof=low high"`
+	Count    int             `validate:"omitempty,min=2"`
+	Priority int             `validate:"oneof=1 2 3"`
+	Address  *testTagAddress `validate:"required"`
+	Billing  *testTagAddress
+	Items    []testTagItem     `validate:"max=2"`
+	Labels   map[string]string `validate:"-"`
+	Note     *string           `validate:"required"`
+	Next     *testTagRequest
+}
+
+type testTagInvalid struct {
+	Number int     `validate:"len=3"`
+	Flag   bool    `validate:"min=1"`
+	Text   string  `validate:"regex=("`
+	Float  float64 `validate:"oneof=a"`
+	Other  string  `validate:"unique"`
+	Items  []struct {
+		Name string `validate:"omitempty,min=1"`
+	}
+}
+
+// ** Tests:
+
+func TestTagRules(t *testing.T) {
+	desc := BuildDescriptor(reflect.TypeOf(&testTagRequest{}))
+
+	rules, issues := TagRules(desc)
+	if len(issues) > 0 {
+		t.Fatalf("Unexpected issues: %v", issues)
+	}
+
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+
+	prefix := "validator.testTagRequest."
+	for _, want := range []string{
+		"Name regex=^[a-z]{1,8}$",
+		"Address required",
+		"Address.Postcode len=7",
+		"Billing.Postcode required",
+		"Items[*].Price max=1000",
+	} {
+		if !slices.Contains(names, prefix+want) {
+			t.Errorf("Missing rule %q in %v", want, names)
+		}
+	}
+
+	for _, name := range names {
+		if slices.Contains([]string{prefix + "Labels", prefix + "Next.Name required"}, name) {
+			t.Errorf("Unexpected rule %q", name)
+		}
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		desc := BuildDescriptor(reflect.TypeOf(&testTagInvalid{}))
+
+		_, issues := TagRules(desc)
+		if len(issues) != 6 {
+			t.Fatalf("Unexpected issues: %v", issues)
+		}
+
+		for _, issue := range issues {
+			if !errors.Is(issue, ErrInvalidTag) {
+				t.Errorf("Unexpected issue: %#v", issue)
+			}
+		}
+	})
+}
+
+//nolint:funlen
+func TestValidatorTags(t *testing.T) {
+	note := "note"
+
+	valid := &testTagRequest{
+		Name:     "tea",
+		Level:    "low",
+		Priority: 2,
+		Address:  &testTagAddress{Postcode: "SW1A1AA"},
+		Items:    []testTagItem{{"cup", 2.5}},
+		Note:     &note,
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*testTagRequest)
+		want   []string
+	}{
+		{"Valid", func(*testTagRequest) {}, nil},
+		{"Required", func(req *testTagRequest) {
+			req.Name = ""
+			req.Note = nil
+		}, []string{"Name required", "Name min", "Name regex", "Note required"}},
+		{"Bounds", func(req *testTagRequest) {
+			req.Name = "toolongname"
+			req.Count = 1
+			req.Items = []testTagItem{{"", 0}, {"b", 1}, {"c", 1}}
+		}, []string{"Name max", "Name regex", "Count min", "Items max", "Items[*].Name min", "Items[*].Price min"}},
+		{"Members", func(req *testTagRequest) {
+			req.Level = "medium"
+			req.Priority = 4
+		}, []string{"Level oneof", "Priority oneof"}},
+		{"Nested", func(req *testTagRequest) {
+			req.Address.Postcode = "SW1"
+			req.Billing = &testTagAddress{}
+		}, []string{"Address.Postcode len", "Billing.Postcode required", "Billing.Postcode len"}},
+	}
+
+	ctx, err := logger.SetLogger(context.TODO(), logger.NewDefaultLogger())
+	if err != nil {
+		t.Fatalf("Could not set logger DI: %#v", err)
+	}
+
+	vdr := NewValidator(ctx)
+
+	if issues := vdr.Compile([]dag.RuleSpec{{
+		Name:       "hand-written",
+		Conditions: []dag.ConditionSpec{{Attribute: "Level", Operator: fvneqToken, Value: "banned"}},
+		Failure: dag.FailureSpec{
+			Perform: "error",
+			Params:  dag.ActionParams{"message": "banned"},
+		},
+	}}); len(issues) > 0 {
+		t.Fatalf("Unexpected issues: %v", issues)
+	}
+
+	if issues := vdr.CompileTags(&testTagRequest{}); len(issues) > 0 {
+		t.Fatalf("Unexpected issues: %v", issues)
+	}
+
+	if issues := vdr.CompileTags(&testTagItem{}); len(issues) > 0 {
+		t.Fatalf("Unexpected issues: %v", issues)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := *valid
+			addr := *valid.Address
+			req.Address = &addr
+			test.mutate(&req)
+
+			got := make([]string, 0)
+
+			result, err := vdr.Validate(&req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %#v", err)
+			}
+
+			for _, err := range result.Errors() {
+				var ferr *FieldError
+				if !errors.As(err, &ferr) {
+					t.Fatalf("Unexpected failure: %#v", err)
+				}
+
+				got = append(got, ferr.Field+" "+ferr.Rule)
+			}
+
+			slices.Sort(got)
+			slices.Sort(test.want)
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("Failure mismatch: %v != %v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("Hand-written rules", func(t *testing.T) {
+		req := *valid
+		req.Level = "banned"
+
+		result, _ := vdr.Validate(&req)
+		errs := result.Errors()
+		banned := slices.ContainsFunc(errs, func(err error) bool {
+			return err.Error() == "banned"
+		})
+
+		// `banned` is not one of the levels allowed by the tag either.
+		if len(errs) != 2 || !banned {
+			t.Errorf("Unexpected failures: %v", errs)
+		}
+	})
+
+	t.Run("Other structures", func(t *testing.T) {
+		if result, _ := vdr.Validate(&testTagItem{Name: "x", Price: 1}); !result.Valid() {
+			t.Errorf("Unexpected failures: %v", result.Errors())
+		}
+
+		result, _ := vdr.Validate(&testTagItem{Price: 1})
+		if errs := result.Errors(); len(errs) != 1 || errs[0].Error() != "Name failed min=1" {
+			t.Errorf("Unexpected failures: %v", errs)
+		}
+
+		if failure := result.Failures[0]; failure.Field != "Name" ||
+			failure.Rule != "validator.testTagItem.Name min=1" {
+			t.Errorf("Unexpected failure: %#v", failure)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if _, err := vdr.Validate(&testTagAddress{}); !errors.Is(err, ErrUnknownStruct) {
+			t.Errorf("Unexpected error: %#v", err)
+		}
+
+		if errs := vdr.CompileTags(42); len(errs) != 1 || !errors.Is(errs[0], ErrNotStruct) {
+			t.Errorf("Unexpected failures: %v", errs)
+		}
+	})
+}
+
+// * tags_test.go ends here.
@@ -40,19 +40,37 @@ package validator
 import (
 	"context"
 	"io"
+	"maps"
+	"reflect"
+	"slices"
 
 	"github.com/Asmodai/gohacks/dag"
 	"gitlab.com/tozd/go/errors"
 )
 
+// * Variables:
+
+var (
+	ErrNotStruct     = errors.Base("value is not a structure")
+	ErrUnknownStruct = errors.Base("structure has not been registered")
+)
+
 // * Code:
 
 // ** Type:
 
 // Validator structure.
+//
+// Once its rules are compiled, a validator may be used to evaluate objects
+// from any number of goroutines.  Compiling rules is not safe while
+// evaluations are in progress.
 type Validator struct {
-	cmplr dag.Compiler // The DAG compiler.
-	act   *actions     // Actions.
+	cmplr    dag.Compiler          // The DAG compiler.
+	act      *actions              // Actions.
+	bindings *Bindings             // Structures registered via tags.
+	specs    []dag.RuleSpec        // Hand-written rules.
+	tagged   []dag.RuleSpec        // Rules derived from struct tags.
+	types    map[reflect.Type]bool // Structures whose tags are compiled.
 }
 
 // ** Methods:
@@ -72,13 +90,88 @@ func (v *Validator) CompileFailure(spec dag.FailureSpec) (dag.ActionFn, error) {
 }
 
 // Compile a slice of rule specs into a DAG graph.
+//
+// Rules derived from struct tags via `CompileTags` are compiled into the
+// same graph.
 func (v *Validator) Compile(specs []dag.RuleSpec) []error {
-	return v.cmplr.Compile(specs)
+	v.specs = annotateRules(specs)
+
+	return v.cmplr.Compile(slices.Concat(v.specs, v.tagged))
+}
+
+// Derive rules from the `validate` struct tags of the given object's type
+// and compile them along with the rules given to `Compile`.
+//
+// The type is registered with the validator's bindings, so objects of it
+// can be passed to `Validate`.  See tags.go for the tag syntax.
+func (v *Validator) CompileTags(object any) []error {
+	if object == nil {
+		return []error{errors.WithStack(ErrNotStruct)}
+	}
+
+	if _, ok := structType(reflect.TypeOf(object)); !ok {
+		return []error{errors.WithMessagef(ErrNotStruct, "%T", object)}
+	}
+
+	desc, _ := v.bindings.BuildWithReflection(object)
+
+	if v.types[desc.Type] {
+		return nil
+	}
+
+	rules, issues := TagRules(desc)
+	if len(issues) > 0 {
+		return issues
+	}
+
+	v.types[desc.Type] = true
+	v.tagged = append(v.tagged, annotateRules(rules)...)
+
+	return v.cmplr.Compile(slices.Concat(v.specs, v.tagged))
+}
+
+// Validate an object whose type was registered via `CompileTags`.
+//
+// Failures from derived rules carry `*FieldError`s.
+func (v *Validator) Validate(object any) (*Result, error) {
+	bound, ok := v.bindings.BindWithReflection(object)
+	if !ok {
+		return nil, errors.WithMessagef(ErrUnknownStruct, "%T", object)
+	}
+
+	return v.Evaluate(bound), nil
 }
 
 // Evaluate an input against the validator.
-func (v *Validator) Evaluate(input dag.Filterable) {
+//
+// Returns the failures recorded during the evaluation.  Only failures for
+// a `*BoundObject` input are recorded.
+func (v *Validator) Evaluate(input dag.Filterable) *Result {
+	result := NewResult()
+
+	if bound, ok := input.(*BoundObject); ok {
+		input = bound.withResult(result)
+	}
+
 	v.cmplr.Evaluate(input)
+
+	return result
+}
+
+// Evaluate an input against the validator, recording a trace.
+//
+// Returns the failures recorded during the evaluation, as `Evaluate` does,
+// along with the trace.
+func (v *Validator) EvaluateWithTrace(input dag.Filterable) (*Result, *dag.Trace) {
+	result := NewResult()
+
+	if bound, ok := input.(*BoundObject); ok {
+		input = bound.withResult(result)
+	}
+
+	trace := v.cmplr.EvaluateWithTrace(input)
+
+	return result, trace
 }
 
 // Export the compiler's rulesets to GraphViz DOT format.
@@ -86,14 +179,10 @@ func (v *Validator) Export(writer io.Writer) {
 	v.cmplr.Export(writer)
 }
 
-// Return a list of failure messages (if any) generated during validation.
-func (v *Validator) Failures() []error {
-	return v.act.errors
-}
-
-// Clear the list of failure messages.
-func (v *Validator) ClearFailures() {
-	v.act.errors = v.act.errors[:0]
+// Export the compiler's rulesets to GraphViz DOT format, highlighting the
+// path taken in the given trace.
+func (v *Validator) ExportTrace(writer io.Writer, trace *dag.Trace) {
+	v.cmplr.ExportTrace(writer, trace)
 }
 
 // ** Functions:
@@ -101,9 +190,9 @@ func (v *Validator) ClearFailures() {
 // Create a new validator with the default action set and predicate list.
 func NewValidator(ctx context.Context) *Validator {
 	inst := &Validator{
-		act: &actions{
-			errors: []error{},
-		},
+		act:      &actions{},
+		bindings: NewBindings(),
+		types:    make(map[reflect.Type]bool),
 	}
 
 	dag := dag.NewCompilerWithPredicates(
@@ -117,4 +206,72 @@ func NewValidator(ctx context.Context) *Validator {
 	return inst
 }
 
+// Add the rule name, failure reason and field to the parameters of each
+// rule's failure specification, so failure actions can record them.
+//
+// The field is taken from the `field` parameter if there is one, or
+// otherwise from the attribute of the rule's last condition.
+//
+// Unless already given, the operator and expected value are taken from
+// the rule's last condition.
+func annotateRules(specs []dag.RuleSpec) []dag.RuleSpec {
+	annotated := slices.Clone(specs)
+
+	for idx := range annotated {
+		spec := &annotated[idx]
+		if len(spec.Failure.Perform) == 0 {
+			continue
+		}
+
+		params := maps.Clone(spec.Failure.Params)
+		if params == nil {
+			params = make(dag.ActionParams)
+		}
+
+		params[paramRuleName] = spec.Name
+		params[paramRuleReason] = spec.Failure.Reason
+
+		var last dag.ConditionSpec
+
+		if count := len(spec.Conditions); count > 0 {
+			last = spec.Conditions[count-1]
+		}
+
+		attr, op, expected := describeCondition(last)
+
+		if field, ok := params["field"]; ok {
+			params[paramRuleField] = field
+		} else {
+			params[paramRuleField] = attr
+		}
+
+		if _, ok := params[paramRuleOperator]; !ok {
+			params[paramRuleOperator] = op
+			params[paramRuleExpected] = expected
+		}
+
+		spec.Failure.Params = params
+	}
+
+	return annotated
+}
+
+// Return the attribute, operator and value of a condition for use in
+// reports.
+//
+// Negated conditions have their operator prefixed with `not`.  Compound
+// conditions have none of them.
+func describeCondition(cond dag.ConditionSpec) (string, string, any) {
+	if cond.Not != nil {
+		attr, op, expected := describeCondition(*cond.Not)
+		if len(op) == 0 {
+			return attr, "", nil
+		}
+
+		return attr, "not " + op, expected
+	}
+
+	return cond.Attribute, cond.Operator, cond.Value
+}
+
 // * validator.go ends here.
@@ -0,0 +1,372 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// codec.go --- Record compression and encryption.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//go:build amd64 || arm64 || riscv64
+
+// * Comments:
+
+//
+// Logs whose header carries `FeatureCompressed` or `FeatureEncrypted` do
+// not store keys and values directly.  Each record instead has a key
+// length of zero and a value holding an envelope:
+//
+//	flags (1 byte) | nonce (encrypted logs only) | body
+//
+// The body is the key length as a uvarint, followed by the key and the
+// value.  If `envDeflated` is set in the flags then the body has been
+// DEFLATE-compressed, which is only done when it makes the record smaller.
+//
+// In encrypted logs the body is then sealed with AES-GCM under a random
+// nonce.  The record's LSN, timestamp and envelope flags are authenticated
+// as additional data, so they cannot be altered or swapped between
+// records.
+//
+// The record CRC covers the envelope as stored, so torn writes are still
+// detected before anything is decrypted.  A record that passes its CRC
+// but fails authentication is reported as an error rather than as a torn
+// tail, so that opening a log with the wrong key can never truncate it.
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	envDeflated  byte = 1 << 0 // Envelope body is DEFLATE-compressed.
+	envKnown          = envDeflated
+	envFlagsSize      = 1
+
+	// Size of the additional data authenticated with each record.
+	envAADSize = i64Size + i64Size + envFlagsSize
+)
+
+// * Variables:
+
+var (
+	ErrEncryptionKey      = errors.Base("missing or invalid encryption key")
+	ErrFeatureMismatch    = errors.Base("WAL features do not match policy")
+	ErrUnsupportedFeature = errors.Base("unsupported WAL feature")
+	ErrDecryptFailed      = errors.Base("record failed authentication")
+)
+
+// * Code:
+
+// ** Types:
+
+// Encoder and decoder for record envelopes.
+type recordCodec struct {
+	aead     cipher.AEAD
+	writers  sync.Pool // of *flate.Writer
+	maxKey   uint32
+	maxVal   uint32
+	compress bool
+}
+
+// ** Methods:
+
+// Largest envelope that can hold a key and value within the limits.
+func (c *recordCodec) maxStored() uint32 {
+	size := int64(envFlagsSize) + c.maxBody()
+
+	if c.aead != nil {
+		size += int64(c.aead.NonceSize() + c.aead.Overhead())
+	}
+
+	if size > math.MaxUint32 {
+		return math.MaxUint32
+	}
+
+	return uint32(size)
+}
+
+// Largest plaintext body that can hold a key and value within the limits.
+func (c *recordCodec) maxBody() int64 {
+	return int64(binary.MaxVarintLen32) + int64(c.maxKey) + int64(c.maxVal)
+}
+
+// Build the additional data authenticated with a record.
+func (c *recordCodec) aad(lsn, tsu uint64, flags byte) []byte {
+	var aad [envAADSize]byte
+
+	binary.LittleEndian.PutUint64(aad[0:], lsn)
+	binary.LittleEndian.PutUint64(aad[i64Size:], tsu)
+	aad[i64Size+i64Size] = flags
+
+	return aad[:]
+}
+
+// Wrap a key and value in an envelope.
+func (c *recordCodec) seal(lsn, tsu uint64, key, val []byte) ([]byte, error) {
+	var flags byte
+
+	body := make([]byte, 0, binary.MaxVarintLen32+len(key)+len(val))
+	body = binary.AppendUvarint(body, uint64(len(key)))
+	body = append(body, key...)
+	body = append(body, val...)
+
+	if c.compress {
+		if packed, ok := c.deflate(body); ok {
+			body = packed
+			flags |= envDeflated
+		}
+	}
+
+	if c.aead == nil {
+		return append([]byte{flags}, body...), nil
+	}
+
+	nonceSize := c.aead.NonceSize()
+	env := make([]byte,
+		envFlagsSize+nonceSize,
+		envFlagsSize+nonceSize+len(body)+c.aead.Overhead())
+	env[0] = flags
+	nonce := env[envFlagsSize:]
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c.aead.Seal(env, nonce, body, c.aad(lsn, tsu, flags)), nil
+}
+
+// Unwrap the key and value from an envelope.
+//
+//nolint:cyclop
+func (c *recordCodec) open(lsn, tsu uint64, env []byte) ([]byte, []byte, error) {
+	if len(env) < envFlagsSize {
+		return nil, nil, errors.WithMessagef(
+			ErrCorruptRecord,
+			"empty envelope at LSN %d",
+			lsn)
+	}
+
+	flags := env[0]
+	body := env[envFlagsSize:]
+
+	if flags&^envKnown != 0 {
+		return nil, nil, errors.WithMessagef(
+			ErrCorruptRecord,
+			"unknown envelope flags 0x%X at LSN %d",
+			flags,
+			lsn)
+	}
+
+	if c.aead != nil {
+		nonceSize := c.aead.NonceSize()
+
+		if len(body) < nonceSize+c.aead.Overhead() {
+			return nil, nil, errors.WithMessagef(
+				ErrCorruptRecord,
+				"short envelope at LSN %d",
+				lsn)
+		}
+
+		plain, err := c.aead.Open(
+			nil,
+			body[:nonceSize],
+			body[nonceSize:],
+			c.aad(lsn, tsu, flags))
+		if err != nil {
+			return nil, nil, errors.WithMessagef(
+				ErrDecryptFailed,
+				"LSN %d",
+				lsn)
+		}
+
+		body = plain
+	}
+
+	if flags&envDeflated != 0 {
+		var err error
+
+		if body, err = c.inflate(body); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+
+	klen, used := binary.Uvarint(body)
+	if used <= 0 || klen > uint64(len(body)-used) {
+		return nil, nil, errors.WithMessagef(
+			ErrCorruptRecord,
+			"malformed envelope at LSN %d",
+			lsn)
+	}
+
+	body = body[used:]
+	key, val := body[:klen], body[klen:]
+
+	if klen > uint64(c.maxKey) {
+		return nil, nil, errors.WithMessagef(
+			ErrKeyTooLarge,
+			"key length %d",
+			klen)
+	}
+
+	if uint64(len(val)) > uint64(c.maxVal) {
+		return nil, nil, errors.WithMessagef(
+			ErrValueTooLarge,
+			"value length %d",
+			len(val))
+	}
+
+	return key, val, nil
+}
+
+// Compress a body, returning false if that does not make it smaller.
+func (c *recordCodec) deflate(body []byte) ([]byte, bool) {
+	var buf bytes.Buffer
+
+	wtr, ok := c.writers.Get().(*flate.Writer)
+	if ok {
+		wtr.Reset(&buf)
+	} else {
+		// Only fails for an invalid level.
+		wtr, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+
+	defer c.writers.Put(wtr)
+
+	if _, err := wtr.Write(body); err != nil {
+		return nil, false
+	}
+
+	if err := wtr.Close(); err != nil || buf.Len() >= len(body) {
+		return nil, false
+	}
+
+	return buf.Bytes(), true
+}
+
+// Decompress a body, refusing to produce more than the limits allow.
+func (c *recordCodec) inflate(body []byte) ([]byte, error) {
+	limit := c.maxBody()
+	rdr := flate.NewReader(bytes.NewReader(body))
+
+	defer rdr.Close()
+
+	out, err := io.ReadAll(io.LimitReader(rdr, limit+1))
+	if err != nil {
+		return nil, errors.WithMessagef(
+			ErrCorruptRecord,
+			"inflate: %s",
+			err.Error())
+	}
+
+	if int64(len(out)) > limit {
+		return nil, errors.WithMessagef(
+			ErrCorruptRecord,
+			"inflated body exceeds %d bytes",
+			limit)
+	}
+
+	return out, nil
+}
+
+// ** Functions:
+
+// Create the codec for a log with the given header features.
+//
+// Returns nil if records in the log are stored as-is.
+func newRecordCodec(features uint64, pol Policy) (*recordCodec, error) {
+	if unknown := features &^ knownFeatures; unknown != 0 {
+		return nil, errors.WithMessagef(
+			ErrUnsupportedFeature,
+			"features 0x%X",
+			unknown)
+	}
+
+	encrypted := features&FeatureEncrypted != 0
+
+	if encrypted && len(pol.EncryptionKey) == 0 {
+		return nil, errors.WithMessage(
+			ErrEncryptionKey,
+			"log is encrypted")
+	}
+
+	if features&(FeatureCompressed|FeatureEncrypted) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	codec := &recordCodec{
+		maxKey:   pol.MaxKeyBytes,
+		maxVal:   pol.MaxValueBytes,
+		compress: features&FeatureCompressed != 0}
+
+	if !encrypted {
+		return codec, nil
+	}
+
+	block, err := aes.NewCipher(pol.EncryptionKey)
+	if err != nil {
+		return nil, errors.WithMessage(ErrEncryptionKey, err.Error())
+	}
+
+	if codec.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return codec, nil
+}
+
+// Check that a log's features agree with the policy it is opened for
+// writing with.
+//
+// Records are always written with the log's own features, so compression
+// may differ, but a log must not be appended to in plaintext when the
+// policy asks for encryption.
+func checkWriteFeatures(features uint64, pol Policy) error {
+	if len(pol.EncryptionKey) > 0 && features&FeatureEncrypted == 0 {
+		return errors.WithMessage(
+			ErrFeatureMismatch,
+			"log is not encrypted")
+	}
+
+	return nil
+}
+
+// * codec.go ends here.
@@ -0,0 +1,281 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// codec_test.go --- Record compression and encryption tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"strings"
+	"testing"
+)
+
+// * Code:
+
+// ** helpers:
+
+var testKey = bytes.Repeat([]byte{0x42}, 32)
+
+func codecPolicy(compress bool, key []byte) Policy {
+	p := tinyPolicy()
+	p.MaxValueBytes = 64 * 1024
+	p.Compress = compress
+	p.EncryptionKey = key
+	return p
+}
+
+func compressible(lsn uint64) []byte {
+	return []byte(strings.Repeat("customer record ", 200) + string(rune('a'+lsn%26)))
+}
+
+// Append a few compressible records, one of them with group commit.
+func appendCodecRecords(t *testing.T, w WriteAheadLog) {
+	t.Helper()
+	for lsn := uint64(1); lsn <= 3; lsn++ {
+		mustAppend(t, w, lsn, int64(lsn), []byte("k"), compressible(lsn))
+	}
+	if err := w.AppendSync(4, 4, []byte("k4"), randBytes(64)); err != nil {
+		t.Fatalf("append sync: %v", err)
+	}
+}
+
+func checkCodecReplay(t *testing.T, w WriteAheadLog) {
+	t.Helper()
+	got, err := collectReplay(w, 0)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(got))
+	}
+	for _, rec := range got[:3] {
+		if string(rec.K) != "k" || !bytes.Equal(rec.V, compressible(rec.LSN)) {
+			t.Fatalf("record %d mismatch", rec.LSN)
+		}
+		if rec.TS != int64(rec.LSN) {
+			t.Fatalf("record %d timestamp %d", rec.LSN, rec.TS)
+		}
+	}
+	if string(got[3].K) != "k4" || len(got[3].V) != 64 {
+		t.Fatalf("record 4 mismatch: %q %d", got[3].K, len(got[3].V))
+	}
+}
+
+func readFeatures(t *testing.T, path string) Header {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open raw: %v", err)
+	}
+	defer f.Close()
+	hdr, err := ReadHeader(f)
+	if err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	return hdr
+}
+
+// ** Tests:
+
+func TestCodec_CompressedRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	w, path := open(t, codecPolicy(true, nil))
+	appendCodecRecords(t, w)
+	checkCodecReplay(t, w)
+	_ = w.Close()
+
+	hdr := readFeatures(t, path)
+	if !hdr.IsCompressed() || hdr.IsEncrypted() || !hdr.HasCRC32C() {
+		t.Fatalf("features 0x%X", hdr.Features)
+	}
+
+	if size := fileSize(t, path); size > int64(len(compressible(1))) {
+		t.Fatalf("log not compressed: %d bytes", size)
+	}
+
+	// The header decides, so a reader without the flag still decodes.
+	w2, err := OpenWALWithPolicy(makeLogger(), path, codecPolicy(false, nil))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer w2.Close()
+	checkCodecReplay(t, w2)
+}
+
+func TestCodec_EncryptedRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, compress := range []bool{false, true} {
+		w, path := open(t, codecPolicy(compress, testKey))
+		appendCodecRecords(t, w)
+		checkCodecReplay(t, w)
+		_ = w.Close()
+
+		hdr := readFeatures(t, path)
+		if !hdr.IsEncrypted() || hdr.IsCompressed() != compress {
+			t.Fatalf("features 0x%X", hdr.Features)
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read raw: %v", err)
+		}
+		if bytes.Contains(raw, []byte("customer record")) {
+			t.Fatalf("plaintext found in encrypted log")
+		}
+
+		w2, err := OpenWALWithPolicy(makeLogger(), path, codecPolicy(false, testKey))
+		if err != nil {
+			t.Fatalf("reopen: %v", err)
+		}
+		checkCodecReplay(t, w2)
+		_ = w2.Close()
+	}
+}
+
+func TestCodec_KeyErrors(t *testing.T) {
+	t.Parallel()
+
+	w, path := open(t, codecPolicy(false, testKey))
+	appendCodecRecords(t, w)
+	_ = w.Close()
+	size := fileSize(t, path)
+
+	if _, err := OpenWALWithPolicy(makeLogger(), path, tinyPolicy()); !errors.Is(err, ErrEncryptionKey) {
+		t.Fatalf("expected ErrEncryptionKey, got %v", err)
+	}
+
+	wrong := bytes.Repeat([]byte{0x24}, 32)
+	w2, err := OpenWALWithPolicy(makeLogger(), path, codecPolicy(false, wrong))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if _, err := collectReplay(w2, 0); !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("expected ErrDecryptFailed, got %v", err)
+	}
+	_ = w2.Close()
+
+	if fileSize(t, path) != size {
+		t.Fatalf("wrong key changed the log")
+	}
+
+	plain, plainPath := open(t, tinyPolicy())
+	_ = plain.Close()
+	if _, err := OpenWALWithPolicy(makeLogger(), plainPath, codecPolicy(false, testKey)); !errors.Is(err, ErrFeatureMismatch) {
+		t.Fatalf("expected ErrFeatureMismatch, got %v", err)
+	}
+
+	if _, err := OpenWALWithPolicy(makeLogger(), tmpPath(t), codecPolicy(false, []byte("short"))); !errors.Is(err, ErrEncryptionKey) {
+		t.Fatalf("expected ErrEncryptionKey, got %v", err)
+	}
+}
+
+func TestCodec_TamperedRecord(t *testing.T) {
+	t.Parallel()
+
+	w, path := open(t, codecPolicy(false, testKey))
+	mustAppend(t, w, 1, 1, []byte("k"), []byte("secret"))
+	_ = w.Close()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read raw: %v", err)
+	}
+
+	// Change the LSN and fix up the CRC, so only authentication can
+	// notice.
+	binary.LittleEndian.PutUint64(raw[HeaderSize+i32Size:], 7)
+	crc := crc32.Checksum(raw[HeaderSize+i32Size:len(raw)-i32Size], crc32.MakeTable(crc32.Castagnoli))
+	binary.LittleEndian.PutUint32(raw[len(raw)-i32Size:], crc)
+
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("write raw: %v", err)
+	}
+
+	if _, err := ScanFileWithKey(makeLogger(), path, testKey, nil); !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("expected ErrDecryptFailed, got %v", err)
+	}
+}
+
+func TestCodec_EncryptedSegments(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pol := codecPolicy(true, testKey)
+	pol.SegmentMaxBytes = 256
+
+	w := openSegmented(t, dir, pol)
+	appendRange(t, w, 1, 20)
+	if len(w.Segments()) < 2 {
+		t.Fatalf("expected rotation, got %d segments", len(w.Segments()))
+	}
+	_ = w.Close()
+
+	w = openSegmented(t, dir, pol)
+	defer w.Close()
+	checkLSNs(t, replayLSNs(t, w, 0), 1, 20)
+}
+
+func TestCodec_ScanWithKey(t *testing.T) {
+	t.Parallel()
+
+	w, path := open(t, codecPolicy(true, testKey))
+	appendCodecRecords(t, w)
+	_ = w.Close()
+
+	if _, err := ScanFile(makeLogger(), path, nil); !errors.Is(err, ErrEncryptionKey) {
+		t.Fatalf("expected ErrEncryptionKey, got %v", err)
+	}
+
+	var keys []string
+	report, err := ScanFileWithKey(makeLogger(), path, testKey, func(rec *Record) error {
+		keys = append(keys, string(rec.Key))
+		return nil
+	})
+	if err != nil || report.Problem != nil {
+		t.Fatalf("scan: %v, %v", err, report.Problem)
+	}
+	if strings.Join(keys, ",") != "k,k,k,k4" {
+		t.Fatalf("keys: %v", keys)
+	}
+}
+
+// * codec_test.go ends here.
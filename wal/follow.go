@@ -0,0 +1,345 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// follow.go --- Following a write-ahead log as it grows.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//go:build amd64 || arm64 || riscv64
+
+// * Comments:
+
+//
+// A follower delivers the records that `Replay` would, then waits for more.
+// Appends wake followers through a `notifier`, which only allocates a wake
+// channel when a follower is actually waiting.
+//
+// Followers read through the log's own records and stop at the first
+// incomplete or corrupt one, exactly as `Replay` does.  They retry from that
+// point on every wake-up, so a record that was merely half-written is picked
+// up once it is complete.  Records behind a genuinely torn record are never
+// delivered, which matches what `Replay` would do.
+//
+// A single-file log that is `Reset` is followed from its start again.  A
+// segmented log is followed segment by segment; segments deleted by a
+// checkpoint before the follower reached them are skipped.
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Variables:
+
+var (
+	ErrLogClosed = errors.Base("write-ahead log closed")
+)
+
+// * Code:
+
+// ** Notifier:
+
+// Broadcasts wake-ups to followers.
+type notifier struct {
+	wake   chan struct{}
+	mu     sync.Mutex
+	closed bool
+}
+
+// Return a channel that is closed on the next broadcast.
+//
+// Returns false if the notifier has been closed.
+func (n *notifier) wait() (<-chan struct{}, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return nil, false
+	}
+
+	if n.wake == nil {
+		n.wake = make(chan struct{})
+	}
+
+	return n.wake, true
+}
+
+// Wake every waiting follower.
+func (n *notifier) broadcast() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.wake != nil {
+		close(n.wake)
+		n.wake = nil
+	}
+}
+
+// Wake every waiting follower for the last time.
+func (n *notifier) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.closed = true
+
+	if n.wake != nil {
+		close(n.wake)
+		n.wake = nil
+	}
+}
+
+// Has the notifier been closed?
+func (n *notifier) isClosed() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.closed
+}
+
+// ** Single-file log:
+
+//nolint:cyclop
+func (wal *writeAheadLog) Follow(ctx context.Context, baseLSN uint64, applyCb ApplyCallbackFn) (uint64, error) {
+	var (
+		pos    = int64(HeaderSize)
+		maxLSN = baseLSN
+	)
+
+	wal.mu.Lock()
+	resets := wal.resets
+	wal.mu.Unlock()
+
+	for {
+		// Take the wake channel first so no append can be missed.
+		wake, open := wal.follow.wait()
+		if !open {
+			return maxLSN, errors.WithStack(ErrLogClosed)
+		}
+
+		wal.mu.Lock()
+		end := wal.bytes
+		gen := wal.resets
+		wal.mu.Unlock()
+
+		if gen != resets || end < pos {
+			pos = HeaderSize
+			resets = gen
+		}
+
+		lsn, next, err := wal.replayRange(pos, end, baseLSN, applyCb)
+		maxLSN = max(maxLSN, lsn)
+
+		if err != nil {
+			if wal.follow.isClosed() {
+				return maxLSN, errors.WithStack(ErrLogClosed)
+			}
+
+			// A reset may have truncated the file under us.
+			wal.mu.Lock()
+			raced := wal.resets != resets
+			wal.mu.Unlock()
+
+			if raced {
+				continue
+			}
+
+			return maxLSN, errors.WithStack(err)
+		}
+
+		if next > pos {
+			pos = next
+
+			continue
+		}
+
+		if err := sleepUntilWoken(ctx, wake); err != nil {
+			return maxLSN, err
+		}
+	}
+}
+
+// ** Segmented log:
+
+// Position of a follower within a segmented log.
+type segmentFollower struct {
+	sl      *segmentedLog
+	reader  *writeAheadLog
+	applyCb ApplyCallbackFn
+	baseLSN uint64
+	maxLSN  uint64
+	seq     uint64 // Segment being read, or the last one finished.
+	pos     int64
+}
+
+// Stop reading the current segment.
+func (sf *segmentFollower) release() {
+	if sf.reader != nil {
+		_ = sf.reader.Close()
+		sf.reader = nil
+	}
+}
+
+// Open the first segment after the current one that has anything to offer.
+//
+// Returns false if there is no such segment yet.
+func (sf *segmentFollower) advance(segs []SegmentInfo) (bool, error) {
+	for _, seg := range segs {
+		if seg.Seq <= sf.seq || (seg.Sealed && seg.coveredBy(sf.baseLSN)) {
+			continue
+		}
+
+		reader, err := openLogReader(sf.sl.lgr, SegmentPath(sf.sl.dir, seg.Seq), sf.sl.policy)
+
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// Deleted by a checkpoint since the snapshot.
+			sf.seq = seg.Seq
+
+			continue
+
+		case err != nil:
+			return false, errors.WithStack(err)
+		}
+
+		sf.reader = reader
+		sf.seq = seg.Seq
+		sf.pos = HeaderSize
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Read whatever is available, returning true if any progress was made.
+func (sf *segmentFollower) step(segs []SegmentInfo) (bool, error) {
+	if sf.reader == nil {
+		return sf.advance(segs)
+	}
+
+	end, sealed, found := int64(0), true, false
+
+	for _, seg := range segs {
+		if seg.Seq == sf.seq {
+			end, sealed, found = seg.Bytes, seg.Sealed, true
+
+			break
+		}
+	}
+
+	if !found {
+		finfo, err := sf.reader.fptr.Stat()
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		end = finfo.Size()
+	}
+
+	lsn, next, err := sf.reader.replayRange(sf.pos, end, sf.baseLSN, sf.applyCb)
+	sf.maxLSN = max(sf.maxLSN, lsn)
+
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	progressed := next > sf.pos
+	sf.pos = next
+
+	if sealed {
+		if next < end {
+			sf.sl.lgr.Warn(
+				"Write Ahead Log follower skipping torn segment tail",
+				"segment", sf.seq,
+				"bytes", end-next)
+		}
+
+		sf.release()
+
+		return true, nil
+	}
+
+	return progressed, nil
+}
+
+// Follow walks the segments in order, then waits on the active one.
+//
+// A segment that vanishes while being read (because of `Reset`) is read to
+// the end of its file before moving on.
+func (sl *segmentedLog) Follow(ctx context.Context, baseLSN uint64, applyCb ApplyCallbackFn) (uint64, error) {
+	flw := &segmentFollower{
+		sl:      sl,
+		applyCb: applyCb,
+		baseLSN: baseLSN,
+		maxLSN:  baseLSN}
+
+	defer flw.release()
+
+	for {
+		wake, open := sl.follow.wait()
+		if !open {
+			return flw.maxLSN, errors.WithStack(ErrLogClosed)
+		}
+
+		progressed, err := flw.step(sl.Segments())
+		if err != nil {
+			return flw.maxLSN, err
+		}
+
+		if progressed {
+			continue
+		}
+
+		if err := sleepUntilWoken(ctx, wake); err != nil {
+			return flw.maxLSN, err
+		}
+	}
+}
+
+// ** Functions:
+
+// Wait for a wake-up or for the context to be done.
+func sleepUntilWoken(ctx context.Context, wake <-chan struct{}) error {
+	select {
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+
+	case <-wake:
+		return nil
+	}
+}
+
+// * follow.go ends here.
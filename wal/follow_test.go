@@ -0,0 +1,213 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// follow_test.go --- Write-ahead log follower tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// * Code:
+
+// ** helpers:
+
+type followResult struct {
+	max uint64
+	err error
+}
+
+// Start following in the background, sending each LSN to the returned
+// channel.
+func startFollow(t *testing.T, w WriteAheadLog, base uint64) (context.CancelFunc, <-chan uint64, <-chan followResult) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	lsns := make(chan uint64, 1024)
+	done := make(chan followResult, 1)
+
+	go func() {
+		max, err := w.Follow(ctx, base, func(lsn uint64, _ int64, _, _ []byte) error {
+			lsns <- lsn
+			return nil
+		})
+		done <- followResult{max, err}
+	}()
+
+	return cancel, lsns, done
+}
+
+func expectLSNs(t *testing.T, lsns <-chan uint64, from, to uint64) {
+	t.Helper()
+	for want := from; want <= to; want++ {
+		select {
+		case got := <-lsns:
+			if got != want {
+				t.Fatalf("follower delivered %d, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for lsn %d", want)
+		}
+	}
+}
+
+func expectNothing(t *testing.T, lsns <-chan uint64) {
+	t.Helper()
+	select {
+	case got := <-lsns:
+		t.Fatalf("unexpected lsn %d", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func waitResult(t *testing.T, done <-chan followResult) followResult {
+	t.Helper()
+	select {
+	case res := <-done:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatalf("follower did not return")
+	}
+	return followResult{}
+}
+
+// ** Tests:
+
+func TestFollow_ExistingThenLive(t *testing.T) {
+	t.Parallel()
+
+	w, _ := open(t, tinyPolicy())
+	defer w.Close()
+
+	appendRange(t, w, 1, 5)
+
+	cancel, lsns, done := startFollow(t, w, 2)
+	expectLSNs(t, lsns, 3, 5)
+	expectNothing(t, lsns)
+
+	appendRange(t, w, 6, 10)
+	if err := w.AppendSync(11, 1, []byte("k"), []byte("v")); err != nil {
+		t.Fatalf("append sync: %v", err)
+	}
+	expectLSNs(t, lsns, 6, 11)
+
+	cancel()
+	res := waitResult(t, done)
+	if !errors.Is(res.err, context.Canceled) || res.max != 11 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestFollow_Reset(t *testing.T) {
+	t.Parallel()
+
+	w, _ := open(t, tinyPolicy())
+	defer w.Close()
+
+	appendRange(t, w, 1, 3)
+
+	cancel, lsns, _ := startFollow(t, w, 0)
+	defer cancel()
+	expectLSNs(t, lsns, 1, 3)
+
+	if err := w.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	appendRange(t, w, 4, 6)
+	expectLSNs(t, lsns, 4, 6)
+}
+
+func TestFollow_CloseAndCallbackError(t *testing.T) {
+	t.Parallel()
+
+	w, _ := open(t, tinyPolicy())
+	appendRange(t, w, 1, 2)
+
+	boom := errors.New("boom")
+	if _, err := w.Follow(context.Background(), 0, func(uint64, int64, []byte, []byte) error {
+		return boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("expected callback error, got %v", err)
+	}
+
+	_, lsns, done := startFollow(t, w, 0)
+	expectLSNs(t, lsns, 1, 2)
+
+	_ = w.Close()
+	if res := waitResult(t, done); !errors.Is(res.err, ErrLogClosed) {
+		t.Fatalf("expected ErrLogClosed, got %v", res.err)
+	}
+}
+
+func TestFollow_Segmented(t *testing.T) {
+	t.Parallel()
+
+	pol := tinyPolicy()
+	pol.SegmentMaxBytes = 512
+	w := openSegmented(t, t.TempDir(), pol)
+
+	appendRange(t, w, 1, 20)
+
+	cancel, lsns, done := startFollow(t, w, 10)
+	defer cancel()
+	expectLSNs(t, lsns, 11, 20)
+
+	// Live appends, across several rotations.
+	appendRange(t, w, 21, 60)
+	expectLSNs(t, lsns, 21, 60)
+
+	if err := w.Checkpoint(60); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	appendRange(t, w, 61, 65)
+	expectLSNs(t, lsns, 61, 65)
+
+	if err := w.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	appendRange(t, w, 66, 70)
+	expectLSNs(t, lsns, 66, 70)
+	expectNothing(t, lsns)
+
+	_ = w.Close()
+	if res := waitResult(t, done); !errors.Is(res.err, ErrLogClosed) || res.max != 70 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+// * follow_test.go ends here.
@@ -0,0 +1,250 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// groupcommit.go --- Group commit for synchronous appends.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//go:build amd64 || arm64 || riscv64
+
+// * Comments:
+
+//
+// `AppendSync` callers queue their records and the first caller to find no
+// commit in progress becomes the leader.  The leader takes everything that
+// has queued, writes it with a single write, fsyncs once, and tells every
+// caller in the batch how it went.
+//
+// Records that queue while the leader is busy form the next batch.  Rather
+// than keep leading (and keep its own caller waiting), the leader hands
+// leadership to the oldest queued caller, who commits that batch in turn.
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"sync"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Variables:
+
+// Sent to a queued caller to make it the next leader.  Never returned.
+//
+//nolint:gochecknoglobals
+var errGroupLeader = errors.Base("group commit leader")
+
+// * Code:
+
+// ** Types:
+
+// A queued synchronous append.
+type syncRequest struct {
+	done   chan error
+	key    []byte
+	val    []byte
+	lsn    uint64
+	tsu    uint64
+	klen   uint32
+	vlen   uint32
+	bufLen uint32
+}
+
+// Group commit state.
+type groupCommit struct {
+	queue   []*syncRequest
+	batches uint64 // Number of batches committed.
+	mu      sync.Mutex
+	leading bool
+}
+
+// ** Methods:
+
+func (wal *writeAheadLog) AppendSync(lsn uint64, tstamp int64, key, val []byte) error {
+	req, err := wal.newSyncRequest(lsn, tstamp, key, val)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	wal.group.mu.Lock()
+	wal.group.queue = append(wal.group.queue, req)
+	lead := !wal.group.leading
+	wal.group.leading = true
+	wal.group.mu.Unlock()
+
+	if !lead {
+		err := <-req.done
+		if !errors.Is(err, errGroupLeader) {
+			return err
+		}
+	}
+
+	wal.leadGroupCommit()
+
+	return <-req.done
+}
+
+// Validate a record and build its request.
+func (wal *writeAheadLog) newSyncRequest(lsn uint64, tstamp int64, key, val []byte) (*syncRequest, error) {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	klen, vlen, tsu, err := wal.validateKV(tstamp, key, val)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	klen, vlen, key, val, err = wal.sealKV(lsn, tsu, klen, vlen, key, val)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	size := recordSize(klen, vlen)
+
+	bufLen, ok := fitsAlloc(size)
+	if !ok {
+		return nil, errors.WithMessagef(
+			ErrRecordTooLarge,
+			"%d bytes",
+			size)
+	}
+
+	return &syncRequest{
+		done:   make(chan error, 1),
+		key:    key,
+		val:    val,
+		lsn:    lsn,
+		tsu:    tsu,
+		klen:   klen,
+		vlen:   vlen,
+		bufLen: bufLen}, nil
+}
+
+// Commit the queued batch, then pass leadership on or give it up.
+func (wal *writeAheadLog) leadGroupCommit() {
+	wal.group.mu.Lock()
+	batch := wal.group.queue
+	wal.group.queue = nil
+	wal.group.mu.Unlock()
+
+	err := wal.commitBatch(batch)
+
+	for _, req := range batch {
+		req.done <- err
+	}
+
+	wal.group.mu.Lock()
+	defer wal.group.mu.Unlock()
+
+	wal.group.batches++
+
+	if len(wal.group.queue) > 0 {
+		wal.group.queue[0].done <- errGroupLeader
+
+		return
+	}
+
+	wal.group.leading = false
+}
+
+// Write a batch of records with one write and one fsync.
+//
+// If the write fails then none of the batch is considered written, and the
+// next write will reuse the same offset.
+func (wal *writeAheadLog) commitBatch(batch []*syncRequest) error {
+	var total int
+
+	for _, req := range batch {
+		total += int(req.bufLen)
+	}
+
+	buf := make([]byte, total)
+	offset := 0
+
+	for _, req := range batch {
+		end := offset + int(req.bufLen)
+
+		encodeRecord(buf[offset:end], req.bufLen, req.lsn, req.tsu,
+			req.klen, req.vlen, req.key, req.val)
+		finaliseCRC(buf[offset:end], int(req.bufLen), wal.crcTab)
+
+		offset = end
+	}
+
+	end, err := wal.writeBatch(buf, batch)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// The fsync happens outside the lock so that the next batch can
+	// queue up, and plain appends can proceed, in the meantime.
+	if err := wal.fptr.Sync(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	// Unless a reset got in first.
+	if end > wal.lastSyncAt && end <= wal.bytes {
+		wal.lastSyncAt = end
+		wal.dirty = wal.bytes != wal.lastSyncAt
+	}
+
+	return nil
+}
+
+// Write an encoded batch at the end of the log, returning the new end.
+func (wal *writeAheadLog) writeBatch(buf []byte, batch []*syncRequest) (int64, error) {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	if err := wal.ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	if err := writeFullAt(wal.fptr, buf, wal.bytes); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	wal.bytes += int64(len(buf))
+	wal.dirty = true
+
+	for _, req := range batch {
+		wal.span.observe(req.lsn)
+	}
+
+	wal.follow.broadcast()
+
+	return wal.bytes, nil
+}
+
+// * groupcommit.go ends here.
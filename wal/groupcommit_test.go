@@ -0,0 +1,232 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// groupcommit_test.go --- Group commit tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// * Code:
+
+// ** Tests:
+
+func TestAppendSync_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	const writers = 32
+	const perWriter = 50
+
+	w, _ := open(t, tinyPolicy())
+	defer w.Close()
+
+	var (
+		next uint64
+		wg   sync.WaitGroup
+	)
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				lsn := atomic.AddUint64(&next, 1)
+				if err := w.AppendSync(lsn, 1, []byte("k"), randBytes(16)); err != nil {
+					t.Errorf("append sync: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	impl := w.(*writeAheadLog)
+	impl.mu.Lock()
+	dirty, synced := impl.dirty, impl.lastSyncAt == impl.bytes
+	impl.mu.Unlock()
+
+	if dirty || !synced {
+		t.Fatalf("log not durable after AppendSync (dirty=%v synced=%v)", dirty, synced)
+	}
+
+	impl.group.mu.Lock()
+	batches, leading, queued := impl.group.batches, impl.group.leading, len(impl.group.queue)
+	impl.group.mu.Unlock()
+
+	if batches == 0 || batches > writers*perWriter {
+		t.Fatalf("unexpected batch count %d", batches)
+	}
+	if leading || queued != 0 {
+		t.Fatalf("group commit left busy (leading=%v queued=%d)", leading, queued)
+	}
+	t.Logf("%d records in %d batches", writers*perWriter, batches)
+
+	recs, err := collectReplay(w, 0)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(recs) != writers*perWriter {
+		t.Fatalf("replayed %d records, want %d", len(recs), writers*perWriter)
+	}
+
+	lsns := make([]uint64, len(recs))
+	for i, r := range recs {
+		lsns[i] = r.LSN
+	}
+	sort.Slice(lsns, func(i, j int) bool { return lsns[i] < lsns[j] })
+	for i, lsn := range lsns {
+		if lsn != uint64(i+1) {
+			t.Fatalf("missing or duplicate lsn at %d: %d", i, lsn)
+		}
+	}
+}
+
+func TestAppendSync_MixedWithAppend(t *testing.T) {
+	t.Parallel()
+
+	w, _ := open(t, tinyPolicy())
+	defer w.Close()
+
+	mustAppend(t, w, 1, 1, []byte("a"), []byte("1"))
+	if err := w.AppendSync(2, 1, []byte("b"), []byte("2")); err != nil {
+		t.Fatalf("append sync: %v", err)
+	}
+	mustAppend(t, w, 3, 1, []byte("c"), []byte("3"))
+
+	recs, err := collectReplay(w, 0)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(recs) != 3 || recs[0].LSN != 1 || recs[1].LSN != 2 || recs[2].LSN != 3 {
+		t.Fatalf("unexpected records: %+v", recs)
+	}
+}
+
+func TestAppendSync_Errors(t *testing.T) {
+	t.Parallel()
+
+	w, _ := open(t, tinyPolicy())
+	defer w.Close()
+
+	if err := w.AppendSync(1, 1, make([]byte, 65), nil); !errors.Is(err, ErrKeyTooLarge) {
+		t.Fatalf("expected ErrKeyTooLarge, got %v", err)
+	}
+	if err := w.AppendSync(1, -1, nil, nil); !errors.Is(err, ErrTimestampNegative) {
+		t.Fatalf("expected ErrTimestampNegative, got %v", err)
+	}
+
+	w.(*writeAheadLog).Cancel()
+
+	if err := w.AppendSync(1, 1, nil, nil); err == nil {
+		t.Fatalf("expected an error after cancel")
+	}
+}
+
+func TestAppendSync_Segmented(t *testing.T) {
+	t.Parallel()
+
+	pol := tinyPolicy()
+	pol.SegmentMaxBytes = 2048
+	w := openSegmented(t, t.TempDir(), pol)
+	defer w.Close()
+
+	var (
+		next uint64
+		wg   sync.WaitGroup
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 40; j++ {
+				lsn := atomic.AddUint64(&next, 1)
+				if err := w.AppendSync(lsn, 1, []byte("k"), randBytes(32)); err != nil {
+					t.Errorf("append sync: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	segs := w.Segments()
+	if len(segs) < 2 {
+		t.Fatalf("expected rotation, got %d segments", len(segs))
+	}
+
+	var total uint64
+	for _, seg := range segs {
+		total += seg.Records
+	}
+	if total != 320 {
+		t.Fatalf("manifest counts %d records, want 320", total)
+	}
+
+	recs, err := collectReplay(w, 0)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(recs) != 320 {
+		t.Fatalf("replayed %d records, want 320", len(recs))
+	}
+}
+
+// ** Benchmarks:
+
+func BenchmarkAppendSync_Concurrent(b *testing.B) {
+	w, _ := openWithPolicy(b, benchPolicy(128))
+	defer w.Close()
+
+	var lsn uint64
+	val := make([]byte, 128)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := w.AppendSync(atomic.AddUint64(&lsn, 1), 1, []byte("k"), val); err != nil {
+				b.Fatalf("append sync: %v", err)
+			}
+		}
+	})
+}
+
+// * groupcommit_test.go ends here.
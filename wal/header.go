@@ -61,11 +61,20 @@ const (
 	// WAL makes use of 32-bit CRC Castagnoli.
 	FeatureCRC32C uint64 = 1 << 0
 
+	// WAL record payloads may be DEFLATE-compressed.
+	FeatureCompressed uint64 = 1 << 1
+
+	// WAL record payloads are sealed with AES-GCM.
+	FeatureEncrypted uint64 = 1 << 2
+
 	// Version number of the latest write-ahead log facility.
 	currentVersion = 1
 
-	// Current feature flags.
+	// Feature flags present in every new log.
 	currentFeatures = FeatureCRC32C
+
+	// Every feature flag this implementation understands.
+	knownFeatures = FeatureCRC32C | FeatureCompressed | FeatureEncrypted
 )
 
 // * Variables:
@@ -98,6 +107,14 @@ func (h *Header) HasCRC32C() bool {
 	return (h.Features&FeatureCRC32C != 0)
 }
 
+func (h *Header) IsCompressed() bool {
+	return (h.Features&FeatureCompressed != 0)
+}
+
+func (h *Header) IsEncrypted() bool {
+	return (h.Features&FeatureEncrypted != 0)
+}
+
 func (h *Header) sanity() bool {
 	if h.Magic != MagicNumber {
 		return false
@@ -164,7 +181,7 @@ func readHeader(fptr *os.File) (bool, Header, error) {
 	return sane, header, nil
 }
 
-func writeHeader(fptr *os.File) (int64, error) {
+func writeHeader(fptr *os.File, features uint64) (int64, error) {
 	var hdr = make([]byte, HeaderSize)
 
 	now, err := tstampU64(time.Now().Unix())
@@ -176,7 +193,7 @@ func writeHeader(fptr *os.File) (int64, error) {
 
 	enc.u32(MagicNumber)
 	enc.u32(VersionNumber)
-	enc.u64(currentFeatures)
+	enc.u64(currentFeatures | features)
 	enc.u64(now)
 
 	if _, err := fptr.WriteAt(hdr[:HeaderSize], 0); err != nil {
@@ -0,0 +1,181 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// inspect.go --- Offline inspection and repair of log files.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//go:build amd64 || arm64 || riscv64
+
+// * Comments:
+
+//
+// These functions work on a log file directly, without opening it as a
+// `WriteAheadLog`, and are meant for tools that examine logs after a crash.
+// Size limits from the policy are not applied, so a file written with a
+// generous policy can still be read.
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"context"
+	"math"
+	"os"
+
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Types:
+
+// A record found while scanning a log file.
+//
+// `Key` and `Value` are only valid during the visit.
+type Record struct {
+	Key       []byte
+	Value     []byte
+	Offset    int64  // Offset of the record within the file.
+	Size      int64  // Size of the record, including framing.
+	LSN       uint64 // Log sequence number.
+	Timestamp int64  // UNIX timestamp, in seconds.
+}
+
+// Outcome of scanning a log file.
+type ScanReport struct {
+	// Why the scan stopped before the end of the file.
+	//
+	// This wraps `ErrTornRecord` or `ErrCorruptRecord`, and is nil if
+	// every byte of the file was accounted for.
+	Problem error
+
+	Header   Header // The file's header.
+	FileSize int64  // Size of the file.
+	ValidEnd int64  // Offset just past the last valid record.
+	Records  uint64 // Number of valid records.
+}
+
+// ** Functions:
+
+// Scan every valid record in a log file, verifying CRCs as it goes.
+//
+// The scan stops at the first torn or corrupt record, which is described in
+// the report.  Errors are returned for unreadable files, invalid headers,
+// and errors returned by `visit`, which may be nil.
+//
+// Encrypted logs must be scanned with `ScanFileWithKey`.
+func ScanFile(ctx context.Context, path string, visit func(*Record) error) (ScanReport, error) {
+	return ScanFileWithKey(ctx, path, nil, visit)
+}
+
+// Scan every valid record in a log file that may be encrypted with the
+// given key.
+//
+// Records that pass their CRC but cannot be decrypted are reported as an
+// error wrapping `ErrDecryptFailed`.
+func ScanFileWithKey(ctx context.Context, path string, key []byte, visit func(*Record) error) (ScanReport, error) {
+	var report ScanReport
+
+	lgr, found := logger.TryGetLogger(ctx)
+	if !found {
+		lgr = logger.NewDefaultLogger()
+	}
+
+	reader, err := openLogReader(lgr, path, Policy{
+		MaxKeyBytes:   math.MaxUint32,
+		MaxValueBytes: math.MaxUint32,
+		EncryptionKey: key})
+	if err != nil {
+		return report, errors.WithStack(err)
+	}
+
+	defer reader.Close()
+
+	if report.Header, err = ReadHeader(reader.fptr); err != nil {
+		return report, errors.WithStack(err)
+	}
+
+	report.FileSize = reader.bytes
+
+	res, err := reader.walk(HeaderSize, reader.bytes, func(rec *recFields, pos int64) error {
+		report.Records++
+
+		if visit == nil {
+			return nil
+		}
+
+		return visit(&Record{
+			Key:       rec.key,
+			Value:     rec.val,
+			Offset:    pos,
+			Size:      recordSize(rec.klen, rec.vlen),
+			LSN:       rec.lsn,
+			Timestamp: rec.tstamp})
+	})
+
+	report.ValidEnd = res.end
+	report.Problem = res.problem
+
+	return report, errors.WithStack(err)
+}
+
+// Truncate a log file to the given size and fsync it.
+//
+// Use the `ValidEnd` of a scan report to cut off a torn or corrupt tail.
+func TruncateFile(path string, size int64) error {
+	if size < HeaderSize {
+		return errors.WithMessagef(ErrInvalidLog,
+			"cannot truncate below the header (%d bytes)",
+			size)
+	}
+
+	fptr, err := os.OpenFile(path, os.O_RDWR, defaultFileMode)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := fptr.Truncate(size); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if err := fptr.Sync(); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(fptr.Close())
+}
+
+// * inspect.go ends here.
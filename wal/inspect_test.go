@@ -0,0 +1,223 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// inspect_test.go --- Offline inspection tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// * Code:
+
+// ** helpers:
+
+// Write `n` records to a fresh log file and close it.
+func writeScanLog(t *testing.T, n uint64) string {
+	t.Helper()
+	w, path := open(t, tinyPolicy())
+	appendRange(t, w, 1, n)
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return path
+}
+
+func scanLSNs(t *testing.T, path string) ([]uint64, ScanReport) {
+	t.Helper()
+	var lsns []uint64
+	report, err := ScanFile(makeLogger(), path, func(rec *Record) error {
+		lsns = append(lsns, rec.LSN)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	return lsns, report
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	return info.Size()
+}
+
+// ** Tests:
+
+func TestScanFile_Clean(t *testing.T) {
+	t.Parallel()
+
+	path := writeScanLog(t, 5)
+
+	var offsets []int64
+	report, err := ScanFile(context.Background(), path, func(rec *Record) error {
+		if len(offsets) > 0 && offsets[len(offsets)-1] >= rec.Offset {
+			t.Errorf("offsets not increasing at LSN %d", rec.LSN)
+		}
+		offsets = append(offsets, rec.Offset)
+		if rec.Timestamp != int64(rec.LSN) {
+			t.Errorf("timestamp %d for LSN %d", rec.Timestamp, rec.LSN)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	if report.Problem != nil {
+		t.Fatalf("unexpected problem: %v", report.Problem)
+	}
+	if report.Records != 5 || len(offsets) != 5 {
+		t.Fatalf("records: %d (visited %d)", report.Records, len(offsets))
+	}
+	if offsets[0] != HeaderSize {
+		t.Fatalf("first record at %d, want %d", offsets[0], HeaderSize)
+	}
+	if report.ValidEnd != report.FileSize || report.FileSize != fileSize(t, path) {
+		t.Fatalf("valid end %d, file size %d", report.ValidEnd, report.FileSize)
+	}
+	if report.Header.Magic != MagicNumber || !report.Header.HasCRC32C() {
+		t.Fatalf("bad header: %#v", report.Header)
+	}
+}
+
+func TestScanFile_TornTailAndTruncate(t *testing.T) {
+	t.Parallel()
+
+	path := writeScanLog(t, 3)
+	clean := fileSize(t, path)
+
+	_, before := scanLSNs(t, path)
+	if err := os.Truncate(path, clean-3); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+
+	lsns, report := scanLSNs(t, path)
+	checkLSNs(t, lsns, 1, 2)
+
+	if !errors.Is(report.Problem, ErrTornRecord) {
+		t.Fatalf("expected torn record, got %v", report.Problem)
+	}
+	if report.ValidEnd >= report.FileSize || report.ValidEnd >= before.ValidEnd {
+		t.Fatalf("valid end %d, file size %d", report.ValidEnd, report.FileSize)
+	}
+
+	if err := TruncateFile(path, report.ValidEnd); err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+
+	lsns, report = scanLSNs(t, path)
+	checkLSNs(t, lsns, 1, 2)
+
+	if report.Problem != nil || report.ValidEnd != fileSize(t, path) {
+		t.Fatalf("not repaired: %v at %d", report.Problem, report.ValidEnd)
+	}
+}
+
+func TestScanFile_CorruptRecordOffset(t *testing.T) {
+	t.Parallel()
+
+	path := writeScanLog(t, 4)
+
+	var third *Record
+	_, err := ScanFile(makeLogger(), path, func(rec *Record) error {
+		if rec.LSN == 3 {
+			third = &Record{Offset: rec.Offset, Size: rec.Size}
+		}
+		return nil
+	})
+	if err != nil || third == nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	// Flip the last byte of the third record's payload.
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("open raw: %v", err)
+	}
+	var b [1]byte
+	_, _ = f.ReadAt(b[:], third.Offset+third.Size-1)
+	b[0] ^= 0xFF
+	if _, err := f.WriteAt(b[:], third.Offset+third.Size-1); err != nil {
+		t.Fatalf("write flip: %v", err)
+	}
+	_ = f.Close()
+
+	lsns, report := scanLSNs(t, path)
+	checkLSNs(t, lsns, 1, 2)
+
+	if !errors.Is(report.Problem, ErrCorruptRecord) {
+		t.Fatalf("expected corrupt record, got %v", report.Problem)
+	}
+	if report.ValidEnd != third.Offset {
+		t.Fatalf("valid end %d, want %d", report.ValidEnd, third.Offset)
+	}
+}
+
+func TestScanFile_VisitError(t *testing.T) {
+	t.Parallel()
+
+	path := writeScanLog(t, 3)
+	stop := errors.New("stop")
+
+	_, err := ScanFile(makeLogger(), path, func(rec *Record) error {
+		if rec.LSN == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected visit error, got %v", err)
+	}
+}
+
+func TestTruncateFile_RefusesHeader(t *testing.T) {
+	t.Parallel()
+
+	path := writeScanLog(t, 1)
+
+	if err := TruncateFile(path, HeaderSize-1); !errors.Is(err, ErrInvalidLog) {
+		t.Fatalf("expected ErrInvalidLog, got %v", err)
+	}
+}
+
+// * inspect_test.go ends here.
@@ -0,0 +1,352 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// manifest.go --- Segmented write-ahead log manifest.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+//
+// The manifest lists the segments of a segmented write-ahead log in order,
+// along with the checkpoint LSN.  It is rewritten atomically (write to a
+// temporary file, fsync, rename, fsync directory) whenever a segment is
+// sealed, created, or deleted.
+//
+// Binary layout:
+//
+//	[magic:u32][version:u32][checkpoint:u64][count:u32]
+//	count * [seq:u64][first:u64][last:u64][records:u64][bytes:u64]
+//	        [created:u64][flags:u32]
+//	[crc:u32]
+//
+// The CRC is a CRC32C of everything before it.
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Constants:
+
+const (
+	// Name of the manifest file within a segmented log's directory.
+	ManifestName = "MANIFEST"
+
+	// File name suffix of segment files.
+	SegmentSuffix = ".wal"
+
+	// Manifest magic number.
+	//
+	// This is the string `WALM` expressed as a little-endian integer.
+	ManifestMagic = 0x4D4C4157
+
+	// Manifest version number.
+	ManifestVersion = 1
+
+	// Segment has been sealed and will receive no more records.
+	segmentSealed uint32 = 1 << 0
+
+	// Size of the manifest preamble.
+	manifestHeadSize = i32Size + i32Size + i64Size + i32Size
+
+	// Size of a manifest entry.
+	manifestEntrySize = 6*i64Size + i32Size
+
+	// Number of digits in a segment file name.
+	segmentNameDigits = 20
+
+	defaultDirMode = 0o755
+)
+
+// * Variables:
+
+var (
+	ErrInvalidManifest = errors.Base("WAL manifest is invalid")
+)
+
+// * Code:
+
+// ** Types:
+
+// Description of one segment of a segmented write-ahead log.
+type SegmentInfo struct {
+	Seq      uint64    // Segment sequence number.
+	FirstLSN uint64    // LSN of the first record.
+	LastLSN  uint64    // Highest LSN in the segment.
+	Records  uint64    // Number of records.
+	Bytes    int64     // Size of the segment file, including header.
+	Created  time.Time // When the segment was created.
+	Sealed   bool      // Has the segment been sealed?
+}
+
+// LSN range of the records in a log file.
+type lsnSpan struct {
+	first   uint64
+	last    uint64
+	records uint64
+}
+
+// Manifest of a segmented write-ahead log.
+type Manifest struct {
+	// Records with an LSN at or below this have been applied.
+	Checkpoint uint64
+
+	// Segments, oldest first.  The last segment is the active one.
+	Segments []SegmentInfo
+}
+
+// ** Methods:
+
+// Set the segment's LSN range and size.
+func (si *SegmentInfo) setSpan(span lsnSpan, bytes int64) {
+	si.FirstLSN = span.first
+	si.LastLSN = span.last
+	si.Records = span.records
+	si.Bytes = bytes
+}
+
+// Note that a record has been appended.
+func (ls *lsnSpan) observe(lsn uint64) {
+	if ls.records == 0 {
+		ls.first = lsn
+	}
+
+	if lsn > ls.last || ls.records == 0 {
+		ls.last = lsn
+	}
+
+	ls.records++
+}
+
+// Is every record in the segment covered by the given checkpoint?
+func (si *SegmentInfo) coveredBy(checkpoint uint64) bool {
+	return si.Records == 0 || si.LastLSN <= checkpoint
+}
+
+func (m *Manifest) marshal(tab *crc32.Table) []byte {
+	size := manifestHeadSize + len(m.Segments)*manifestEntrySize + i32Size
+	buf := make([]byte, size)
+	enc := newEncoder(buf)
+
+	enc.u32(ManifestMagic)
+	enc.u32(ManifestVersion)
+	enc.u64(m.Checkpoint)
+	enc.u32(uint32(len(m.Segments))) //nolint:gosec
+
+	for idx := range m.Segments {
+		seg := &m.Segments[idx]
+
+		var flags uint32
+
+		if seg.Sealed {
+			flags |= segmentSealed
+		}
+
+		created, _ := tstampU64(seg.Created.Unix())
+
+		enc.u64(seg.Seq)
+		enc.u64(seg.FirstLSN)
+		enc.u64(seg.LastLSN)
+		enc.u64(seg.Records)
+		enc.u64(uint64(seg.Bytes)) //nolint:gosec
+		enc.u64(created)
+		enc.u32(flags)
+	}
+
+	enc.u32(crc32.Checksum(buf[:enc.offset], tab))
+
+	return buf
+}
+
+// ** Functions:
+
+// Return the path of the segment with the given sequence number.
+func SegmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir,
+		fmt.Sprintf("%0*d%s", segmentNameDigits, seq, SegmentSuffix))
+}
+
+// Parse a segment file name into its sequence number.
+func parseSegmentName(name string) (uint64, bool) {
+	stem, found := strings.CutSuffix(name, SegmentSuffix)
+	if !found || len(stem) != segmentNameDigits {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseUint(stem, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return seq, true
+}
+
+//nolint:cyclop
+func unmarshalManifest(data []byte, tab *crc32.Table) (Manifest, error) {
+	var man Manifest
+
+	if len(data) < manifestHeadSize+i32Size {
+		return man, errors.WithMessage(ErrInvalidManifest, "too short")
+	}
+
+	body := data[:len(data)-i32Size]
+	crcDec := newDecoder(data[len(body):])
+
+	if want, _ := crcDec.u32(); crc32.Checksum(body, tab) != want {
+		return man, errors.WithMessage(ErrInvalidManifest, "CRC mismatch")
+	}
+
+	dec := newDecoder(body)
+	magic, _ := dec.u32()
+	version, _ := dec.u32()
+	man.Checkpoint, _ = dec.u64()
+	count, _ := dec.u32()
+
+	if magic != ManifestMagic {
+		return man, errors.WithMessagef(ErrInvalidManifest,
+			"bad magic %08x",
+			magic)
+	}
+
+	if version == 0 || version > ManifestVersion {
+		return man, errors.WithMessagef(ErrInvalidManifest,
+			"unsupported version %d",
+			version)
+	}
+
+	if int64(count)*manifestEntrySize != dec.length-dec.offset {
+		return man, errors.WithMessagef(ErrInvalidManifest,
+			"%d entries do not fit",
+			count)
+	}
+
+	man.Segments = make([]SegmentInfo, count)
+
+	for idx := range man.Segments {
+		seg := &man.Segments[idx]
+
+		seg.Seq, _ = dec.u64()
+		seg.FirstLSN, _ = dec.u64()
+		seg.LastLSN, _ = dec.u64()
+		seg.Records, _ = dec.u64()
+		size, _ := dec.u64()
+		created, _ := dec.u64()
+		flags, _ := dec.u32()
+
+		stamp, err := u64Tstamp(created)
+		if err != nil {
+			return man, errors.WithStack(err)
+		}
+
+		if size > uint64(1<<62) || (idx > 0 && seg.Seq <= man.Segments[idx-1].Seq) {
+			return man, errors.WithMessagef(ErrInvalidManifest,
+				"bad entry %d",
+				idx)
+		}
+
+		seg.Bytes = int64(size)
+		seg.Created = time.Unix(stamp, 0)
+		seg.Sealed = flags&segmentSealed != 0
+	}
+
+	return man, nil
+}
+
+// Read the manifest of the segmented log in the given directory.
+//
+// Returns an error wrapping `fs.ErrNotExist` if there is no manifest.
+func ReadManifest(dir string) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return Manifest{}, errors.WithStack(err)
+	}
+
+	return unmarshalManifest(data, crc32.MakeTable(crc32.Castagnoli))
+}
+
+// Atomically replace the manifest in the given directory.
+func writeManifest(dir string, man *Manifest, tab *crc32.Table) error {
+	final := filepath.Join(dir, ManifestName)
+	temp := final + ".tmp"
+
+	fptr, err := os.OpenFile(temp,
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		defaultFileMode)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := writeFullAt(fptr, man.marshal(tab), 0); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if err := fptr.Sync(); err != nil {
+		_ = fptr.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if err := fptr.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.Rename(temp, final); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return syncDir(dir)
+}
+
+// Fsync a directory so that renames and removals within it are durable.
+func syncDir(dir string) error {
+	fptr, err := os.Open(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer fptr.Close()
+
+	return errors.WithStack(fptr.Sync())
+}
+
+// * manifest.go ends here.
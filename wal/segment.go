@@ -0,0 +1,603 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// segment.go --- Segmented write-ahead log.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+//
+//go:build amd64 || arm64 || riscv64
+
+// * Comments:
+
+//
+// A segmented log is a directory holding a manifest and a run of ordinary
+// write-ahead log files, one per segment.  Only the last segment receives
+// appends; it is sealed and a new one created once the size or age limits
+// in the policy are reached, or when `Rotate` is called.
+//
+// Sealed segments whose records are all at or below the checkpoint LSN are
+// deleted by `Checkpoint`.
+//
+// Appends hold the read lock, so that synchronous appends can queue up for
+// a group commit on the active segment together; anything that replaces the
+// active segment or rewrites the manifest holds the write lock.
+//
+// On open, segment files that the manifest does not mention are left over
+// from an interrupted rotation or checkpoint and are removed, and a torn
+// tail on the active segment is truncated away.
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"context"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Asmodai/gohacks/logger"
+	"gitlab.com/tozd/go/errors"
+)
+
+// * Code:
+
+// ** Types:
+
+type segmentedLog struct {
+	lgr      logger.Logger
+	ctx      context.Context
+	crcTab   *crc32.Table
+	active   *writeAheadLog
+	follow   notifier
+	dir      string
+	manifest Manifest
+	policy   Policy
+	mu       sync.RWMutex
+}
+
+// ** Methods:
+
+// Return the manifest entry of the active segment.
+func (sl *segmentedLog) activeInfo() *SegmentInfo {
+	return &sl.manifest.Segments[len(sl.manifest.Segments)-1]
+}
+
+// Open the segment with the given sequence number for appending.
+func (sl *segmentedLog) openSegment(seq uint64) (*writeAheadLog, error) {
+	log, err := OpenWALWithPolicy(sl.ctx, SegmentPath(sl.dir, seq), sl.policy)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	seg, ok := log.(*writeAheadLog)
+	if !ok {
+		_ = log.Close()
+
+		return nil, errors.WithStack(ErrInvalidLog)
+	}
+
+	return seg, nil
+}
+
+// Should the active segment be rolled before the next append?
+func (sl *segmentedLog) needsRotation() bool {
+	span, bytes := sl.active.snapshot()
+
+	if span.records == 0 {
+		return false
+	}
+
+	if sl.policy.SegmentMaxBytes > 0 && bytes >= sl.policy.SegmentMaxBytes {
+		return true
+	}
+
+	return sl.policy.SegmentMaxAge > 0 &&
+		time.Since(sl.activeInfo().Created) >= sl.policy.SegmentMaxAge
+}
+
+// Run an append against the active segment, rotating first if needed.
+func (sl *segmentedLog) withActive(appendFn func(*writeAheadLog) error) error {
+	sl.mu.RLock()
+
+	if sl.needsRotation() {
+		sl.mu.RUnlock()
+		sl.mu.Lock()
+
+		// Someone else may have rotated in the meantime.
+		if sl.needsRotation() {
+			if err := sl.rotateLocked(); err != nil {
+				sl.mu.Unlock()
+
+				return errors.WithStack(err)
+			}
+		}
+
+		sl.mu.Unlock()
+		sl.mu.RLock()
+	}
+
+	defer sl.mu.RUnlock()
+
+	if err := appendFn(sl.active); err != nil {
+		return errors.WithStack(err)
+	}
+
+	sl.follow.broadcast()
+
+	return nil
+}
+
+// Seal the active segment and start a new one.
+//
+// The new segment is created and recorded in the manifest before the old
+// one is closed, so a failure leaves the old segment active.
+func (sl *segmentedLog) rotateLocked() error {
+	prev := *sl.activeInfo()
+	seq := prev.Seq + 1
+
+	next, err := sl.openSegment(seq)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	man := sl.snapshotManifest()
+	sealed := &man.Segments[len(man.Segments)-1]
+	sealed.Sealed = true
+
+	man.Segments = append(man.Segments, SegmentInfo{
+		Seq:     seq,
+		Bytes:   HeaderSize,
+		Created: time.Now()})
+
+	if err := writeManifest(sl.dir, &man, sl.crcTab); err != nil {
+		_ = next.Close()
+		_ = os.Remove(SegmentPath(sl.dir, seq))
+
+		return errors.WithStack(err)
+	}
+
+	old := sl.active
+	sl.active = next
+	sl.manifest = man
+
+	sl.follow.broadcast()
+
+	if err := old.Close(); err != nil {
+		sl.lgr.Warn(
+			"Write Ahead Log could not close sealed segment",
+			"segment", prev.Seq,
+			"err", err.Error())
+	}
+
+	return nil
+}
+
+// Return a deep copy of the manifest, with the active segment's entry
+// brought up to date.
+func (sl *segmentedLog) snapshotManifest() Manifest {
+	segs := make([]SegmentInfo, len(sl.manifest.Segments), len(sl.manifest.Segments)+1)
+	copy(segs, sl.manifest.Segments)
+	segs[len(segs)-1].setSpan(sl.active.snapshot())
+
+	return Manifest{
+		Checkpoint: sl.manifest.Checkpoint,
+		Segments:   segs}
+}
+
+// Remove the given segment files, logging failures.
+func (sl *segmentedLog) removeSegments(segs []SegmentInfo) {
+	for idx := range segs {
+		err := os.Remove(SegmentPath(sl.dir, segs[idx].Seq))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			sl.lgr.Warn(
+				"Write Ahead Log could not remove segment",
+				"segment", segs[idx].Seq,
+				"err", err.Error())
+		}
+	}
+}
+
+func (sl *segmentedLog) Append(lsn uint64, tstamp int64, key, val []byte) error {
+	return sl.withActive(func(seg *writeAheadLog) error {
+		return seg.Append(lsn, tstamp, key, val)
+	})
+}
+
+func (sl *segmentedLog) AppendSync(lsn uint64, tstamp int64, key, val []byte) error {
+	return sl.withActive(func(seg *writeAheadLog) error {
+		return seg.AppendSync(lsn, tstamp, key, val)
+	})
+}
+
+func (sl *segmentedLog) Rotate() error {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	return sl.rotateLocked()
+}
+
+func (sl *segmentedLog) Checkpoint(lsn uint64) error {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	if lsn < sl.manifest.Checkpoint {
+		return nil
+	}
+
+	current := sl.snapshotManifest()
+	man := Manifest{Checkpoint: lsn}
+	last := len(current.Segments) - 1
+
+	var doomed []SegmentInfo
+
+	for idx, seg := range current.Segments {
+		if idx < last && seg.coveredBy(lsn) {
+			doomed = append(doomed, seg)
+
+			continue
+		}
+
+		man.Segments = append(man.Segments, seg)
+	}
+
+	if err := writeManifest(sl.dir, &man, sl.crcTab); err != nil {
+		return errors.WithStack(err)
+	}
+
+	sl.manifest = man
+	sl.removeSegments(doomed)
+
+	return nil
+}
+
+func (sl *segmentedLog) CheckpointLSN() uint64 {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+
+	return sl.manifest.Checkpoint
+}
+
+func (sl *segmentedLog) Segments() []SegmentInfo {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+
+	return sl.snapshotManifest().Segments
+}
+
+// Replay walks the segments in order.
+//
+// Sealed segments that lie wholly at or below `baseLSN` are skipped without
+// being read.  Each segment is read through its own file handle, so
+// rotation and checkpoints may proceed concurrently; a segment deleted by a
+// checkpoint before it could be opened is skipped.
+func (sl *segmentedLog) Replay(baseLSN uint64, applyCb ApplyCallbackFn) (uint64, error) {
+	maxLSN := baseLSN
+
+	for _, seg := range sl.Segments() {
+		if seg.Sealed && seg.coveredBy(baseLSN) {
+			continue
+		}
+
+		segLSN, err := sl.replaySegment(seg.Seq, baseLSN, applyCb)
+		if err != nil {
+			return maxLSN, errors.WithStack(err)
+		}
+
+		maxLSN = max(maxLSN, segLSN)
+	}
+
+	return maxLSN, nil
+}
+
+func (sl *segmentedLog) replaySegment(seq, baseLSN uint64, applyCb ApplyCallbackFn) (uint64, error) {
+	seg, err := openLogReader(sl.lgr, SegmentPath(sl.dir, seq), sl.policy)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return baseLSN, nil
+
+	case err != nil:
+		return baseLSN, errors.WithStack(err)
+	}
+
+	defer seg.Close()
+
+	return seg.Replay(baseLSN, applyCb)
+}
+
+func (sl *segmentedLog) SetPolicy(pol Policy) {
+	pol.sanity()
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	sl.policy = pol
+	sl.active.SetPolicy(pol)
+}
+
+func (sl *segmentedLog) Sync() error {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+
+	return errors.WithStack(sl.active.Sync())
+}
+
+// Reset discards every segment and starts afresh with a single empty one.
+//
+// The checkpoint LSN is preserved.
+func (sl *segmentedLog) Reset() error {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	seq := sl.activeInfo().Seq + 1
+
+	next, err := sl.openSegment(seq)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	man := Manifest{
+		Checkpoint: sl.manifest.Checkpoint,
+		Segments: []SegmentInfo{{
+			Seq:     seq,
+			Bytes:   HeaderSize,
+			Created: time.Now()}}}
+
+	if err := writeManifest(sl.dir, &man, sl.crcTab); err != nil {
+		_ = next.Close()
+		_ = os.Remove(SegmentPath(sl.dir, seq))
+
+		return errors.WithStack(err)
+	}
+
+	old := sl.active
+	doomed := sl.manifest.Segments
+
+	sl.active = next
+	sl.manifest = man
+
+	_ = old.Close()
+	sl.removeSegments(doomed)
+	sl.follow.broadcast()
+
+	return nil
+}
+
+func (sl *segmentedLog) Close() error {
+	sl.follow.close()
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	return errors.WithStack(sl.active.Close())
+}
+
+// Remove segment files that the manifest does not mention.
+func (sl *segmentedLog) removeOrphans() error {
+	entries, err := os.ReadDir(sl.dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	known := make(map[uint64]struct{}, len(sl.manifest.Segments))
+
+	for _, seg := range sl.manifest.Segments {
+		known[seg.Seq] = struct{}{}
+	}
+
+	for _, entry := range entries {
+		seq, ok := parseSegmentName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		if _, found := known[seq]; found {
+			continue
+		}
+
+		sl.lgr.Info(
+			"Write Ahead Log removing orphaned segment",
+			"segment", seq)
+
+		if err := os.Remove(SegmentPath(sl.dir, seq)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// Open the active segment, recovering its LSN range and truncating any
+// torn tail.
+func (sl *segmentedLog) recoverActive() error {
+	info := sl.activeInfo()
+
+	seg, err := sl.openSegment(info.Seq)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var span lsnSpan
+
+	_, end, err := seg.replay(0, func(lsn uint64, _ int64, _, _ []byte) error {
+		span.observe(lsn)
+
+		return nil
+	})
+	if err != nil {
+		_ = seg.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if end < seg.bytes {
+		sl.lgr.Info(
+			"Write Ahead Log truncating torn segment tail",
+			"segment", info.Seq,
+			"bytes", seg.bytes-end)
+
+		if err := seg.fptr.Truncate(end); err != nil {
+			_ = seg.Close()
+
+			return errors.WithStack(err)
+		}
+
+		seg.bytes = end
+	}
+
+	seg.span = span
+	info.setSpan(span, seg.bytes)
+	sl.active = seg
+
+	return nil
+}
+
+// ** Functions:
+
+// Open a log file read-only.
+func openLogReader(lgr logger.Logger, path string, pol Policy) (*writeAheadLog, error) {
+	fptr, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	finfo, err := fptr.Stat()
+	if err != nil {
+		_ = fptr.Close()
+
+		return nil, errors.WithStack(err)
+	}
+
+	if finfo.Size() < HeaderSize {
+		_ = fptr.Close()
+
+		return nil, errors.WithStack(ErrInvalidLog)
+	}
+
+	hdrOk, hdr, err := readHeader(fptr)
+	if err != nil {
+		_ = fptr.Close()
+
+		return nil, errors.WithStack(err)
+	}
+
+	if !hdrOk {
+		_ = fptr.Close()
+
+		return nil, errors.WithStack(ErrInvalidHeader)
+	}
+
+	pol.sanity()
+
+	codec, err := newRecordCodec(hdr.Features, pol)
+	if err != nil {
+		_ = fptr.Close()
+
+		return nil, errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &writeAheadLog{
+		lgr:    lgr,
+		ctx:    ctx,
+		cancel: cancel,
+		crcTab: crc32.MakeTable(crc32.Castagnoli),
+		codec:  codec,
+		path:   path,
+		fptr:   fptr,
+		bytes:  finfo.Size(),
+		policy: pol}, nil
+}
+
+// Open a segmented write-ahead log in the given directory.
+//
+// The directory is created if it does not exist.
+//
+//nolint:funlen
+func OpenSegmentedWAL(parent context.Context, dir string, pol Policy) (SegmentedWriteAheadLog, error) {
+	pol.sanity()
+
+	if err := os.MkdirAll(dir, defaultDirMode); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sl := &segmentedLog{
+		lgr:    logger.MustGetLogger(parent),
+		ctx:    parent,
+		crcTab: crc32.MakeTable(crc32.Castagnoli),
+		dir:    dir,
+		policy: pol}
+
+	man, err := ReadManifest(dir)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		man = Manifest{Segments: []SegmentInfo{{
+			Seq:     1,
+			Bytes:   HeaderSize,
+			Created: time.Now()}}}
+
+		sl.manifest = man
+
+		// Clear out anything left by a creation that never got as
+		// far as writing a manifest.
+		if err := sl.removeOrphans(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if err := writeManifest(dir, &man, sl.crcTab); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+	case err != nil:
+		return nil, errors.WithStack(err)
+
+	case len(man.Segments) == 0:
+		return nil, errors.WithMessage(ErrInvalidManifest, "no segments")
+
+	default:
+		sl.manifest = man
+
+		if err := sl.removeOrphans(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if err := sl.recoverActive(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return sl, nil
+}
+
+// * segment.go ends here.
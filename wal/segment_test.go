@@ -0,0 +1,346 @@
+// -*- Mode: Go; auto-fill: t; fill-column: 78; -*-
+//
+// SPDX-License-Identifier: MIT
+//
+// segment_test.go --- Segmented write-ahead log tests.
+//
+// Copyright (c) 2026 Paul Ward <paul@lisphacker.uk>
+//
+// Author:     Paul Ward <paul@lisphacker.uk>
+// Maintainer: Paul Ward <paul@lisphacker.uk>
+//
+// Permission is hereby granted, free of charge, to any person
+// obtaining a copy of this software and associated documentation files
+// (the "Software"), to deal in the Software without restriction,
+// including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS
+// BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN
+// ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// * Comments:
+
+// * Package:
+
+package wal
+
+// * Imports:
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// * Code:
+
+// ** helpers:
+
+func openSegmented(t *testing.T, dir string, pol Policy) SegmentedWriteAheadLog {
+	t.Helper()
+	w, err := OpenSegmentedWAL(makeLogger(), dir, pol)
+	if err != nil {
+		t.Fatalf("open segmented: %v", err)
+	}
+	return w
+}
+
+func appendRange(t *testing.T, w WriteAheadLog, from, to uint64) {
+	t.Helper()
+	for lsn := from; lsn <= to; lsn++ {
+		mustAppend(t, w, lsn, int64(lsn), []byte(fmt.Sprintf("k%d", lsn)), randBytes(32))
+	}
+}
+
+func replayLSNs(t *testing.T, w WriteAheadLog, base uint64) []uint64 {
+	t.Helper()
+	recs, err := collectReplay(w, base)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	out := make([]uint64, 0, len(recs))
+	for _, r := range recs {
+		out = append(out, r.LSN)
+	}
+	return out
+}
+
+func checkLSNs(t *testing.T, got []uint64, from, to uint64) {
+	t.Helper()
+	if uint64(len(got)) != to-from+1 {
+		t.Fatalf("replayed %d records, want %d", len(got), to-from+1)
+	}
+	for i, lsn := range got {
+		if lsn != from+uint64(i) {
+			t.Fatalf("record %d has lsn %d, want %d", i, lsn, from+uint64(i))
+		}
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+SegmentSuffix))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	return files
+}
+
+// ** Tests:
+
+func TestSegmented_RotatesBySize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pol := tinyPolicy()
+	pol.SegmentMaxBytes = 512
+	w := openSegmented(t, dir, pol)
+	defer w.Close()
+
+	appendRange(t, w, 1, 40)
+
+	segs := w.Segments()
+	if len(segs) < 3 {
+		t.Fatalf("expected several segments, got %d", len(segs))
+	}
+
+	next := uint64(1)
+	for i, seg := range segs {
+		if seg.Sealed != (i < len(segs)-1) {
+			t.Fatalf("segment %d sealed=%v", seg.Seq, seg.Sealed)
+		}
+		if seg.FirstLSN != next || seg.Records != seg.LastLSN-seg.FirstLSN+1 {
+			t.Fatalf("segment %d range %d..%d (%d records), want start %d",
+				seg.Seq, seg.FirstLSN, seg.LastLSN, seg.Records, next)
+		}
+		if seg.Sealed && seg.Bytes < pol.SegmentMaxBytes {
+			t.Fatalf("segment %d sealed early at %d bytes", seg.Seq, seg.Bytes)
+		}
+		next = seg.LastLSN + 1
+	}
+
+	if got := len(segmentFiles(t, dir)); got != len(segs) {
+		t.Fatalf("%d segment files for %d segments", got, len(segs))
+	}
+
+	checkLSNs(t, replayLSNs(t, w, 0), 1, 40)
+	checkLSNs(t, replayLSNs(t, w, 25), 26, 40)
+}
+
+func TestSegmented_RotatesByAge(t *testing.T) {
+	t.Parallel()
+
+	pol := tinyPolicy()
+	pol.SegmentMaxAge = time.Nanosecond
+	w := openSegmented(t, t.TempDir(), pol)
+	defer w.Close()
+
+	appendRange(t, w, 1, 3)
+
+	if got := len(w.Segments()); got != 3 {
+		t.Fatalf("expected one segment per record, got %d", got)
+	}
+}
+
+func TestSegmented_CheckpointDeletesSegments(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w := openSegmented(t, dir, tinyPolicy())
+
+	for i := uint64(0); i < 4; i++ {
+		appendRange(t, w, i*10+1, i*10+10)
+		if err := w.Rotate(); err != nil {
+			t.Fatalf("rotate: %v", err)
+		}
+	}
+	appendRange(t, w, 41, 45)
+
+	// 25 is inside the third segment, so only two go.
+	if err := w.Checkpoint(25); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+
+	segs := w.Segments()
+	if len(segs) != 3 || segs[0].FirstLSN != 21 {
+		t.Fatalf("unexpected segments after checkpoint: %+v", segs)
+	}
+	if got := len(segmentFiles(t, dir)); got != 3 {
+		t.Fatalf("%d segment files remain, want 3", got)
+	}
+
+	checkLSNs(t, replayLSNs(t, w, 0), 21, 45)
+
+	// Checkpoints never move backwards.
+	if err := w.Checkpoint(5); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if got := w.CheckpointLSN(); got != 25 {
+		t.Fatalf("checkpoint moved to %d", got)
+	}
+
+	// The active segment survives even when wholly covered.
+	if err := w.Checkpoint(100); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if segs := w.Segments(); len(segs) != 1 || segs[0].Sealed {
+		t.Fatalf("unexpected segments: %+v", segs)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	w = openSegmented(t, dir, tinyPolicy())
+	defer w.Close()
+
+	if got := w.CheckpointLSN(); got != 100 {
+		t.Fatalf("checkpoint not persisted: %d", got)
+	}
+	checkLSNs(t, replayLSNs(t, w, 0), 41, 45)
+}
+
+func TestSegmented_ReopenRecoversActive(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pol := tinyPolicy()
+	pol.SegmentMaxBytes = 1024
+	w := openSegmented(t, dir, pol)
+
+	appendRange(t, w, 1, 30)
+	before := w.Segments()
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	w = openSegmented(t, dir, pol)
+	defer w.Close()
+
+	after := w.Segments()
+	if len(after) != len(before) {
+		t.Fatalf("segment count changed: %d != %d", len(after), len(before))
+	}
+	last, want := after[len(after)-1], before[len(before)-1]
+	if last.FirstLSN != want.FirstLSN || last.LastLSN != want.LastLSN ||
+		last.Records != want.Records || last.Bytes != want.Bytes {
+		t.Fatalf("active segment not recovered: %+v != %+v", last, want)
+	}
+
+	appendRange(t, w, 31, 50)
+	checkLSNs(t, replayLSNs(t, w, 0), 1, 50)
+}
+
+func TestSegmented_TruncatesTornTail(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w := openSegmented(t, dir, tinyPolicy())
+	appendRange(t, w, 1, 5)
+	seq := w.Segments()[0].Seq
+	_ = w.Close()
+
+	f, err := os.OpenFile(SegmentPath(dir, seq), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	_, _ = f.Write([]byte{0x40, 0, 0, 0, 1, 2, 3})
+	_ = f.Close()
+
+	w = openSegmented(t, dir, tinyPolicy())
+	defer w.Close()
+
+	appendRange(t, w, 6, 8)
+	checkLSNs(t, replayLSNs(t, w, 0), 1, 8)
+}
+
+func TestSegmented_RemovesOrphans(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w := openSegmented(t, dir, tinyPolicy())
+	appendRange(t, w, 1, 3)
+	_ = w.Close()
+
+	orphan := SegmentPath(dir, 99)
+	if err := os.WriteFile(orphan, []byte("junk"), 0o644); err != nil {
+		t.Fatalf("write orphan: %v", err)
+	}
+
+	w = openSegmented(t, dir, tinyPolicy())
+	defer w.Close()
+
+	if _, err := os.Stat(orphan); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("orphan survived: %v", err)
+	}
+	checkLSNs(t, replayLSNs(t, w, 0), 1, 3)
+}
+
+func TestSegmented_Reset(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w := openSegmented(t, dir, tinyPolicy())
+	defer w.Close()
+
+	appendRange(t, w, 1, 5)
+	_ = w.Rotate()
+	appendRange(t, w, 6, 10)
+	_ = w.Checkpoint(3)
+
+	if err := w.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	if got := replayLSNs(t, w, 0); len(got) != 0 {
+		t.Fatalf("records survived reset: %v", got)
+	}
+	if got := len(segmentFiles(t, dir)); got != 1 {
+		t.Fatalf("%d segment files after reset", got)
+	}
+	if got := w.CheckpointLSN(); got != 3 {
+		t.Fatalf("checkpoint lost: %d", got)
+	}
+
+	appendRange(t, w, 11, 12)
+	checkLSNs(t, replayLSNs(t, w, 0), 11, 12)
+}
+
+func TestSegmented_InvalidManifest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w := openSegmented(t, dir, tinyPolicy())
+	_ = w.Close()
+
+	path := filepath.Join(dir, ManifestName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	if _, err := OpenSegmentedWAL(makeLogger(), dir, tinyPolicy()); !errors.Is(err, ErrInvalidManifest) {
+		t.Fatalf("expected ErrInvalidManifest, got %v", err)
+	}
+}
+
+// * segment_test.go ends here.
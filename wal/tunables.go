@@ -59,6 +59,49 @@ type Policy struct {
 
 	// Maximum size of a key in bytes.
 	MaxKeyBytes uint32
+
+	// SegmentMaxBytes: roll to a new segment once the active segment
+	// holds at least this many bytes.
+	//
+	// Only used by segmented logs.  0 disables size-based rotation.
+	SegmentMaxBytes int64
+
+	// SegmentMaxAge: roll to a new segment once the active segment is
+	// at least this old.  The age is checked on append.
+	//
+	// Only used by segmented logs.  0 disables age-based rotation.
+	SegmentMaxAge time.Duration
+
+	// Compress: DEFLATE-compress record payloads where doing so makes
+	// them smaller.
+	//
+	// Only applies to logs created with this policy; existing logs keep
+	// the features recorded in their header.
+	Compress bool
+
+	// EncryptionKey: seal record payloads with AES-GCM under this key,
+	// which must be 16, 24 or 32 bytes long.
+	//
+	// New logs created with a key are encrypted.  Opening an encrypted
+	// log requires its key, and an unencrypted log cannot be opened
+	// for writing with one.  The key must not change for the life of
+	// the log.
+	EncryptionKey []byte
+}
+
+// Header features for logs created with this policy.
+func (pol *Policy) features() uint64 {
+	var features uint64
+
+	if pol.Compress {
+		features |= FeatureCompressed
+	}
+
+	if len(pol.EncryptionKey) > 0 {
+		features |= FeatureEncrypted
+	}
+
+	return features
 }
 
 func (pol *Policy) sanity() {
@@ -77,6 +120,14 @@ func (pol *Policy) sanity() {
 	if pol.SyncEveryBytes < 0 {
 		pol.SyncEveryBytes = 0
 	}
+
+	if pol.SegmentMaxBytes < 0 {
+		pol.SegmentMaxBytes = 0
+	}
+
+	if pol.SegmentMaxAge < 0 {
+		pol.SegmentMaxAge = 0
+	}
 }
 
 // * tunables.go ends here.
@@ -37,6 +37,10 @@
 
 package wal
 
+// * Imports:
+
+import "context"
+
 // * Code:
 
 type ApplyCallbackFn func(lsn uint64, tstamp int64, key, value []byte) error
@@ -104,6 +108,20 @@ type WriteAheadLog interface {
 	//   • Call Sync() to force durability.
 	Append(lsn uint64, tstamp int64, key, value []byte) error
 
+	// AppendSync writes one record to the log and returns once it is
+	// durable.
+	//
+	// Concurrent callers are batched: records queued while an fsync is
+	// in progress are written together with one write and one fsync
+	// (group commit).  Records within a batch are written in the order
+	// they were submitted.
+	//
+	// Errors:
+	//   • As for Append.
+	//   • If the batch's write or fsync fails, every caller in the batch
+	//     receives the error.
+	AppendSync(lsn uint64, tstamp int64, key, value []byte) error
+
 	// Replay re-applies records with LSN > baseLSN in log order.
 	//
 	// The supplied callback is invoked for each record; if the callback
@@ -116,6 +134,20 @@ type WriteAheadLog interface {
 	//     are preserved.
 	Replay(baseLSN uint64, applyCb ApplyCallbackFn) (uint64, error)
 
+	// Follow delivers records with LSN > baseLSN in log order, as Replay
+	// does, and then blocks waiting for new records, delivering each as
+	// it is appended.
+	//
+	// Follow returns when the context is done, when the callback returns
+	// an error, or with ErrLogClosed when the log is closed.  It returns
+	// the highest LSN delivered.
+	//
+	// Robustness:
+	//   • Stops at incomplete or corrupt records just as Replay does, and
+	//     retries from there when woken.
+	//   • If the log is reset, following resumes from the new start.
+	Follow(ctx context.Context, baseLSN uint64, applyCb ApplyCallbackFn) (uint64, error)
+
 	// SetPolicy atomically updates durability and size limits at runtime.
 	//
 	// Notes:
@@ -127,6 +159,10 @@ type WriteAheadLog interface {
 	// Sync forces an fsync if there are dirty bytes; otherwise it is a
 	// no-op.
 	//
+	// Appends may proceed while the fsync is in progress.  Concurrent
+	// callers share fsyncs, so this can be used for group commit by
+	// callers that must append in order.
+	//
 	// Returns any I/O error encountered.
 	Sync() error
 
@@ -144,4 +180,53 @@ type WriteAheadLog interface {
 	Close() error
 }
 
+// SegmentedWriteAheadLog is a write-ahead log split across segment files.
+//
+// Appends go to the active segment, which is sealed and replaced by a new
+// one once Policy.SegmentMaxBytes or Policy.SegmentMaxAge is reached.  A
+// manifest records each segment's LSN range and the checkpoint LSN.
+//
+// Reclamation:
+//   - Checkpoint(lsn) declares that every record with an LSN at or below
+//     lsn has been applied.  Sealed segments wholly covered by the
+//     checkpoint are deleted.  The active segment is never deleted.
+//   - Reset discards all segments but keeps the checkpoint.
+//
+// Replay walks the segments oldest first and has the same semantics as for
+// a single-file log.
+//
+// Example:
+//
+// ```go
+//
+//	w, _ := wal.OpenSegmentedWAL(ctx, "data", wal.Policy{
+//		SegmentMaxBytes: 64 << 20,
+//	})
+//	defer w.Close()
+//	_ = w.Append(next, time.Now().Unix(), []byte("k"), []byte("v"))
+//	_ = w.Checkpoint(applied)
+//
+// ```
+type SegmentedWriteAheadLog interface {
+	WriteAheadLog
+
+	// Rotate seals the active segment and starts a new one, regardless
+	// of policy.
+	Rotate() error
+
+	// Checkpoint records that all records with an LSN at or below the
+	// given LSN have been applied, and deletes sealed segments that are
+	// wholly covered by it.
+	//
+	// Checkpoints never move backwards; a lower LSN is ignored.
+	Checkpoint(lsn uint64) error
+
+	// CheckpointLSN returns the current checkpoint LSN.
+	CheckpointLSN() uint64
+
+	// Segments returns a snapshot of the manifest's segments, oldest
+	// first.
+	Segments() []SegmentInfo
+}
+
 // * types.go ends here.
@@ -84,6 +84,8 @@ var (
 	ErrInvalidLog        = errors.Base("WAL log file is invalid")
 	ErrTimestampNegative = errors.Base("negative value timestamp")
 	ErrTimestampTooBig   = errors.Base("timestamp too big")
+	ErrTornRecord        = errors.Base("torn record")
+	ErrCorruptRecord     = errors.Base("corrupt record")
 )
 
 // * Code:
@@ -103,8 +105,15 @@ type recFields struct {
 	val    []byte
 	lsn    uint64
 	tstamp int64
-	klen   uint32
-	vlen   uint32
+	klen   uint32 // Key length as stored.
+	vlen   uint32 // Value length as stored.
+}
+
+// ** Walk result structure:
+
+type walkResult struct {
+	problem error // Why the walk stopped short, if it did.
+	end     int64 // Offset just past the last valid record.
 }
 
 // ** Types:
@@ -117,12 +126,18 @@ type writeAheadLog struct {
 	flushTicker *time.Ticker
 	stopCh      chan struct{}
 	crcTab      *crc32.Table
+	codec       *recordCodec
 	pool        *bufPool
+	group       groupCommit
+	follow      notifier
+	span        lsnSpan
 	path        string
 	policy      Policy
 	bytes       int64
+	resets      uint64
 	lastSyncAt  int64
 	mu          sync.Mutex
+	syncMu      sync.Mutex // Serialises `Sync`.
 	dirty       bool
 }
 
@@ -157,6 +172,30 @@ func (wal *writeAheadLog) validateKV(tstamp int64, key, val []byte) (uint32, uin
 	return klen, vlen, tsu, nil
 }
 
+// Wrap the key and value in an envelope if the log has a codec.
+//
+// Returns the lengths, key and value to store.
+func (wal *writeAheadLog) sealKV(lsn, tsu uint64, klen, vlen uint32, key, val []byte) (uint32, uint32, []byte, []byte, error) {
+	if wal.codec == nil {
+		return klen, vlen, key, val, nil
+	}
+
+	env, err := wal.codec.seal(lsn, tsu, key, val)
+	if err != nil {
+		return 0, 0, nil, nil, errors.WithStack(err)
+	}
+
+	envLen, ok := lenU32(env)
+	if !ok {
+		return 0, 0, nil, nil, errors.WithMessagef(
+			ErrRecordTooLarge,
+			"%d bytes",
+			len(env))
+	}
+
+	return 0, envLen, nil, env, nil
+}
+
 func (wal *writeAheadLog) allocRecordBuf(klen, vlen uint32) (*[]byte, []byte, uint32, error) {
 	size := recordSize(klen, vlen)
 
@@ -186,6 +225,11 @@ func (wal *writeAheadLog) Append(lsn uint64, tstamp int64, key, val []byte) erro
 		return errors.WithStack(err)
 	}
 
+	klen, vlen, key, val, err = wal.sealKV(lsn, tsu, klen, vlen, key, val)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	pbuf, buf, buflenU32, err := wal.allocRecordBuf(klen, vlen)
 	if err != nil {
 		return errors.WithStack(err)
@@ -210,6 +254,8 @@ func (wal *writeAheadLog) Append(lsn uint64, tstamp int64, key, val []byte) erro
 
 	wal.bytes += int64(buflen)
 	wal.dirty = true
+	wal.span.observe(lsn)
+	wal.follow.broadcast()
 
 	if wal.policy.SyncEveryBytes > 0 && (wal.bytes-wal.lastSyncAt) >= wal.policy.SyncEveryBytes {
 		if err := wal.syncLocked(); err != nil {
@@ -220,6 +266,14 @@ func (wal *writeAheadLog) Append(lsn uint64, tstamp int64, key, val []byte) erro
 	return nil
 }
 
+// Return the LSN range and size of the log.
+func (wal *writeAheadLog) snapshot() (lsnSpan, int64) {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	return wal.span, wal.bytes
+}
+
 func (wal *writeAheadLog) readRecSizeAt(pos, end int64) (recSize, bool, error) {
 	var out recSize
 
@@ -283,7 +337,7 @@ func (wal *writeAheadLog) readRecAt(pos int64, size int, scratch []byte) ([]byte
 	return buf, nil
 }
 
-//nolint:cyclop
+//nolint:cyclop,funlen
 func (wal *writeAheadLog) decodeFields(full []byte) (recFields, bool, error) {
 	payload, ok := wal.validateCRC(full)
 	if !ok {
@@ -310,14 +364,19 @@ func (wal *writeAheadLog) decodeFields(full []byte) (recFields, bool, error) {
 		return recFields{}, false, nil
 	}
 
-	if klen > wal.policy.MaxKeyBytes {
+	maxKey, maxVal := wal.policy.MaxKeyBytes, wal.policy.MaxValueBytes
+	if wal.codec != nil {
+		maxKey, maxVal = 0, wal.codec.maxStored()
+	}
+
+	if klen > maxKey {
 		return recFields{}, false, errors.WithMessagef(
 			ErrKeyTooLarge,
 			"key length %d",
 			klen)
 	}
 
-	if vlen > wal.policy.MaxValueBytes {
+	if vlen > maxVal {
 		return recFields{}, false, errors.WithMessagef(
 			ErrValueTooLarge,
 			"value length %d",
@@ -335,6 +394,12 @@ func (wal *writeAheadLog) decodeFields(full []byte) (recFields, bool, error) {
 		return recFields{}, false, nil
 	}
 
+	if wal.codec != nil {
+		if key, val, err = wal.codec.open(lsn, tsu, val); err != nil {
+			return recFields{}, false, errors.WithStack(err)
+		}
+	}
+
 	return recFields{
 			lsn:    lsn,
 			tstamp: tstamp,
@@ -359,17 +424,38 @@ func (wal *writeAheadLog) validateCRC(buf []byte) ([]byte, bool) {
 	return payload, have == want
 }
 
+// The fsync happens outside the lock so that appends can proceed in the
+// meantime.  Only one `Sync` runs at a time, so callers queued behind an
+// fsync usually find that it covered their records and return at once.
 func (wal *writeAheadLog) Sync() error {
+	wal.syncMu.Lock()
+	defer wal.syncMu.Unlock()
+
 	wal.mu.Lock()
-	defer wal.mu.Unlock()
 
 	if !wal.dirty || wal.bytes == wal.lastSyncAt {
+		wal.mu.Unlock()
+
 		return nil
 	}
 
-	result := wal.syncLocked()
+	end, resets := wal.bytes, wal.resets
+	wal.mu.Unlock()
+
+	if err := wal.fptr.Sync(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	// Unless a reset got in first.
+	if resets == wal.resets && end > wal.lastSyncAt {
+		wal.lastSyncAt = end
+		wal.dirty = wal.bytes != wal.lastSyncAt
+	}
 
-	return result
+	return nil
 }
 
 func (wal *writeAheadLog) syncLocked() error {
@@ -393,6 +479,10 @@ func (wal *writeAheadLog) Reset() error {
 	}
 
 	wal.bytes = HeaderSize
+	wal.span = lsnSpan{}
+	wal.resets++
+
+	defer wal.follow.broadcast()
 
 	if err := wal.syncLocked(); err != nil {
 		return errors.WithStack(err)
@@ -401,33 +491,92 @@ func (wal *writeAheadLog) Reset() error {
 	return nil
 }
 
-//nolint:cyclop,funlen
 func (wal *writeAheadLog) Replay(baseLSN uint64, applyCb ApplyCallbackFn) (uint64, error) {
+	maxLSN, _, err := wal.replay(baseLSN, applyCb)
+
+	return maxLSN, err
+}
+
+// Replay the log, also returning the offset just past the last valid
+// record.
+func (wal *writeAheadLog) replay(baseLSN uint64, applyCb ApplyCallbackFn) (uint64, int64, error) {
 	wal.mu.Lock()
 	end := wal.bytes
 	wal.mu.Unlock()
 
-	var (
-		pos     = int64(HeaderSize)
-		maxLSN  = baseLSN
-		scratch []byte
-	)
+	return wal.replayRange(HeaderSize, end, baseLSN, applyCb)
+}
+
+// Replay the records between `pos` and `end`, returning the offset just
+// past the last valid record.
+func (wal *writeAheadLog) replayRange(pos, end int64, baseLSN uint64, applyCb ApplyCallbackFn) (uint64, int64, error) {
+	maxLSN := baseLSN
+
+	res, err := wal.walk(pos, end, func(rec *recFields, _ int64) error {
+		if rec.lsn <= baseLSN {
+			return nil
+		}
+
+		if err := applyCb(rec.lsn, rec.tstamp, rec.key, rec.val); err != nil {
+			wal.lgr.Info(
+				"Write ahead Log callback failed",
+				"err", err.Error())
+
+			return errors.WithStack(err)
+		}
+
+		if rec.lsn > maxLSN {
+			maxLSN = rec.lsn
+		}
+
+		return nil
+	})
+
+	return maxLSN, res.end, err
+}
+
+// Visit each valid record between `pos` and `end`.
+//
+// The walk stops at the first torn or corrupt record, which is reported in
+// the result rather than as an error.  Errors are reserved for I/O
+// failures, records that violate the policy, and errors from the visitor.
+//
+//nolint:cyclop,funlen
+func (wal *writeAheadLog) walk(pos, end int64, visit func(*recFields, int64) error) (walkResult, error) {
+	var scratch []byte
 
 	for pos < end {
 		size, done, err := wal.readRecSizeAt(pos, end)
 
 		if done {
-			break
+			// A size field that was read but is too small is
+			// corrupt; anything else is a torn tail.
+			if size.nextPos != 0 {
+				return walkResult{end: pos, problem: errors.WithMessagef(
+					ErrCorruptRecord,
+					"record size %d at offset %d",
+					size.sizeU32,
+					pos)}, nil
+			}
+
+			return walkResult{end: pos, problem: errors.WithMessagef(
+				ErrTornRecord,
+				"partial size field at offset %d",
+				pos)}, nil
 		}
 
 		if err != nil {
-			return maxLSN, err
+			return walkResult{end: pos}, err
 		}
 
 		total := int(size.sizeU32)
 
 		if pos+int64(i32Size)+int64(total) > end {
-			break
+			return walkResult{end: pos, problem: errors.WithMessagef(
+				ErrTornRecord,
+				"%d byte record at offset %d runs past end",
+				total,
+				pos)}, nil
 		}
 
 		scratch, err = wal.readRecAt(size.nextPos, total, scratch)
@@ -436,7 +585,7 @@ func (wal *writeAheadLog) Replay(baseLSN uint64, applyCb ApplyCallbackFn) (uint6
 				"Write Ahead Log CRC failed",
 				"err", err.Error())
 
-			return maxLSN, errors.WithStack(err)
+			return walkResult{end: pos}, errors.WithStack(err)
 		}
 
 		rec, recOk, err := wal.decodeFields(scratch)
@@ -445,35 +594,28 @@ func (wal *writeAheadLog) Replay(baseLSN uint64, applyCb ApplyCallbackFn) (uint6
 				"Write Ahead Log truncated tail",
 				"err", err.Error())
 
-			return maxLSN, errors.WithStack(err)
+			return walkResult{end: pos}, errors.WithStack(err)
 		}
 
 		if !recOk {
-			break
+			return walkResult{end: pos, problem: errors.WithMessagef(
+				ErrCorruptRecord,
+				"CRC mismatch or malformed record at offset %d",
+				pos)}, nil
 		}
 
-		if rec.lsn > baseLSN {
-			err := applyCb(rec.lsn, rec.tstamp, rec.key, rec.val)
-			if err != nil {
-				wal.lgr.Info(
-					"Write ahead Log callback failed",
-					"err", err.Error())
-
-				return maxLSN, errors.WithStack(err)
-			}
-
-			if rec.lsn > maxLSN {
-				maxLSN = rec.lsn
-			}
+		if err := visit(&rec, pos); err != nil {
+			return walkResult{end: pos}, err
 		}
 
 		pos = size.nextPos + int64(total)
 	}
 
-	return maxLSN, nil
+	return walkResult{end: pos}, nil
 }
 
 func (wal *writeAheadLog) Close() error {
+	wal.follow.close()
 	wal.stopSyncTicker()
 
 	if err := wal.flushIfDirty(); err != nil {
@@ -722,55 +864,78 @@ func OpenWALWithPolicy(parent context.Context, path string, pol Policy) (WriteAh
 
 	pol.sanity()
 
-	ctx, cancel := context.WithCancel(parent)
-
-	maxRecSize := int(recordSize(
-		pol.MaxKeyBytes,
-		pol.MaxValueBytes))
-
-	poolSize, poolOk := fitsAlloc(int64(maxRecSize))
-	if poolOk {
-		pool = newBufPool(int(poolSize))
-	}
-
-	wal := &writeAheadLog{
-		lgr:        lgr,
-		ctx:        ctx,
-		cancel:     cancel,
-		crcTab:     crc32.MakeTable(crc32.Castagnoli),
-		path:       path,
-		fptr:       fptr,
-		bytes:      finfo.Size(),
-		lastSyncAt: finfo.Size(),
-		policy:     pol,
-		pool:       pool}
+	size := finfo.Size()
+	features := currentFeatures | pol.features()
 
 	switch {
-	case finfo.Size() == 0:
-		written, err := writeHeader(fptr)
+	case size == 0:
+		written, err := writeHeader(fptr, features)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
 
-		wal.bytes = written
-		wal.lastSyncAt = written
+		size = written
 
-	case finfo.Size() >= HeaderSize:
-		hdrOk, _, err := readHeader(fptr)
+	case size >= HeaderSize:
+		hdrOk, hdr, err := readHeader(fptr)
 		if err != nil {
+			fptr.Close()
+
 			return nil, errors.WithStack(err)
 		}
 
 		if !hdrOk {
+			fptr.Close()
+
 			return nil, errors.WithStack(ErrInvalidHeader)
 		}
 
+		if err := checkWriteFeatures(hdr.Features, pol); err != nil {
+			fptr.Close()
+
+			return nil, errors.WithStack(err)
+		}
+
+		features = hdr.Features
+
 	default:
 		fptr.Close()
 
 		return nil, errors.WithStack(ErrInvalidLog)
 	}
 
+	codec, err := newRecordCodec(features, pol)
+	if err != nil {
+		fptr.Close()
+
+		return nil, errors.WithStack(err)
+	}
+
+	maxRecSize := recordSize(pol.MaxKeyBytes, pol.MaxValueBytes)
+	if codec != nil {
+		maxRecSize = recordSize(0, codec.maxStored())
+	}
+
+	poolSize, poolOk := fitsAlloc(maxRecSize)
+	if poolOk {
+		pool = newBufPool(int(poolSize))
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+
+	wal := &writeAheadLog{
+		lgr:        lgr,
+		ctx:        ctx,
+		cancel:     cancel,
+		crcTab:     crc32.MakeTable(crc32.Castagnoli),
+		codec:      codec,
+		path:       path,
+		fptr:       fptr,
+		bytes:      size,
+		lastSyncAt: size,
+		policy:     pol,
+		pool:       pool}
+
 	if pol.SyncEvery > 0 {
 		// Context used by this is part of the structure.
 		wal.createSyncTicker()